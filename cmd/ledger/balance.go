@@ -0,0 +1,67 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/x1unix/sbda-ledger/internal/app"
+	"github.com/x1unix/sbda-ledger/internal/config"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/repository"
+	"github.com/x1unix/sbda-ledger/internal/service"
+	"go.uber.org/zap"
+)
+
+const cmdCheckBalance = "check-balance"
+
+// checkBalance compares balance cache with loans journal and prints discrepancies.
+//
+// Returns false if inconsistent balance cache was found and wasn't rebuilt.
+func checkBalance(ctx context.Context, logger *zap.Logger, cfg *config.Config, conns *app.Connectors, args []string) bool {
+	var (
+		rebuild bool
+		users   string
+	)
+
+	fs := flag.NewFlagSet(cmdCheckBalance, flag.ExitOnError)
+	fs.BoolVar(&rebuild, "rebuild", false, "Rebuild inconsistent balance cache")
+	fs.StringVar(&users, "users", "", "Comma-separated list of user IDs to check (default: all users)")
+	_ = fs.Parse(args)
+
+	var ids []string
+	if users != "" {
+		ids = strings.Split(users, ",")
+	}
+
+	uids, err := model.DecodeUUIDs(ids...)
+	if err != nil {
+		logger.Error("invalid user ID", zap.Error(err))
+		return false
+	}
+
+	balanceStore, loansStore, err := app.ProvideBalanceStorage(logger, cfg.Balance, conns)
+	if err != nil {
+		logger.Error("failed to initialize balance storage", zap.Error(err))
+		return false
+	}
+
+	checker := service.NewBalanceChecker(logger, balanceStore, loansStore, repository.NewUserRepository(conns.DB))
+
+	report, err := checker.Check(ctx, uids, rebuild)
+	if err != nil {
+		logger.Error("failed to check balance cache", zap.Error(err))
+		return false
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(report)
+
+	fmt.Printf("%d users checked, %d not cached, %d inconsistent, %d rebuilt\n",
+		report.Checked, report.NotCached, report.Inconsistent, report.Rebuilt)
+	return report.Inconsistent == report.Rebuilt
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"context"
+
+	"github.com/x1unix/sbda-ledger/internal/app"
+	"github.com/x1unix/sbda-ledger/internal/config"
+	"go.uber.org/zap"
+)
+
+// commandFunc is command handler.
+//
+// Returns false if command failed.
+type commandFunc = func(ctx context.Context, logger *zap.Logger, cfg *config.Config, conns *app.Connectors, args []string) bool
+
+type command struct {
+	description string
+	run         commandFunc
+}
+
+var commands = map[string]command{
+	cmdVerifyChain: {
+		description: "verify journal transactions hash chain",
+		run:         verifyChain,
+	},
+	cmdCheckBalance: {
+		description: "check balance cache consistency and optionally rebuild it",
+		run:         checkBalance,
+	},
+}
@@ -2,6 +2,9 @@ package main
 
 import (
 	"flag"
+	"fmt"
+	"os"
+	"sort"
 
 	"github.com/x1unix/sbda-ledger/internal/app"
 	"go.uber.org/zap"
@@ -10,8 +13,19 @@ import (
 func main() {
 	var cfgPath string
 	flag.StringVar(&cfgPath, "config", "", "Path to config file (optional)")
+	flag.Usage = usage
 	flag.Parse()
 
+	var cmd *command
+	if name := flag.Arg(0); name != "" {
+		c, ok := commands[name]
+		if !ok {
+			app.Fatal("unknown command:", name)
+			return
+		}
+		cmd = &c
+	}
+
 	cfg, err := app.ProvideConfig(cfgPath)
 	if err != nil {
 		app.Fatal("failed to read config:", err)
@@ -34,6 +48,37 @@ func main() {
 	}
 
 	defer conns.Close()
-	svc := app.NewService(ctx, logger, conns, cfg)
+	if cmd != nil {
+		if !cmd.run(ctx, logger, cfg, conns, flag.Args()[1:]) {
+			conns.Close()
+			os.Exit(1)
+		}
+		return
+	}
+
+	svc, err := app.NewService(ctx, logger, conns, cfg)
+	if err != nil {
+		logger.Sugar().Fatal(err)
+	}
+
 	svc.Start(ctx)
 }
+
+func usage() {
+	out := flag.CommandLine.Output()
+	_, _ = fmt.Fprintf(out, "Usage: %s [flags] [command] [command flags]\n\n", os.Args[0])
+	_, _ = fmt.Fprintln(out, "Service is started if command is not specified.")
+	_, _ = fmt.Fprintln(out, "\nCommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		_, _ = fmt.Fprintf(out, "  %s\t%s\n", name, commands[name].description)
+	}
+
+	_, _ = fmt.Fprintln(out, "\nFlags:")
+	flag.PrintDefaults()
+}
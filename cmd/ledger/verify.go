@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+
+	"github.com/x1unix/sbda-ledger/internal/app"
+	"github.com/x1unix/sbda-ledger/internal/config"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/repository"
+	"github.com/x1unix/sbda-ledger/internal/service"
+	"go.uber.org/zap"
+)
+
+const cmdVerifyChain = "verify-chain"
+
+// verifyChain walks through journal transactions chain and prints first broken link.
+//
+// Printed chain head should be stored outside of database and passed
+// to the next verification to detect chain truncation.
+//
+// Returns false if chain is broken or verification failed.
+func verifyChain(ctx context.Context, logger *zap.Logger, _ *config.Config, conns *app.Connectors, args []string) bool {
+	var headStr string
+	fs := flag.NewFlagSet(cmdVerifyChain, flag.ExitOnError)
+	fs.StringVar(&headStr, "head", "", "Previously recorded chain head hash, used to detect chain truncation")
+	_ = fs.Parse(args)
+
+	var head loan.Hash
+	if headStr != "" {
+		var err error
+		if head, err = loan.ParseHash(headStr); err != nil {
+			logger.Error("invalid chain head", zap.Error(err))
+			return false
+		}
+	}
+
+	verifier := service.NewChainVerifier(logger, repository.NewLoansRepository(conns.DB))
+	report, err := verifier.Verify(ctx, head)
+	if err != nil {
+		logger.Error("failed to verify journal chain", zap.Error(err))
+		return false
+	}
+
+	if report.Valid {
+		fmt.Printf("OK: %d transactions verified, head: %s\n", report.Checked, report.Head)
+		return true
+	}
+
+	link := report.BrokenLink
+	fmt.Printf("BROKEN: transaction #%d (%s): %s\n", link.Seq, user.IDToString(link.TransactionID), link.Reason)
+	fmt.Printf("%d transactions verified before broken link\n", report.Checked)
+	return false
+}
@@ -0,0 +1,6 @@
+-- Loan currency
+--
+-- All loans registered before multi-currency support
+-- are considered to be in EUR.
+ALTER TABLE "loans"
+    ADD COLUMN "currency" CHAR(3) NOT NULL DEFAULT 'EUR';
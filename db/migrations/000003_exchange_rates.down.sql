@@ -0,0 +1,4 @@
+ALTER TABLE "loans" DROP COLUMN IF EXISTS "expense_id";
+DROP TABLE IF EXISTS "expenses";
+DROP TABLE IF EXISTS "exchange_rates";
+ALTER TABLE "groups" DROP COLUMN IF EXISTS "currency";
@@ -0,0 +1,51 @@
+-- Group base currency
+--
+-- All group expenses are converted to a group base currency.
+ALTER TABLE "groups"
+    ADD COLUMN "currency" CHAR(3) NOT NULL DEFAULT 'EUR';
+
+-- Exchange rates table
+--
+-- Contains manually maintained exchange rates.
+-- Rate is amount of quote currency for 1 unit of base currency.
+--
+-- Only one direction of currency pair is required,
+-- reverse rate is calculated by a service.
+CREATE TABLE "exchange_rates"
+(
+    "base"       CHAR(3)         NOT NULL,
+    "quote"      CHAR(3)         NOT NULL,
+    "rate"       NUMERIC(20, 10) NOT NULL CHECK (rate > 0),
+    "updated_at" timestamptz     NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (base, quote)
+);
+
+-- Expenses table
+--
+-- Contains expenses registered in a group.
+--
+-- Amount is always stored in group base currency, while original
+-- amount and currency are kept as provided by user.
+-- Rate is exchange rate captured at posting time.
+CREATE TABLE "expenses"
+(
+    "id"                uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    "group_id"          uuid             NOT NULL,
+    "payer_id"          uuid             NOT NULL,
+    "amount"            bigint           NOT NULL CHECK (amount > 0),
+    "currency"          CHAR(3)          NOT NULL,
+    "original_amount"   bigint           NOT NULL CHECK (original_amount > 0),
+    "original_currency" CHAR(3)          NOT NULL,
+    "rate"              NUMERIC(20, 10)  NOT NULL CHECK (rate > 0),
+    "created_at"        timestamptz      NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE CASCADE,
+    FOREIGN KEY (payer_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Loans created from expense are linked to it.
+--
+-- Loan is kept even if expense was removed together with a group.
+ALTER TABLE "loans"
+    ADD COLUMN "expense_id" uuid NULL REFERENCES expenses (id) ON DELETE SET NULL;
@@ -0,0 +1,2 @@
+ALTER TABLE "loans"
+    ALTER COLUMN "amount" TYPE integer;
@@ -0,0 +1,4 @@
+-- Amounts are stored in minor units of currency as int64,
+-- so 32-bit integer is not enough to keep large amounts.
+ALTER TABLE "loans"
+    ALTER COLUMN "amount" TYPE bigint;
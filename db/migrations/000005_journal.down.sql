@@ -0,0 +1,25 @@
+CREATE TABLE "loans"
+(
+    "id"         uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    "lender_id"  uuid             NOT NULL,
+    "debtor_id"  uuid             NOT NULL,
+    "amount"     bigint           NOT NULL CHECK (amount >= 0),
+    "currency"   CHAR(3)          NOT NULL DEFAULT 'EUR',
+    "expense_id" uuid             NULL REFERENCES expenses (id) ON DELETE SET NULL,
+    "created_at" timestamptz      NOT NULL DEFAULT NOW(),
+
+    FOREIGN KEY (lender_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (debtor_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Each positive posting is converted back to a loan from user to counterparty.
+INSERT INTO loans (lender_id, debtor_id, amount, currency, expense_id, created_at)
+SELECT p.user_id, p.counterparty_id, p.amount, p.currency, t.expense_id, t.created_at
+FROM journal_postings p
+         INNER JOIN journal_transactions t ON t.id = p.transaction_id
+WHERE p.amount > 0;
+
+DROP TABLE IF EXISTS "journal_postings";
+DROP TABLE IF EXISTS "journal_transactions";
+DROP FUNCTION IF EXISTS journal_check_balance();
+DROP FUNCTION IF EXISTS journal_reject_update();
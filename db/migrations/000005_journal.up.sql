@@ -0,0 +1,124 @@
+-- Journal transactions table
+--
+-- Double-entry journal which replaces "loans" table.
+--
+-- Each transaction consists of N postings (see "journal_postings").
+-- Kind describes business operation which caused transaction:
+--  - "expense" - expense shared between group members.
+--  - "settlement" - debt repayment between users.
+--  - "refund" - refund of previously registered expense.
+--  - "loan" - plain loan from one user to another (loans migrated from "loans" table without expense).
+CREATE TABLE "journal_transactions"
+(
+    "id"         uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    "kind"       VARCHAR(16)      NOT NULL CHECK (kind IN ('expense', 'settlement', 'refund', 'loan')),
+    "expense_id" uuid             NULL REFERENCES expenses (id) ON DELETE SET NULL,
+    "created_at" timestamptz      NOT NULL DEFAULT NOW()
+);
+
+-- Journal postings table
+--
+-- Posting changes balance of user account against a counterparty.
+-- Positive amount means that counterparty owes money to user,
+-- negative amount means that user owes money to counterparty.
+--
+-- Postings of each transaction in each currency must sum to zero.
+--
+-- So Alice payed 4$, Bob owes her 2$:
+--  (user_id = alice, counterparty_id = bob, amount = 200)
+--  (user_id = bob, counterparty_id = alice, amount = -200)
+CREATE TABLE "journal_postings"
+(
+    "id"              bigserial PRIMARY KEY,
+    "transaction_id"  uuid    NOT NULL,
+    "user_id"         uuid    NOT NULL,
+    "counterparty_id" uuid    NOT NULL,
+    "currency"        CHAR(3) NOT NULL,
+    "amount"          bigint  NOT NULL CHECK (amount <> 0),
+
+    CHECK (user_id <> counterparty_id),
+
+    FOREIGN KEY (transaction_id) REFERENCES journal_transactions (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE,
+    FOREIGN KEY (counterparty_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Unlike "loans", postings are only searched by user, so only one index is required.
+CREATE INDEX "journal_postings_user_idx" ON "journal_postings" (user_id, currency);
+CREATE INDEX "journal_postings_transaction_idx" ON "journal_postings" (transaction_id);
+
+-- Checks that postings of transaction in each currency sum to zero.
+--
+-- Check is deferred until commit, so all transaction postings
+-- can be inserted one by one.
+CREATE FUNCTION journal_check_balance() RETURNS trigger AS
+$$
+DECLARE
+    tx_id uuid;
+BEGIN
+    IF TG_OP = 'DELETE' THEN
+        tx_id := OLD.transaction_id;
+    ELSE
+        tx_id := NEW.transaction_id;
+    END IF;
+
+    IF EXISTS(SELECT 1
+              FROM journal_postings
+              WHERE transaction_id = tx_id
+              GROUP BY currency
+              HAVING SUM(amount) <> 0) THEN
+        RAISE EXCEPTION 'journal transaction % is not balanced', tx_id
+            USING ERRCODE = 'check_violation';
+    END IF;
+
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE CONSTRAINT TRIGGER "journal_postings_balanced"
+    AFTER INSERT OR DELETE
+    ON "journal_postings"
+    DEFERRABLE INITIALLY DEFERRED
+    FOR EACH ROW
+EXECUTE PROCEDURE journal_check_balance();
+
+-- Journal is append-only, posting can't be changed after commit.
+CREATE FUNCTION journal_reject_update() RETURNS trigger AS
+$$
+BEGIN
+    RAISE EXCEPTION 'journal postings are immutable'
+        USING ERRCODE = 'restrict_violation';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "journal_postings_immutable"
+    BEFORE UPDATE
+    ON "journal_postings"
+    FOR EACH ROW
+EXECUTE PROCEDURE journal_reject_update();
+
+-- Convert existing loans into journal transactions.
+--
+-- Each loan becomes a transaction with a pair of postings for lender and debtor.
+-- Zero and self loans don't change balance and are skipped, so there are no transactions without postings.
+INSERT INTO journal_transactions (id, kind, expense_id, created_at)
+SELECT id,
+       CASE WHEN expense_id IS NULL THEN 'loan' ELSE 'expense' END,
+       expense_id,
+       created_at
+FROM loans
+WHERE amount > 0
+  AND lender_id <> debtor_id;
+
+INSERT INTO journal_postings (transaction_id, user_id, counterparty_id, currency, amount)
+SELECT id, lender_id, debtor_id, currency, amount
+FROM loans
+WHERE amount > 0
+  AND lender_id <> debtor_id
+UNION ALL
+SELECT id, debtor_id, lender_id, currency, -amount
+FROM loans
+WHERE amount > 0
+  AND lender_id <> debtor_id;
+
+DROP TABLE "loans";
@@ -0,0 +1,7 @@
+DROP TRIGGER IF EXISTS "journal_transactions_immutable" ON "journal_transactions";
+DROP FUNCTION IF EXISTS journal_reject_chain_update();
+
+ALTER TABLE "journal_transactions"
+    DROP COLUMN IF EXISTS "seq",
+    DROP COLUMN IF EXISTS "prev_hash",
+    DROP COLUMN IF EXISTS "hash";
@@ -0,0 +1,81 @@
+-- Hash chain of journal transactions
+--
+-- Each transaction stores SHA-256 hash of its contents and postings
+-- chained to a hash of the previous transaction, so any change of
+-- already committed record breaks the chain.
+--
+-- Chain is global, "seq" is position of transaction in a chain.
+-- First transaction refers to a zero hash.
+--
+-- Hashed payload (fields are separated by new line):
+--  seq, hex(prev_hash), id, kind, created_at (UTC, microseconds)
+-- followed by a line for each posting (ordered by posting ID):
+--  user_id:counterparty_id:currency:amount
+--
+-- Expense link is not a part of hash, since it's cleared when group is removed.
+ALTER TABLE "journal_transactions"
+    ADD COLUMN "seq"       bigint NULL,
+    ADD COLUMN "prev_hash" bytea  NULL,
+    ADD COLUMN "hash"      bytea  NULL;
+
+-- Build chain for existing transactions
+DO
+$$
+    DECLARE
+        rec     RECORD;
+        n       bigint := 0;
+        prev    bytea  := decode(repeat('00', 32), 'hex');
+        cur     bytea;
+        payload text;
+    BEGIN
+        FOR rec IN SELECT * FROM journal_transactions ORDER BY created_at, id
+            LOOP
+                n := n + 1;
+                payload := n::text || E'\n' ||
+                           encode(prev, 'hex') || E'\n' ||
+                           rec.id::text || E'\n' ||
+                           rec.kind || E'\n' ||
+                           to_char(rec.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"') || E'\n' ||
+                           COALESCE((SELECT string_agg(
+                                                    user_id::text || ':' || counterparty_id::text || ':' ||
+                                                    currency::text || ':' || amount::text || E'\n', '' ORDER BY id)
+                                     FROM journal_postings
+                                     WHERE transaction_id = rec.id), '');
+                cur := sha256(convert_to(payload, 'UTF8'));
+
+                UPDATE journal_transactions
+                SET seq       = n,
+                    prev_hash = prev,
+                    hash      = cur
+                WHERE id = rec.id;
+
+                prev := cur;
+            END LOOP;
+    END
+$$;
+
+ALTER TABLE "journal_transactions"
+    ALTER COLUMN "seq" SET NOT NULL,
+    ALTER COLUMN "prev_hash" SET NOT NULL,
+    ALTER COLUMN "hash" SET NOT NULL,
+    ADD CONSTRAINT "journal_transactions_seq_key" UNIQUE (seq);
+
+-- Chain fields can't be changed after commit.
+CREATE FUNCTION journal_reject_chain_update() RETURNS trigger AS
+$$
+BEGIN
+    IF NEW.seq <> OLD.seq OR NEW.prev_hash <> OLD.prev_hash OR NEW.hash <> OLD.hash
+        OR NEW.kind <> OLD.kind OR NEW.created_at <> OLD.created_at OR NEW.id <> OLD.id THEN
+        RAISE EXCEPTION 'journal transactions are immutable'
+            USING ERRCODE = 'restrict_violation';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER "journal_transactions_immutable"
+    BEFORE UPDATE
+    ON "journal_transactions"
+    FOR EACH ROW
+EXECUTE PROCEDURE journal_reject_chain_update();
@@ -0,0 +1,21 @@
+-- Balance cache outbox
+--
+-- Contains balance cache update events written in the same transaction
+-- as journal postings. Event is removed when applied to balance cache.
+--
+-- Each event contains balance deltas of a single user caused by journal transaction.
+-- Pair of transaction and user IDs is used as idempotency key for cache updates.
+CREATE TABLE "balance_outbox"
+(
+    "transaction_id" uuid        NOT NULL,
+    "user_id"        uuid        NOT NULL,
+    "deltas"         jsonb       NOT NULL,
+    "attempts"       integer     NOT NULL DEFAULT 0,
+    "created_at"     timestamptz NOT NULL DEFAULT NOW(),
+
+    PRIMARY KEY (transaction_id, user_id),
+    FOREIGN KEY (transaction_id) REFERENCES journal_transactions (id) ON DELETE CASCADE,
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE INDEX "balance_outbox_created_at_idx" ON "balance_outbox" (created_at);
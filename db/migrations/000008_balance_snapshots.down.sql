@@ -0,0 +1,2 @@
+ALTER TABLE "balance_outbox"
+    DROP COLUMN IF EXISTS "seq";
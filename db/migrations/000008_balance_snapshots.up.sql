@@ -0,0 +1,11 @@
+-- Journal position of event transaction.
+--
+-- Cached balance keeps journal position of balance snapshot,
+-- so events already included in snapshot are not applied twice.
+ALTER TABLE "balance_outbox"
+    ADD COLUMN "seq" bigint NOT NULL DEFAULT 0;
+
+UPDATE balance_outbox o
+SET seq = t.seq
+FROM journal_transactions t
+WHERE t.id = o.transaction_id;
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "pair_balances";
+DROP TABLE IF EXISTS "pair_balance_users";
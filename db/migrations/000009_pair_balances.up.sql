@@ -0,0 +1,29 @@
+-- Materialized users balance
+--
+-- Alternative to Redis balance cache (see "balance" config section).
+-- Balance is updated in the same transaction as journal postings.
+--
+-- Only balance of users listed in "pair_balance_users" is materialized.
+-- Seq is journal position of the last transaction included into user balance.
+CREATE TABLE "pair_balance_users"
+(
+    "user_id" uuid PRIMARY KEY NOT NULL,
+    "seq"     bigint           NOT NULL DEFAULT 0,
+
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+-- Balance of user against a counterparty in each currency.
+--
+-- Same as sum of user postings against a counterparty in "journal_postings".
+CREATE TABLE "pair_balances"
+(
+    "user_id"         uuid    NOT NULL,
+    "currency"        CHAR(3) NOT NULL,
+    "counterparty_id" uuid    NOT NULL,
+    "balance"         bigint  NOT NULL,
+
+    PRIMARY KEY (user_id, currency, counterparty_id),
+    FOREIGN KEY (user_id) REFERENCES pair_balance_users (user_id) ON DELETE CASCADE,
+    FOREIGN KEY (counterparty_id) REFERENCES users (id) ON DELETE CASCADE
+);
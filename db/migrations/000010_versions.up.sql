@@ -0,0 +1,8 @@
+-- Resource versions for optimistic concurrency control.
+--
+-- Version is incremented on each change and exposed to clients as ETag.
+-- Changes with "If-Match" header are rejected if version doesn't match.
+--
+-- Expenses can't be changed after creation, so only groups are versioned.
+ALTER TABLE "groups"
+    ADD COLUMN "version" bigint NOT NULL DEFAULT 1;
@@ -0,0 +1,2 @@
+ALTER TABLE "group_membership"
+    DROP COLUMN IF EXISTS "role";
@@ -0,0 +1,8 @@
+-- Group member roles
+--
+-- Group owner is not listed in "group_membership" and always has "owner" role.
+--  - "admin" - can manage members and expenses.
+--  - "member" - can post expenses.
+--  - "viewer" - read-only access.
+ALTER TABLE "group_membership"
+    ADD COLUMN "role" VARCHAR(16) NOT NULL DEFAULT 'member' CHECK (role IN ('admin', 'member', 'viewer'));
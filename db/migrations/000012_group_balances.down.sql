@@ -0,0 +1,10 @@
+-- Fails if debt transfers were registered, since journal transactions can't be removed from chain.
+ALTER TABLE "journal_transactions"
+    DROP CONSTRAINT "journal_transactions_kind_check",
+    ADD CONSTRAINT "journal_transactions_kind_check"
+        CHECK (kind IN ('expense', 'settlement', 'refund', 'loan'));
+
+DROP INDEX IF EXISTS "journal_transactions_group_idx";
+
+ALTER TABLE "journal_transactions"
+    DROP COLUMN IF EXISTS "group_id";
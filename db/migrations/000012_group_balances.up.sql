@@ -0,0 +1,20 @@
+-- Group-scoped balances
+--
+-- Transaction group is used to calculate user balance inside a group.
+-- Transactions of group expenses are linked to expense group.
+--
+-- "transfer" kind is transfer of user debts inside a group to another group member.
+ALTER TABLE "journal_transactions"
+    ADD COLUMN "group_id" uuid NULL REFERENCES groups (id) ON DELETE SET NULL;
+
+UPDATE "journal_transactions" t
+SET group_id = e.group_id
+FROM "expenses" e
+WHERE e.id = t.expense_id;
+
+CREATE INDEX "journal_transactions_group_idx" ON "journal_transactions" (group_id);
+
+ALTER TABLE "journal_transactions"
+    DROP CONSTRAINT "journal_transactions_kind_check",
+    ADD CONSTRAINT "journal_transactions_kind_check"
+        CHECK (kind IN ('expense', 'settlement', 'refund', 'loan', 'transfer'));
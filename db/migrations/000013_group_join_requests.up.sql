@@ -0,0 +1,22 @@
+-- Group join requests
+--
+-- User requests to join a group and group admins approve or deny request.
+-- Only one pending request per user and group is allowed.
+--  - "pending" - request is waiting for decision.
+--  - "approved" - user was added to a group.
+--  - "denied" - request was rejected.
+CREATE TABLE "group_join_requests"
+(
+    "id"         uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    "group_id"   uuid             NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    "user_id"    uuid             NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    "message"    VARCHAR(256)     NOT NULL DEFAULT '',
+    "status"     VARCHAR(16)      NOT NULL DEFAULT 'pending' CHECK (status IN ('pending', 'approved', 'denied')),
+    "created_at" timestamptz      NOT NULL DEFAULT NOW(),
+    "decided_by" uuid             NULL REFERENCES users (id) ON DELETE SET NULL,
+    "decided_at" timestamptz      NULL
+);
+
+CREATE UNIQUE INDEX "group_join_requests_pending_idx" ON "group_join_requests" (group_id, user_id)
+    WHERE status = 'pending';
+CREATE INDEX "group_join_requests_user_idx" ON "group_join_requests" (user_id);
@@ -0,0 +1,4 @@
+ALTER TABLE "groups"
+    DROP COLUMN IF EXISTS "description",
+    DROP COLUMN IF EXISTS "split_mode",
+    DROP COLUMN IF EXISTS "rounding";
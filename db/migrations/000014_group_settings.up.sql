@@ -0,0 +1,14 @@
+-- Group settings
+--
+-- Split mode is default expense split mode:
+--  - "equal" - expense is split equally between all members including payer.
+--  - "exclude_payer" - expense is split equally between all members except payer.
+--
+-- Rounding is rounding policy of expense share in minor units:
+--  - "half_up" - half away from zero.
+--  - "up" - away from zero.
+--  - "down" - towards zero.
+ALTER TABLE "groups"
+    ADD COLUMN "description" VARCHAR(256) NOT NULL DEFAULT '',
+    ADD COLUMN "split_mode"  VARCHAR(16)  NOT NULL DEFAULT 'equal' CHECK (split_mode IN ('equal', 'exclude_payer')),
+    ADD COLUMN "rounding"    VARCHAR(16)  NOT NULL DEFAULT 'half_up' CHECK (rounding IN ('half_up', 'up', 'down'));
@@ -0,0 +1,6 @@
+-- Soft-deleted groups can't be represented without "deleted_at" column.
+DELETE FROM "groups" WHERE "deleted_at" IS NOT NULL;
+
+ALTER TABLE "groups"
+    DROP COLUMN IF EXISTS "archived_at",
+    DROP COLUMN IF EXISTS "deleted_at";
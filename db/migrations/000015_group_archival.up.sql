@@ -0,0 +1,7 @@
+-- Group archival and soft deletion
+--
+-- Archived groups are read-only and hidden from default group listings.
+-- Deleted groups are hidden everywhere and can be restored by owner during restore window.
+ALTER TABLE "groups"
+    ADD COLUMN "archived_at" timestamptz NULL,
+    ADD COLUMN "deleted_at"  timestamptz NULL;
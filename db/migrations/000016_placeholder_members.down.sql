@@ -0,0 +1,14 @@
+-- Placeholders can't be removed as they are referenced by journal postings,
+-- so they get unique reserved email and blank password which never matches.
+UPDATE "users"
+SET "email"    = "id" || '@placeholder.invalid',
+    "password" = ''
+WHERE "placeholder";
+
+ALTER TABLE "users"
+    DROP CONSTRAINT IF EXISTS "users_credentials_check",
+    DROP CONSTRAINT IF EXISTS "users_claimed_check",
+    DROP COLUMN IF EXISTS "claimed_by",
+    DROP COLUMN IF EXISTS "placeholder",
+    ALTER COLUMN "email" SET NOT NULL,
+    ALTER COLUMN "password" SET NOT NULL;
@@ -0,0 +1,14 @@
+-- Placeholder members
+--
+-- Placeholder is a named user without credentials, added to a group by group admin.
+-- Placeholder participates in expenses like a regular member and can be claimed later
+-- by registered user. Claimed placeholder is kept, as journal postings are immutable.
+ALTER TABLE "users"
+    ALTER COLUMN "email" DROP NOT NULL,
+    ALTER COLUMN "password" DROP NOT NULL,
+    ADD COLUMN "placeholder" boolean NOT NULL DEFAULT false,
+    ADD COLUMN "claimed_by"  uuid    NULL REFERENCES users (id) ON DELETE SET NULL,
+    ADD CONSTRAINT "users_credentials_check"
+        CHECK (placeholder OR (email IS NOT NULL AND password IS NOT NULL)),
+    ADD CONSTRAINT "users_claimed_check"
+        CHECK (placeholder OR claimed_by IS NULL);
@@ -0,0 +1,14 @@
+ALTER TABLE "expenses"
+    DROP COLUMN IF EXISTS "spent_at";
+
+-- Closed membership periods can't be represented without "left_at" column.
+DELETE FROM "group_membership" WHERE "left_at" IS NOT NULL;
+
+DROP INDEX IF EXISTS "group_membership_active_idx";
+
+ALTER TABLE "group_membership"
+    DROP CONSTRAINT "group_membership_pkey",
+    ADD PRIMARY KEY ("group_id", "member_id"),
+    DROP CONSTRAINT IF EXISTS "group_membership_period_check",
+    DROP COLUMN IF EXISTS "joined_at",
+    DROP COLUMN IF EXISTS "left_at";
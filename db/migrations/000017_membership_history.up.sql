@@ -0,0 +1,36 @@
+-- Membership history
+--
+-- Membership is closed by "left_at" date instead of row removal, so user which rejoined a group
+-- has several membership periods. Only one membership period of a user in a group can be active.
+--
+-- Join date of existing memberships is unknown, date of the first group expense is used instead,
+-- so existing members keep participating in backdated expenses.
+ALTER TABLE "group_membership"
+    ADD COLUMN "joined_at" timestamptz NOT NULL DEFAULT NOW(),
+    ADD COLUMN "left_at"   timestamptz NULL,
+    ADD CONSTRAINT "group_membership_period_check" CHECK (left_at IS NULL OR left_at >= joined_at);
+
+UPDATE "group_membership" m
+SET "joined_at" = COALESCE(
+        (SELECT MIN(e.created_at) FROM "expenses" e WHERE e.group_id = m.group_id AND e.created_at < m.joined_at),
+        m.joined_at
+    );
+
+ALTER TABLE "group_membership"
+    DROP CONSTRAINT "group_membership_pkey",
+    ADD PRIMARY KEY ("group_id", "member_id", "joined_at");
+
+CREATE UNIQUE INDEX "group_membership_active_idx" ON "group_membership" ("group_id", "member_id")
+    WHERE "left_at" IS NULL;
+
+-- Expense date is date when expense was made, it can be earlier than registration date.
+--
+-- Expense is shared between members who were active at expense date.
+ALTER TABLE "expenses"
+    ADD COLUMN "spent_at" timestamptz NULL;
+
+UPDATE "expenses" SET "spent_at" = "created_at";
+
+ALTER TABLE "expenses"
+    ALTER COLUMN "spent_at" SET NOT NULL,
+    ALTER COLUMN "spent_at" SET DEFAULT NOW();
@@ -0,0 +1,16 @@
+-- Group activity feed
+--
+-- Chronological log of group events, like shared expenses, member changes and settlements.
+-- Event ID is increasing and used as feed page cursor.
+-- Payload structure depends on event type.
+CREATE TABLE "group_activity"
+(
+    "id"         bigserial PRIMARY KEY NOT NULL,
+    "group_id"   uuid                  NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    "actor_id"   uuid                  NULL REFERENCES users (id) ON DELETE SET NULL,
+    "type"       VARCHAR(32)           NOT NULL,
+    "payload"    jsonb                 NOT NULL DEFAULT '{}',
+    "created_at" timestamptz           NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "group_activity_group_idx" ON "group_activity" (group_id, id DESC);
@@ -0,0 +1,8 @@
+ALTER TABLE "journal_transactions"
+    DROP COLUMN IF EXISTS "event_id";
+
+ALTER TABLE "expenses"
+    DROP COLUMN IF EXISTS "event_id";
+
+DROP TABLE IF EXISTS "group_event_participants";
+DROP TABLE IF EXISTS "group_events";
@@ -0,0 +1,34 @@
+-- Group events
+--
+-- Event is a sub-ledger inside a group, like a trip or an occasion.
+-- Event participants are group members. Event without participants is shared by all group members.
+--
+-- Expenses and their journal transactions are linked to event, so balances and reports
+-- can be scoped per event or rolled up to the whole group.
+CREATE TABLE "group_events"
+(
+    "id"          uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    "group_id"    uuid             NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    "name"        VARCHAR(64)      NOT NULL,
+    "description" VARCHAR(256)     NOT NULL DEFAULT '',
+    "created_by"  uuid             NULL REFERENCES users (id) ON DELETE SET NULL,
+    "created_at"  timestamptz      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "group_events_group_idx" ON "group_events" (group_id);
+
+CREATE TABLE "group_event_participants"
+(
+    "event_id"  uuid NOT NULL REFERENCES group_events (id) ON DELETE CASCADE,
+    "member_id" uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY ("event_id", "member_id")
+);
+
+ALTER TABLE "expenses"
+    ADD COLUMN "event_id" uuid NULL REFERENCES group_events (id) ON DELETE CASCADE;
+
+ALTER TABLE "journal_transactions"
+    ADD COLUMN "event_id" uuid NULL REFERENCES group_events (id) ON DELETE SET NULL;
+
+CREATE INDEX "expenses_event_idx" ON "expenses" (event_id);
+CREATE INDEX "journal_transactions_event_idx" ON "journal_transactions" (event_id);
@@ -0,0 +1,2 @@
+DROP TABLE IF EXISTS "group_household_members";
+DROP TABLE IF EXISTS "group_households";
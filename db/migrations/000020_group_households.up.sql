@@ -0,0 +1,28 @@
+-- Group households
+--
+-- Household is a set of group members, like a couple, which shares one balance inside a group.
+-- Household is charged as a single expense participant with specified weight,
+-- and household debts are held by household holder.
+CREATE TABLE "group_households"
+(
+    "id"         uuid PRIMARY KEY NOT NULL DEFAULT uuid_generate_v4(),
+    "group_id"   uuid             NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    "name"       VARCHAR(64)      NOT NULL,
+    "holder_id"  uuid             NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    "weight"     INTEGER          NOT NULL DEFAULT 1 CHECK ("weight" > 0),
+    "created_by" uuid             NULL REFERENCES users (id) ON DELETE SET NULL,
+    "created_at" timestamptz      NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX "group_households_group_idx" ON "group_households" (group_id);
+
+-- Member can belong only to a single household inside a group.
+CREATE TABLE "group_household_members"
+(
+    "household_id" uuid NOT NULL REFERENCES group_households (id) ON DELETE CASCADE,
+    "group_id"     uuid NOT NULL REFERENCES groups (id) ON DELETE CASCADE,
+    "member_id"    uuid NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    PRIMARY KEY ("group_id", "member_id")
+);
+
+CREATE INDEX "group_household_members_household_idx" ON "group_household_members" (household_id);
@@ -0,0 +1,20 @@
+-- Group ownership period
+--
+-- Owner is not stored in members table, so start date of ownership is stored in a group.
+-- Owner participates only in expenses made after ownership start,
+-- former owner becomes a member since ownership start date.
+--
+-- Ownership start of existing groups is unknown, date when owner's membership was closed
+-- on ownership transfer or date of the first group membership or expense is used instead.
+ALTER TABLE "groups"
+    ADD COLUMN "owner_since" timestamptz NOT NULL DEFAULT NOW();
+
+UPDATE "groups" g
+SET "owner_since" = COALESCE(
+        (SELECT MAX(m.left_at) FROM "group_membership" m WHERE m.group_id = g.id AND m.member_id = g.owner_id),
+        LEAST(
+            (SELECT MIN(m.joined_at) FROM "group_membership" m WHERE m.group_id = g.id),
+            (SELECT MIN(e.spent_at) FROM "expenses" e WHERE e.group_id = g.id),
+            g.owner_since
+        )
+    );
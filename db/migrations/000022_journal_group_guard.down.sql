@@ -0,0 +1,12 @@
+CREATE OR REPLACE FUNCTION journal_reject_chain_update() RETURNS trigger AS
+$$
+BEGIN
+    IF NEW.seq <> OLD.seq OR NEW.prev_hash <> OLD.prev_hash OR NEW.hash <> OLD.hash
+        OR NEW.kind <> OLD.kind OR NEW.created_at <> OLD.created_at OR NEW.id <> OLD.id THEN
+        RAISE EXCEPTION 'journal transactions are immutable'
+            USING ERRCODE = 'restrict_violation';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
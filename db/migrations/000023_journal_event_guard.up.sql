@@ -0,0 +1,17 @@
+-- Transaction event link is not a part of hash, since it's cleared when event is removed.
+--
+-- Event link can only be cleared, like group link.
+CREATE OR REPLACE FUNCTION journal_reject_chain_update() RETURNS trigger AS
+$$
+BEGIN
+    IF NEW.seq <> OLD.seq OR NEW.prev_hash <> OLD.prev_hash OR NEW.hash <> OLD.hash
+        OR NEW.kind <> OLD.kind OR NEW.created_at <> OLD.created_at OR NEW.id <> OLD.id
+        OR (NEW.group_id IS NOT NULL AND NEW.group_id IS DISTINCT FROM OLD.group_id)
+        OR (NEW.event_id IS NOT NULL AND NEW.event_id IS DISTINCT FROM OLD.event_id) THEN
+        RAISE EXCEPTION 'journal transactions are immutable'
+            USING ERRCODE = 'restrict_violation';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,7 @@
+-- Group join policy
+--
+-- Join policy controls whether users outside a group can request to join it:
+--  - "closed" - group doesn't accept join requests, members are added only by admins or invites.
+--  - "request" - any user can request to join a group, request is approved by group admins.
+ALTER TABLE "groups"
+    ADD COLUMN "join_policy" VARCHAR(16) NOT NULL DEFAULT 'closed' CHECK (join_policy IN ('closed', 'request'));
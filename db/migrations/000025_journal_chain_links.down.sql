@@ -0,0 +1,70 @@
+-- Links to removed groups and events are cleared to restore foreign keys.
+ALTER TABLE "journal_transactions"
+    DISABLE TRIGGER "journal_transactions_immutable";
+
+UPDATE journal_transactions
+SET group_id = NULL
+WHERE group_id IS NOT NULL
+  AND NOT EXISTS(SELECT 1 FROM groups WHERE groups.id = journal_transactions.group_id);
+
+UPDATE journal_transactions
+SET event_id = NULL
+WHERE event_id IS NOT NULL
+  AND NOT EXISTS(SELECT 1 FROM group_events WHERE group_events.id = journal_transactions.event_id);
+
+-- Rebuild chain without links
+DO
+$$
+    DECLARE
+        rec     RECORD;
+        prev    bytea := decode(repeat('00', 32), 'hex');
+        cur     bytea;
+        payload text;
+    BEGIN
+        FOR rec IN SELECT * FROM journal_transactions ORDER BY seq
+            LOOP
+                payload := rec.seq::text || E'\n' ||
+                           encode(prev, 'hex') || E'\n' ||
+                           rec.id::text || E'\n' ||
+                           rec.kind || E'\n' ||
+                           to_char(rec.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"') || E'\n' ||
+                           COALESCE((SELECT string_agg(
+                                                    user_id::text || ':' || counterparty_id::text || ':' ||
+                                                    currency::text || ':' || amount::text || E'\n', '' ORDER BY id)
+                                     FROM journal_postings
+                                     WHERE transaction_id = rec.id), '');
+                cur := sha256(convert_to(payload, 'UTF8'));
+
+                UPDATE journal_transactions
+                SET prev_hash = prev,
+                    hash      = cur
+                WHERE id = rec.id;
+
+                prev := cur;
+            END LOOP;
+    END
+$$;
+
+ALTER TABLE "journal_transactions"
+    ENABLE TRIGGER "journal_transactions_immutable";
+
+ALTER TABLE "journal_transactions"
+    ADD CONSTRAINT "journal_transactions_group_id_fkey"
+        FOREIGN KEY (group_id) REFERENCES groups (id) ON DELETE SET NULL,
+    ADD CONSTRAINT "journal_transactions_event_id_fkey"
+        FOREIGN KEY (event_id) REFERENCES group_events (id) ON DELETE SET NULL;
+
+CREATE OR REPLACE FUNCTION journal_reject_chain_update() RETURNS trigger AS
+$$
+BEGIN
+    IF NEW.seq <> OLD.seq OR NEW.prev_hash <> OLD.prev_hash OR NEW.hash <> OLD.hash
+        OR NEW.kind <> OLD.kind OR NEW.created_at <> OLD.created_at OR NEW.id <> OLD.id
+        OR (NEW.group_id IS NOT NULL AND NEW.group_id IS DISTINCT FROM OLD.group_id)
+        OR (NEW.event_id IS NOT NULL AND NEW.event_id IS DISTINCT FROM OLD.event_id) THEN
+        RAISE EXCEPTION 'journal transactions are immutable'
+            USING ERRCODE = 'restrict_violation';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
@@ -0,0 +1,71 @@
+-- Group and event links of journal transactions are part of hash
+--
+-- Previously links were cleared on group or event removal, so they couldn't be hashed
+-- and a cleared link wasn't distinguishable from tampering.
+-- Journal outlives groups, so links are kept after removal and can't be changed at all.
+--
+-- Hashed payload (fields are separated by new line):
+--  seq, hex(prev_hash), id, kind, created_at (UTC, microseconds), group_id, event_id
+-- followed by a line for each posting (ordered by posting ID):
+--  user_id:counterparty_id:currency:amount
+--
+-- Empty links are hashed as empty lines.
+ALTER TABLE "journal_transactions"
+    DROP CONSTRAINT IF EXISTS "journal_transactions_group_id_fkey",
+    DROP CONSTRAINT IF EXISTS "journal_transactions_event_id_fkey";
+
+CREATE OR REPLACE FUNCTION journal_reject_chain_update() RETURNS trigger AS
+$$
+BEGIN
+    IF NEW.seq <> OLD.seq OR NEW.prev_hash <> OLD.prev_hash OR NEW.hash <> OLD.hash
+        OR NEW.kind <> OLD.kind OR NEW.created_at <> OLD.created_at OR NEW.id <> OLD.id
+        OR NEW.group_id IS DISTINCT FROM OLD.group_id
+        OR NEW.event_id IS DISTINCT FROM OLD.event_id THEN
+        RAISE EXCEPTION 'journal transactions are immutable'
+            USING ERRCODE = 'restrict_violation';
+    END IF;
+
+    RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+
+-- Rebuild chain with links included
+ALTER TABLE "journal_transactions"
+    DISABLE TRIGGER "journal_transactions_immutable";
+
+DO
+$$
+    DECLARE
+        rec     RECORD;
+        prev    bytea := decode(repeat('00', 32), 'hex');
+        cur     bytea;
+        payload text;
+    BEGIN
+        FOR rec IN SELECT * FROM journal_transactions ORDER BY seq
+            LOOP
+                payload := rec.seq::text || E'\n' ||
+                           encode(prev, 'hex') || E'\n' ||
+                           rec.id::text || E'\n' ||
+                           rec.kind || E'\n' ||
+                           to_char(rec.created_at AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS.US"Z"') || E'\n' ||
+                           COALESCE(rec.group_id::text, '') || E'\n' ||
+                           COALESCE(rec.event_id::text, '') || E'\n' ||
+                           COALESCE((SELECT string_agg(
+                                                    user_id::text || ':' || counterparty_id::text || ':' ||
+                                                    currency::text || ':' || amount::text || E'\n', '' ORDER BY id)
+                                     FROM journal_postings
+                                     WHERE transaction_id = rec.id), '');
+                cur := sha256(convert_to(payload, 'UTF8'));
+
+                UPDATE journal_transactions
+                SET prev_hash = prev,
+                    hash      = cur
+                WHERE id = rec.id;
+
+                prev := cur;
+            END LOOP;
+    END
+$$;
+
+ALTER TABLE "journal_transactions"
+    ENABLE TRIGGER "journal_transactions_immutable";
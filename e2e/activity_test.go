@@ -0,0 +1,169 @@
+package e2e
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/pkg/ledger"
+)
+
+func TestGroup_Activity(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	carol := mustCreateUser(t, "carol", "carol@mail.com")
+	outsider := mustCreateUser(t, "outsider", "outsider@mail.com")
+	grp, err := Client.CreateGroup("activity", alice.Token)
+	require.NoError(t, err)
+
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID))
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, carol.User.ID))
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 3000, "", alice.Token))
+	require.NoError(t, Client.LeaveGroup(grp.ID, ledger.RemovalOptions{TransferTo: carol.User.ID}, bob.Token))
+	require.NoError(t, Client.DeleteGroupMemberWithOptions(grp.ID, carol.User.ID,
+		ledger.RemovalOptions{Force: true}, alice.Token))
+
+	page, err := Client.GroupActivity(grp.ID, ledger.ActivityQuery{}, alice.Token)
+	require.NoError(t, err)
+	require.Empty(t, page.NextCursor)
+
+	type entry struct {
+		actor     string
+		eventType string
+	}
+	entries := make([]entry, 0, len(page.Events))
+	for _, e := range page.Events {
+		require.Equal(t, grp.ID, e.GroupID)
+		entries = append(entries, entry{actor: e.ActorID, eventType: e.Type})
+	}
+	require.Equal(t, []entry{
+		{alice.User.ID, ledger.EventMemberRemoved},
+		{bob.User.ID, ledger.EventMemberLeft},
+		{bob.User.ID, ledger.EventBalanceTransferred},
+		{alice.User.ID, ledger.EventExpenseCreated},
+		{alice.User.ID, ledger.EventMemberAdded},
+		{alice.User.ID, ledger.EventMemberAdded},
+	}, entries)
+
+	var expense struct {
+		PayerID string   `json:"payer_id"`
+		Amount  int64    `json:"amount"`
+		Share   int64    `json:"share"`
+		Debtors []string `json:"debtors"`
+	}
+	require.NoError(t, json.Unmarshal(page.Events[3].Payload, &expense))
+	require.Equal(t, alice.User.ID, expense.PayerID)
+	require.Equal(t, int64(3000), expense.Amount)
+	require.Equal(t, int64(1000), expense.Share)
+	require.ElementsMatch(t, []string{bob.User.ID, carol.User.ID}, expense.Debtors)
+
+	var removal struct {
+		MemberID   string `json:"member_id"`
+		TransferTo string `json:"transfer_to"`
+	}
+	require.NoError(t, json.Unmarshal(page.Events[1].Payload, &removal))
+	require.Equal(t, bob.User.ID, removal.MemberID)
+	require.Equal(t, carol.User.ID, removal.TransferTo)
+
+	// pagination
+	var paged []ledger.ActivityEvent
+	q := ledger.ActivityQuery{Limit: 4}
+	for {
+		p, err := Client.GroupActivity(grp.ID, q, alice.Token)
+		require.NoError(t, err)
+		paged = append(paged, p.Events...)
+		if p.NextCursor == "" {
+			break
+		}
+
+		require.Len(t, p.Events, q.Limit)
+		q.Cursor = p.NextCursor
+	}
+	require.Equal(t, page.Events, paged)
+
+	// filter by event type
+	page, err = Client.GroupActivity(grp.ID, ledger.ActivityQuery{
+		Types: []string{ledger.EventMemberLeft, ledger.EventMemberRemoved},
+	}, alice.Token)
+	require.NoError(t, err)
+	require.Len(t, page.Events, 2)
+	require.Equal(t, ledger.EventMemberRemoved, page.Events[0].Type)
+	require.Equal(t, ledger.EventMemberLeft, page.Events[1].Type)
+
+	_, err = Client.GroupActivity(grp.ID, ledger.ActivityQuery{Types: []string{"foo"}}, alice.Token)
+	shouldContainError(t, err, `400 Bad Request: unknown event type "foo"`)
+	_, err = Client.GroupActivity(grp.ID, ledger.ActivityQuery{Cursor: "foo"}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request: invalid cursor parameter value")
+	_, err = Client.GroupActivity(grp.ID, ledger.ActivityQuery{Limit: 1000}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request: limit should be between 1 and 200")
+
+	// feed is visible only to group members
+	_, err = Client.GroupActivity(grp.ID, ledger.ActivityQuery{}, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	_, err = Client.GroupActivity(grp.ID, ledger.ActivityQuery{}, bob.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+}
+
+func TestGroup_MembershipActivity(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	carol := mustCreateUser(t, "carol", "carol@mail.com")
+	dave := mustCreateUser(t, "dave", "dave@mail.com")
+	grp, err := Client.CreateGroup("activity", alice.Token)
+	require.NoError(t, err)
+
+	inv, err := Client.CreateInvite(grp.ID, ledger.InviteParams{}, alice.Token)
+	require.NoError(t, err)
+	_, err = Client.AcceptInvite(inv.Token, bob.Token)
+	require.NoError(t, err)
+
+	mustAcceptJoinRequests(t, grp.ID, alice.Token)
+	req, err := Client.RequestJoin(grp.ID, "", carol.Token)
+	require.NoError(t, err)
+	_, err = Client.ApproveJoinRequest(grp.ID, req.ID, alice.Token)
+	require.NoError(t, err)
+
+	ghost, err := Client.AddPlaceholder(grp.ID, "ghost", alice.Token)
+	require.NoError(t, err)
+	inv, err = Client.CreateInvite(grp.ID, ledger.InviteParams{PlaceholderID: ghost.ID}, alice.Token)
+	require.NoError(t, err)
+	_, err = Client.AcceptInvite(inv.Token, dave.Token)
+	require.NoError(t, err)
+
+	require.NoError(t, Client.TransferGroup(grp.ID, bob.User.ID, alice.Token))
+
+	page, err := Client.GroupActivity(grp.ID, ledger.ActivityQuery{}, bob.Token)
+	require.NoError(t, err)
+
+	type member struct {
+		Members       []string `json:"members"`
+		Role          string   `json:"role"`
+		PlaceholderID string   `json:"placeholder_id"`
+		FormerOwnerID string   `json:"former_owner_id"`
+		OwnerID       string   `json:"owner_id"`
+	}
+	type entry struct {
+		actor     string
+		eventType string
+		payload   member
+	}
+	entries := make([]entry, 0, len(page.Events))
+	for _, e := range page.Events {
+		var p member
+		require.NoError(t, json.Unmarshal(e.Payload, &p))
+		entries = append(entries, entry{actor: e.ActorID, eventType: e.Type, payload: p})
+	}
+	require.Equal(t, []entry{
+		{alice.User.ID, ledger.EventOwnershipTransferred, member{FormerOwnerID: alice.User.ID, OwnerID: bob.User.ID}},
+		{dave.User.ID, ledger.EventMemberAdded, member{
+			Members: []string{dave.User.ID}, Role: ledger.RoleMember, PlaceholderID: ghost.ID,
+		}},
+		{alice.User.ID, ledger.EventMemberAdded, member{Members: []string{ghost.ID}, Role: ledger.RoleMember}},
+		{alice.User.ID, ledger.EventMemberAdded, member{Members: []string{carol.User.ID}, Role: ledger.RoleMember}},
+		{bob.User.ID, ledger.EventMemberAdded, member{Members: []string{bob.User.ID}, Role: ledger.RoleMember}},
+	}, entries)
+}
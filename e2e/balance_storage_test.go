@@ -0,0 +1,121 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/balance"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/repository"
+	"github.com/x1unix/sbda-ledger/internal/service"
+	"go.uber.org/zap"
+)
+
+// balanceStorageCase is balance storage implementation with matching journal storage.
+type balanceStorageCase struct {
+	store service.BalanceStorage
+	loans *repository.LoansRepository
+}
+
+func balanceStorageCases() map[string]balanceStorageCase {
+	return map[string]balanceStorageCase{
+		"redis": {
+			store: repository.NewBalanceRepository(zap.NewNop(), Redis),
+			loans: repository.NewLoansRepository(DB),
+		},
+		"postgres": {
+			store: repository.NewPairBalanceRepository(DB),
+			loans: repository.NewMaterializedLoansRepository(DB),
+		},
+	}
+}
+
+// TestBalanceStorage_Contract checks that all balance storage implementations behave the same way.
+func TestBalanceStorage_Contract(t *testing.T) {
+	for name, c := range balanceStorageCases() {
+		t.Run(name, func(t *testing.T) {
+			require.NoError(t, TruncateData(), "failed to truncate data before test")
+			ctx := context.Background()
+
+			alice := mustDecodeUserID(t, mustCreateUser(t, "alice", "alice@mail.com").User.ID)
+			bob := mustDecodeUserID(t, mustCreateUser(t, "bob", "bob@mail.com").User.ID)
+
+			// balance is not populated yet
+			_, err := c.store.GetBalance(ctx, alice)
+			require.Equal(t, service.ErrNoBalance, err)
+
+			addTx := func(amount loan.Amount) {
+				tx := loan.Transaction{Kind: loan.KindLoan}
+				tx.AddLoan(alice, bob, amount, model.DefaultCurrency)
+				require.NoError(t, c.loans.AddTransaction(ctx, &tx))
+
+				// Events are delivered at least once
+				for i := 0; i < 2; i++ {
+					for _, e := range balance.EventsFromTransaction(tx) {
+						_, err := c.store.ApplyEvent(ctx, e)
+						require.NoError(t, err)
+					}
+				}
+			}
+
+			// transactions before population should be included into balance
+			addTx(500)
+			populateStoredBalance(t, c, alice, true)
+			requireStoredBalance(t, c.store, alice, map[user.ID]loan.Amount{bob: 500})
+
+			// repeated population is ignored
+			populateStoredBalance(t, c, alice, false)
+
+			addTx(250)
+			requireStoredBalance(t, c.store, alice, map[user.ID]loan.Amount{bob: 750})
+
+			// balance of user without cached balance is not updated
+			_, err = c.store.GetBalance(ctx, bob)
+			require.Equal(t, service.ErrNoBalance, err)
+
+			require.NoError(t, c.store.ClearBalance(ctx, alice))
+			_, err = c.store.GetBalance(ctx, alice)
+			require.Equal(t, service.ErrNoBalance, err)
+
+			addTx(100)
+			populateStoredBalance(t, c, alice, true)
+			requireStoredBalance(t, c.store, alice, map[user.ID]loan.Amount{bob: 850})
+
+			populateStoredBalance(t, c, bob, true)
+			requireStoredBalance(t, c.store, bob, map[user.ID]loan.Amount{alice: -850})
+		})
+	}
+}
+
+func mustDecodeUserID(t *testing.T, id string) user.ID {
+	uid, err := model.DecodeUUID(id)
+	require.NoError(t, err)
+	return *uid
+}
+
+func populateStoredBalance(t *testing.T, c balanceStorageCase, uid user.ID, expectPopulated bool) {
+	ctx := context.Background()
+	require.NoError(t, c.store.BeginPopulation(ctx, uid))
+
+	snapshot, seq, err := c.loans.UserBalanceSnapshot(ctx, uid)
+	require.NoError(t, err)
+
+	ok, err := c.store.CompletePopulation(ctx, uid, seq, snapshot...)
+	require.NoError(t, err)
+	require.Equal(t, expectPopulated, ok, "unexpected population result")
+}
+
+func requireStoredBalance(t *testing.T, store service.BalanceStorage, uid user.ID, expect map[user.ID]loan.Amount) {
+	items, err := store.GetBalance(context.Background(), uid)
+	require.NoError(t, err)
+
+	got := make(map[user.ID]loan.Amount, len(items))
+	for _, v := range items {
+		require.Equal(t, model.DefaultCurrency, v.Currency)
+		got[v.UserID] = v.Balance
+	}
+	require.Equal(t, expect, got)
+}
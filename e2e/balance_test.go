@@ -3,11 +3,16 @@ package e2e
 import (
 	"context"
 	"strconv"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
 	"github.com/x1unix/sbda-ledger/internal/model/loan"
 	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/repository"
 	"github.com/x1unix/sbda-ledger/pkg/ledger"
 )
 
@@ -30,7 +35,7 @@ func TestBalance(t *testing.T) {
 	// hey are out for dinner, and Alice pays the bill.
 	// The bill has to be split equally between them, so she enters a new expense named "Pizza!"
 	// of €42 to the "Friends" group.
-	require.NoError(t, Client.AddGroupExpense(gFriends.ID, 4200, alice.Token), "failed to add an pizza expense")
+	require.NoError(t, Client.AddGroupExpense(gFriends.ID, 4200, "", alice.Token), "failed to add an pizza expense")
 
 	// When Alice checks her balance, the system will let her know that Bob owes her €14 and Charlie owes her €14 as well.
 	b, err := Client.Balance(alice.Token)
@@ -41,9 +46,9 @@ func TestBalance(t *testing.T) {
 	}
 
 	// Check Alice balance
-	aliceBalance := balanceListToMap(b)
+	aliceBalance := balanceListToMap(b, model.DefaultCurrency)
 	require.Equal(t, expectAliceBalance, aliceBalance, "unexpected alice balance from response")
-	checkDatabaseAndCacheBalance(t, alice.User.ID, expectAliceBalance)
+	checkDatabaseAndCacheBalance(t, alice.User.ID, model.DefaultCurrency, expectAliceBalance)
 
 	// Check balance of Bob and Charlie
 	expectedRestBalance := map[string]int64{
@@ -53,9 +58,9 @@ func TestBalance(t *testing.T) {
 		b, err = Client.Balance(u.Token)
 		require.NoError(t, err, "failed to get balance of", u.User.Name, u.User.ID)
 
-		balanceMap := balanceListToMap(b)
+		balanceMap := balanceListToMap(b, model.DefaultCurrency)
 		require.Equalf(t, expectedRestBalance, balanceMap, "unexpected %s's balance from response", u.User.Name)
-		checkDatabaseAndCacheBalance(t, u.User.ID, expectedRestBalance)
+		checkDatabaseAndCacheBalance(t, u.User.ID, model.DefaultCurrency, expectedRestBalance)
 	}
 
 	// The day after, Alice and Bob are out for a coffee, and Bob pays the bill, €8.
@@ -67,7 +72,7 @@ func TestBalance(t *testing.T) {
 	require.NoError(t, Client.AddGroupMembers(gCoffee.ID, alice.Token, bob.User.ID))
 
 	// Put €8 bill for Bob and Alice
-	require.NoError(t, Client.AddGroupExpense(gCoffee.ID, 800, bob.Token))
+	require.NoError(t, Client.AddGroupExpense(gCoffee.ID, 800, "", bob.Token))
 
 	// When Alice checks her balance again, the system will let her know
 	// that Bob owes her €10, being the simplified debit of €14 he owed from
@@ -78,9 +83,9 @@ func TestBalance(t *testing.T) {
 	}
 	b, err = Client.Balance(alice.Token)
 	require.NoError(t, err, "failed to get Alice's balance")
-	aliceBalance = balanceListToMap(b)
+	aliceBalance = balanceListToMap(b, model.DefaultCurrency)
 	require.Equal(t, expectAliceBalance, aliceBalance, "unexpected alice balance from response")
-	checkDatabaseAndCacheBalance(t, alice.User.ID, expectAliceBalance)
+	checkDatabaseAndCacheBalance(t, alice.User.ID, model.DefaultCurrency, expectAliceBalance)
 
 	// Check Bob's balance.
 	// Bob's balance state also should be synced in cache after an update.
@@ -89,12 +94,101 @@ func TestBalance(t *testing.T) {
 	expectedBobBalance := map[string]int64{
 		alice.User.ID: -1000,
 	}
-	balanceMap := balanceListToMap(b)
+	balanceMap := balanceListToMap(b, model.DefaultCurrency)
 	require.Equalf(t, expectedBobBalance, balanceMap, "unexpected %s's balance from response", bob.User.Name)
-	checkDatabaseAndCacheBalance(t, bob.User.ID, expectedBobBalance)
+	checkDatabaseAndCacheBalance(t, bob.User.ID, model.DefaultCurrency, expectedBobBalance)
 }
 
-func balanceListToMap(l []ledger.Balance) map[string]int64 {
+func TestBalance_Currencies(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	charlie := mustCreateUser(t, "charlie", "charlie@mail.com")
+
+	// Unknown currency should be rejected
+	_, err := Client.CreateCurrencyGroup("bad currency", "XYZ", alice.Token)
+	shouldContainError(t, err, "400 Bad Request: invalid request payload")
+
+	gEUR, err := Client.CreateCurrencyGroup("paris", "EUR", alice.Token)
+	require.NoError(t, err, "failed to create a test group")
+	require.Equal(t, "EUR", gEUR.Currency)
+	require.NoError(t, Client.AddGroupMembers(gEUR.ID, alice.Token, bob.User.ID, charlie.User.ID),
+		"failed to add members to a test group")
+
+	gCHF, err := Client.CreateCurrencyGroup("zurich", "CHF", charlie.Token)
+	require.NoError(t, err, "failed to create a test group")
+	require.NoError(t, Client.AddGroupMembers(gCHF.ID, charlie.Token, alice.User.ID, bob.User.ID),
+		"failed to add members to a test group")
+
+	err = Client.AddGroupExpense(gEUR.ID, 100, "XYZ", alice.Token)
+	shouldContainError(t, err, "400 Bad Request: invalid request payload")
+
+	// Alice pays €30 for a dinner and Charlie pays CHF 60 for a taxi.
+	require.NoError(t, Client.AddGroupExpense(gEUR.ID, 3000, "", alice.Token))
+	require.NoError(t, Client.AddGroupExpense(gCHF.ID, 6000, "", charlie.Token))
+
+	b, err := Client.Balance(alice.Token)
+	require.NoError(t, err, "failed to get Alice's balance")
+	require.Len(t, b, 2, "balance should be grouped by currency")
+
+	expectEUR := map[string]int64{
+		bob.User.ID:     1000,
+		charlie.User.ID: 1000,
+	}
+	expectCHF := map[string]int64{
+		charlie.User.ID: -2000,
+	}
+	require.Equal(t, expectEUR, balanceListToMap(b, "EUR"), "unexpected EUR balance")
+	require.Equal(t, expectCHF, balanceListToMap(b, "CHF"), "unexpected CHF balance")
+	checkDatabaseAndCacheBalance(t, alice.User.ID, "EUR", expectEUR)
+	checkDatabaseAndCacheBalance(t, alice.User.ID, "CHF", expectCHF)
+}
+
+func TestBalance_ExchangeRates(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+
+	grp, err := Client.CreateCurrencyGroup("trip", "EUR", alice.Token)
+	require.NoError(t, err, "failed to create a test group")
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID),
+		"failed to add members to a test group")
+
+	err = Client.AddGroupExpense(grp.ID, 1000, "USD", alice.Token)
+	shouldContainError(t, err, "400 Bad Request: no exchange rate available for USD/EUR")
+
+	// Only EUR/USD rate is provided, so reverse rate should be used.
+	_, err = DB.Exec("INSERT INTO exchange_rates (base, quote, rate) VALUES ('EUR', 'USD', 1.25)")
+	require.NoError(t, err, "failed to add test exchange rate")
+
+	// Bob pays $50, which is €40
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 5000, "USD", bob.Token))
+
+	b, err := Client.Balance(alice.Token)
+	require.NoError(t, err, "failed to get Alice's balance")
+	require.Len(t, b, 1, "all expenses should be converted to group currency")
+	require.Equal(t, map[string]int64{bob.User.ID: -2000}, balanceListToMap(b, "EUR"))
+
+	var exp struct {
+		Amount           int64   `db:"amount"`
+		Currency         string  `db:"currency"`
+		OriginalAmount   int64   `db:"original_amount"`
+		OriginalCurrency string  `db:"original_currency"`
+		Rate             float64 `db:"rate"`
+	}
+	err = DB.Get(&exp, "SELECT amount, currency, original_amount, original_currency, rate FROM expenses WHERE group_id = $1", grp.ID)
+	require.NoError(t, err, "failed to get expense")
+	require.Equal(t, int64(4000), exp.Amount)
+	require.Equal(t, "EUR", exp.Currency)
+	require.Equal(t, int64(5000), exp.OriginalAmount)
+	require.Equal(t, "USD", exp.OriginalCurrency)
+	require.Equal(t, 0.8, exp.Rate)
+}
+
+func balanceListToMap(b ledger.Balances, cur model.Currency) map[string]int64 {
+	l := b[cur.String()]
 	out := make(map[string]int64, len(l))
 	for _, v := range l {
 		out[v.UserID] = v.Balance
@@ -102,19 +196,16 @@ func balanceListToMap(l []ledger.Balance) map[string]int64 {
 	return out
 }
 
-func checkDatabaseAndCacheBalance(t *testing.T, uid string, expect map[string]int64) {
-	checkCacheBalance(t, uid, expect)
-	checkCacheBalance(t, uid, expect)
+func checkDatabaseAndCacheBalance(t *testing.T, uid string, cur model.Currency, expect map[string]int64) {
+	checkCacheBalance(t, uid, cur, expect)
+	checkDatabaseBalance(t, uid, cur, expect)
 }
 
-func checkDatabaseBalance(t *testing.T, uid string, expect map[string]int64) {
+func checkDatabaseBalance(t *testing.T, uid string, cur model.Currency, expect map[string]int64) {
 	var out []loan.Balance
-	const query = "SELECT user_id, SUM(amount) as balance FROM (" +
-		"SELECT debtor_id AS user_id, amount FROM loans WHERE lender_id = $1" +
-		" UNION ALL " +
-		"SELECT lender_id AS user_id, amount * -1 FROM loans WHERE debtor_id = $1" +
-		") balance GROUP BY user_id"
-	err := DB.Select(&out, query, uid)
+	const query = "SELECT counterparty_id AS user_id, SUM(amount)::bigint as balance " +
+		"FROM journal_postings WHERE user_id = $1 AND currency = $2 GROUP BY counterparty_id"
+	err := DB.Select(&out, query, uid, cur)
 	require.NoError(t, err, "failed to calculate balance of user", uid)
 	got := make(map[string]int64, len(out))
 	for _, v := range out {
@@ -123,7 +214,7 @@ func checkDatabaseBalance(t *testing.T, uid string, expect map[string]int64) {
 	require.Equal(t, expect, got, "mismatch between DB and expected balance")
 }
 
-func checkCacheBalance(t *testing.T, uid string, expect map[string]int64) {
+func checkCacheBalance(t *testing.T, uid string, cur model.Currency, expect map[string]int64) {
 	// assert that cache flag is set
 	exits, err := Redis.Exists(context.Background(), "cached:"+uid).Result()
 	require.NoError(t, err, "failed to check if cache flag is set")
@@ -135,10 +226,221 @@ func checkCacheBalance(t *testing.T, uid string, expect map[string]int64) {
 	kv, err := Redis.HGetAll(context.Background(), "balance:"+uid).Result()
 	require.NoError(t, err, "failed to get keys from Redis")
 	got := make(map[string]int64, len(kv))
-	for debtorID, val := range kv {
+	for field, val := range kv {
+		// Balance fields have "<currency>:<uid>" format
+		debtorID := strings.TrimPrefix(field, cur.String()+":")
+		if debtorID == field {
+			continue
+		}
+
 		balance, err := strconv.ParseInt(val, 10, 64)
 		require.NoError(t, err, "failed to parse balance value for debtor", balance)
 		got[debtorID] = balance
 	}
 	require.Equal(t, expect, got, "mismatch between Redis and expected balance")
 }
+
+func TestBalance_ExpenseTooSmall(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	charlie := mustCreateUser(t, "charlie", "charlie@mail.com")
+	grp, err := Client.CreateGroup("pennies", alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID, charlie.User.ID))
+
+	// 0.01 / 3 is rounded to zero with default half up rounding
+	err = Client.AddGroupExpense(grp.ID, 1, "", alice.Token)
+	shouldContainError(t, err, "400 Bad Request: expense amount is too small to split")
+
+	var count int
+	require.NoError(t, DB.Get(&count, "SELECT COUNT(*) FROM expenses WHERE group_id = $1", grp.ID))
+	require.Zero(t, count, "expense should not be saved")
+	checkDatabaseBalance(t, alice.User.ID, model.DefaultCurrency, map[string]int64{})
+}
+
+func TestJournal_UnbalancedTransaction(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+
+	tx, err := DB.Beginx()
+	require.NoError(t, err)
+	defer tx.Rollback()
+
+	var tid string
+	require.NoError(t, tx.Get(&tid, "INSERT INTO journal_transactions (kind) VALUES ('loan') RETURNING id::text"))
+
+	// lender posting without debtor's counter posting
+	_, err = tx.Exec("INSERT INTO journal_postings (transaction_id, user_id, counterparty_id, currency, amount) "+
+		"VALUES ($1, $2, $3, 'EUR', 100)", tid, alice.User.ID, bob.User.ID)
+	require.NoError(t, err)
+
+	err = tx.Commit()
+	require.Error(t, err, "unbalanced journal transaction should be rejected")
+	require.Contains(t, err.Error(), "is not balanced")
+}
+
+func TestJournal_GroupLinkImmutable(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	grp, err := Client.CreateGroup("trip", alice.Token)
+	require.NoError(t, err)
+	other, err := Client.CreateGroup("other", alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID))
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 1000, "", alice.Token))
+
+	// transaction can't be moved to another group
+	_, err = DB.Exec("UPDATE journal_transactions SET group_id = $1 WHERE group_id = $2", other.ID, grp.ID)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "journal transactions are immutable")
+
+	event, err := Client.CreateEvent(grp.ID, ledger.EventParams{Name: "dinner"}, alice.Token)
+	require.NoError(t, err)
+	_, err = DB.Exec("UPDATE journal_transactions SET event_id = $1 WHERE group_id = $2", event.ID, grp.ID)
+	require.Error(t, err, "transaction can't be moved to an event")
+	require.Contains(t, err.Error(), "journal transactions are immutable")
+
+	// group link is part of transaction hash and can't be cleared
+	_, err = DB.Exec("UPDATE journal_transactions SET group_id = NULL WHERE group_id = $1", grp.ID)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "journal transactions are immutable")
+}
+
+func TestBalance_OutboxRedelivery(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+
+	grp, err := Client.CreateGroup("outbox", alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID))
+
+	// populate cache before expense
+	_, err = Client.Balance(alice.Token)
+	require.NoError(t, err)
+
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 1000, "", alice.Token))
+	expect := map[string]int64{bob.User.ID: 500}
+	checkDatabaseAndCacheBalance(t, alice.User.ID, model.DefaultCurrency, expect)
+
+	var pending int
+	require.NoError(t, DB.Get(&pending, "SELECT COUNT(*) FROM balance_outbox"))
+	require.Zero(t, pending, "applied events should be removed from outbox")
+
+	// Simulate event redelivery, already applied event should be ignored.
+	_, err = DB.Exec("INSERT INTO balance_outbox (transaction_id, user_id, deltas, created_at) "+
+		"SELECT transaction_id, user_id, jsonb_agg(jsonb_build_object("+
+		"'user_id', counterparty_id, 'currency', currency, 'balance', amount)), NOW() - INTERVAL '1 minute' "+
+		"FROM journal_postings WHERE user_id = $1 GROUP BY transaction_id, user_id", alice.User.ID)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		require.NoError(t, DB.Get(&pending, "SELECT COUNT(*) FROM balance_outbox"))
+		return pending == 0
+	}, 15*time.Second, 500*time.Millisecond, "outbox worker should process pending events")
+
+	checkDatabaseAndCacheBalance(t, alice.User.ID, model.DefaultCurrency, expect)
+}
+
+func TestBalance_ConcurrentPopulation(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	const (
+		expensesPerUser = 20
+		readsPerUser    = 20
+	)
+
+	users := []*ledger.LoginResponse{
+		mustCreateUser(t, "alice", "alice@mail.com"),
+		mustCreateUser(t, "bob", "bob@mail.com"),
+		mustCreateUser(t, "charlie", "charlie@mail.com"),
+	}
+
+	// Each user pays different amount, so all users have non-zero debts.
+	// Amounts are divisible by number of users to avoid rounding.
+	amounts := map[string]int64{
+		users[0].User.ID: 300,
+		users[1].User.ID: 600,
+		users[2].User.ID: 1200,
+	}
+
+	grp, err := Client.CreateGroup("race", users[0].Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, users[0].Token, users[1].User.ID, users[2].User.ID))
+
+	// Each user adds expenses while other goroutines drop balance cache
+	// and populate it again by querying balance.
+	wg := new(sync.WaitGroup)
+	errs := make(chan error, len(users)*(expensesPerUser+readsPerUser))
+	for _, u := range users {
+		wg.Add(2)
+		go func(u *ledger.LoginResponse) {
+			defer wg.Done()
+			for i := 0; i < expensesPerUser; i++ {
+				errs <- Client.AddGroupExpense(grp.ID, amounts[u.User.ID], "", u.Token)
+			}
+		}(u)
+
+		go func(u *ledger.LoginResponse) {
+			defer wg.Done()
+			for i := 0; i < readsPerUser; i++ {
+				if i%2 == 0 {
+					errs <- Redis.Del(context.Background(), "cached:"+u.User.ID, "balance:"+u.User.ID).Err()
+				}
+
+				_, err := Client.Balance(u.Token)
+				errs <- err
+			}
+		}(u)
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		require.NoError(t, err)
+	}
+
+	require.Eventually(t, func() bool {
+		var pending int
+		require.NoError(t, DB.Get(&pending, "SELECT COUNT(*) FROM balance_outbox"))
+		return pending == 0
+	}, 15*time.Second, 500*time.Millisecond, "outbox worker should process pending events")
+
+	// Each expense is split equally, so counterparty owes a share of each user's expense
+	// and user owes a share of each counterparty's expense.
+	loans := repository.NewLoansRepository(DB)
+	for _, u := range users {
+		balance, err := Client.Balance(u.Token)
+		require.NoError(t, err)
+
+		expect := make(map[string]int64, len(users)-1)
+		for _, other := range users {
+			if other.User.ID != u.User.ID {
+				diff := amounts[u.User.ID] - amounts[other.User.ID]
+				expect[other.User.ID] = expensesPerUser * diff / int64(len(users))
+			}
+		}
+		require.Equal(t, expect, balanceListToMap(balance, model.DefaultCurrency))
+		checkDatabaseAndCacheBalance(t, u.User.ID, model.DefaultCurrency, expect)
+
+		uid, err := model.DecodeUUID(u.User.ID)
+		require.NoError(t, err)
+		journal, err := loans.GetUserBalance(context.Background(), *uid)
+		require.NoError(t, err)
+
+		got := make(map[string]int64, len(journal))
+		for _, b := range journal {
+			if b.Currency == model.DefaultCurrency {
+				got[user.IDToString(b.UserID)] = b.Balance
+			}
+		}
+		require.Equal(t, expect, got, "mismatch between journal and expected balance")
+	}
+}
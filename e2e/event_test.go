@@ -0,0 +1,96 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/pkg/ledger"
+)
+
+func TestGroup_Events(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	carol := mustCreateUser(t, "carol", "carol@mail.com")
+	viewer := mustCreateUser(t, "viewer", "viewer@mail.com")
+	outsider := mustCreateUser(t, "outsider", "outsider@mail.com")
+	grp, err := Client.CreateGroup("flat", alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID, carol.User.ID))
+	require.NoError(t, Client.AddGroupMembersWithRole(grp.ID, ledger.RoleViewer, alice.Token, viewer.User.ID))
+
+	_, err = Client.CreateEvent(grp.ID, ledger.EventParams{
+		Name:         "ski weekend",
+		Participants: []string{alice.User.ID, outsider.User.ID},
+	}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request: event participants should be group members")
+	_, err = Client.CreateEvent(grp.ID, ledger.EventParams{Name: "ski weekend"}, viewer.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to post expenses in this group")
+
+	ski, err := Client.CreateEvent(grp.ID, ledger.EventParams{
+		Name:         "ski weekend",
+		Participants: []string{alice.User.ID, bob.User.ID},
+	}, bob.Token)
+	require.NoError(t, err)
+	require.Equal(t, grp.ID, ski.GroupID)
+	require.Equal(t, bob.User.ID, ski.CreatedBy)
+	require.ElementsMatch(t, []string{alice.User.ID, bob.User.ID}, ski.Participants)
+
+	events, err := Client.GroupEvents(grp.ID, viewer.Token)
+	require.NoError(t, err)
+	require.Len(t, events, 1)
+	require.Equal(t, ski.ID, events[0].ID)
+
+	got, err := Client.GroupEvent(grp.ID, ski.ID, carol.Token)
+	require.NoError(t, err)
+	require.Equal(t, ski.Name, got.Name)
+
+	// event expenses are shared only between event participants, payer might not participate
+	require.NoError(t, Client.AddEventExpense(grp.ID, ski.ID, 3000, "", alice.Token))
+	require.NoError(t, Client.AddEventExpense(grp.ID, ski.ID, 1000, "", carol.Token))
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 3000, "", alice.Token))
+
+	cur := model.DefaultCurrency.String()
+	report, err := Client.GroupReport(grp.ID, ski.ID, bob.Token)
+	require.NoError(t, err)
+	require.Equal(t, ski.ID, report.EventID)
+	require.Equal(t, []ledger.Total{{Currency: cur, Amount: 4000}}, report.Spent)
+	require.ElementsMatch(t, []ledger.Debt{
+		{LenderID: alice.User.ID, DebtorID: bob.User.ID, Currency: cur, Amount: 1500},
+		{LenderID: carol.User.ID, DebtorID: alice.User.ID, Currency: cur, Amount: 500},
+		{LenderID: carol.User.ID, DebtorID: bob.User.ID, Currency: cur, Amount: 500},
+	}, report.Debts)
+
+	// group report includes event expenses
+	report, err = Client.GroupReport(grp.ID, "", bob.Token)
+	require.NoError(t, err)
+	require.Empty(t, report.EventID)
+	require.Equal(t, []ledger.Total{{Currency: cur, Amount: 7000}}, report.Spent)
+	require.ElementsMatch(t, []ledger.Debt{
+		{LenderID: alice.User.ID, DebtorID: bob.User.ID, Currency: cur, Amount: 2250},
+		{LenderID: alice.User.ID, DebtorID: viewer.User.ID, Currency: cur, Amount: 750},
+		{LenderID: alice.User.ID, DebtorID: carol.User.ID, Currency: cur, Amount: 250},
+		{LenderID: carol.User.ID, DebtorID: bob.User.ID, Currency: cur, Amount: 500},
+	}, report.Debts)
+
+	// events are scoped to a group
+	other, err := Client.CreateGroup("other", carol.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(other.ID, carol.Token, alice.User.ID))
+	_, err = Client.GroupEvent(other.ID, ski.ID, carol.Token)
+	shouldContainError(t, err, "404 Not Found: event not found")
+	err = Client.AddEventExpense(other.ID, ski.ID, 1000, "", carol.Token)
+	shouldContainError(t, err, "404 Not Found: event not found")
+	_, err = Client.GroupReport(other.ID, ski.ID, carol.Token)
+	shouldContainError(t, err, "404 Not Found: event not found")
+
+	// events are visible only to group members
+	_, err = Client.GroupEvents(grp.ID, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	_, err = Client.GroupEvent(grp.ID, ski.ID, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	_, err = Client.GroupReport(grp.ID, "", outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+}
@@ -1,12 +1,15 @@
 package e2e
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
 	"github.com/x1unix/sbda-ledger/pkg/ledger"
 )
 
@@ -68,7 +71,7 @@ func TestGroup_Members(t *testing.T) {
 	require.Empty(t, memberList)
 }
 
-func compareMembers(t *testing.T, want []ledger.User, got []ledger.User) {
+func compareMembers(t *testing.T, want []ledger.User, got []ledger.Member) {
 	if len(want) != len(got) {
 		t.Fatal("member mismatch")
 	}
@@ -84,7 +87,8 @@ func compareMembers(t *testing.T, want []ledger.User, got []ledger.User) {
 			t.Fatalf("unexpected user in member list: %q (%s)", u.ID, u.Name)
 		}
 
-		require.Equal(t, expect, u)
+		require.Equal(t, expect, u.User)
+		require.Equal(t, ledger.RoleMember, u.Role)
 	}
 }
 
@@ -469,3 +473,522 @@ func TestGroup_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestGroup_ETag(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "etagowner", "etagowner@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	grp, err := Client.CreateGroup("etag", owner.Token)
+	require.NoError(t, err)
+
+	info, err := Client.GroupByID(grp.ID, owner.Token)
+	require.NoError(t, err)
+	require.NotEmpty(t, info.ETag, "group ETag is missing")
+
+	// first change with the same version wins
+	require.NoError(t, Client.AddGroupMembersIfMatch(grp.ID, info.ETag, owner.Token, alice.User.ID))
+	err = Client.AddGroupMembersIfMatch(grp.ID, info.ETag, owner.Token, bob.User.ID)
+	shouldContainError(t, err, "412 Precondition Failed")
+
+	members, err := Client.GroupMembers(grp.ID, owner.Token)
+	require.NoError(t, err)
+	compareMembers(t, []ledger.User{alice.User}, members)
+
+	updated, err := Client.GroupByID(grp.ID, owner.Token)
+	require.NoError(t, err)
+	require.NotEqual(t, info.ETag, updated.ETag, "ETag should change after group change")
+	require.Equal(t, info.Version+1, updated.Version)
+
+	err = Client.DeleteGroupMemberIfMatch(grp.ID, alice.User.ID, info.ETag, owner.Token)
+	shouldContainError(t, err, "412 Precondition Failed")
+	require.NoError(t, Client.DeleteGroupMemberIfMatch(grp.ID, alice.User.ID, updated.ETag, owner.Token))
+
+	err = Client.DeleteGroupIfMatch(grp.ID, updated.ETag, owner.Token)
+	shouldContainError(t, err, "412 Precondition Failed")
+
+	// changes without If-Match are not checked
+	require.NoError(t, Client.AddGroupMembers(grp.ID, owner.Token, bob.User.ID))
+
+	updated, err = Client.GroupByID(grp.ID, owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.DeleteGroupIfMatch(grp.ID, updated.ETag, owner.Token))
+}
+
+func TestGroup_Roles(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "roleowner", "roleowner@mail.com")
+	admin := mustCreateUser(t, "roleadmin", "roleadmin@mail.com")
+	member := mustCreateUser(t, "rolemember", "rolemember@mail.com")
+	viewer := mustCreateUser(t, "roleviewer", "roleviewer@mail.com")
+	grp, err := Client.CreateGroup("roles", owner.Token)
+	require.NoError(t, err)
+
+	err = Client.AddGroupMembersWithRole(grp.ID, ledger.RoleOwner, owner.Token, admin.User.ID)
+	shouldContainError(t, err, "400 Bad Request: invalid request payload")
+
+	require.NoError(t, Client.AddGroupMembersWithRole(grp.ID, ledger.RoleAdmin, owner.Token, admin.User.ID))
+	require.NoError(t, Client.AddGroupMembers(grp.ID, admin.Token, member.User.ID))
+	require.NoError(t, Client.AddGroupMembersWithRole(grp.ID, ledger.RoleViewer, admin.Token, viewer.User.ID))
+
+	members, err := Client.GroupMembers(grp.ID, viewer.Token)
+	require.NoError(t, err)
+	roles := make(map[string]string, len(members))
+	for _, m := range members {
+		roles[m.ID] = m.Role
+	}
+	require.Equal(t, map[string]string{
+		admin.User.ID:  ledger.RoleAdmin,
+		member.User.ID: ledger.RoleMember,
+		viewer.User.ID: ledger.RoleViewer,
+	}, roles)
+
+	// only owner can manage admins
+	outsider := mustCreateUser(t, "roleoutsider", "roleoutsider@mail.com")
+	err = Client.AddGroupMembersWithRole(grp.ID, ledger.RoleAdmin, admin.Token, outsider.User.ID)
+	shouldContainError(t, err, "403 Forbidden: you have no right to manage group admins")
+	err = Client.SetMemberRole(grp.ID, member.User.ID, ledger.RoleAdmin, admin.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to manage group admins")
+	err = Client.DeleteGroupMember(grp.ID, admin.User.ID, admin.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to manage group admins")
+	err = Client.SetMemberRole(grp.ID, owner.User.ID, ledger.RoleMember, admin.Token)
+	shouldContainError(t, err, "400 Bad Request: group creator role cannot be changed")
+
+	// members and viewers can't manage members
+	err = Client.AddGroupMembers(grp.ID, member.Token, outsider.User.ID)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+	err = Client.SetMemberRole(grp.ID, viewer.User.ID, ledger.RoleMember, viewer.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+
+	// viewers can't post expenses
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 300, "", member.Token))
+	err = Client.AddGroupExpense(grp.ID, 300, "", viewer.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to post expenses in this group")
+
+	require.NoError(t, Client.SetMemberRole(grp.ID, viewer.User.ID, ledger.RoleMember, admin.Token))
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 300, "", viewer.Token))
+
+	// only owner can delete group
+	err = Client.DeleteGroup(grp.ID, admin.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+
+	require.NoError(t, Client.SetMemberRole(grp.ID, admin.User.ID, ledger.RoleMember, owner.Token))
+	err = Client.DeleteGroupMember(grp.ID, member.User.ID, admin.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+	require.NoError(t, Client.DeleteGroup(grp.ID, owner.Token))
+}
+
+func TestGroup_TransferOwnership(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "transferowner", "transferowner@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	grp, err := Client.CreateGroup("transfer", owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, owner.Token, alice.User.ID))
+
+	err = Client.TransferGroup(grp.ID, bob.User.ID, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: ownership can be transferred only to a group member")
+	err = Client.TransferGroup(grp.ID, owner.User.ID, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: user is already a group owner")
+	err = Client.TransferGroup(grp.ID, alice.User.ID, alice.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+
+	// placeholder can't log in and manage a group
+	placeholder, err := Client.AddPlaceholder(grp.ID, "dave", owner.Token)
+	require.NoError(t, err)
+	err = Client.TransferGroup(grp.ID, placeholder.ID, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: ownership cannot be transferred to a placeholder member")
+	err = Client.SetMemberRole(grp.ID, placeholder.ID, ledger.RoleAdmin, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: placeholder member cannot be a group admin")
+	require.NoError(t, Client.DeleteGroupMember(grp.ID, placeholder.ID, owner.Token))
+
+	info, err := Client.GroupByID(grp.ID, owner.Token)
+	require.NoError(t, err)
+	err = Client.TransferGroupIfMatch(grp.ID, alice.User.ID, `"0"`, owner.Token)
+	shouldContainError(t, err, "412 Precondition Failed")
+	require.NoError(t, Client.TransferGroupIfMatch(grp.ID, alice.User.ID, info.ETag, owner.Token))
+
+	info, err = Client.GroupByID(grp.ID, alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, alice.User.ID, info.OwnerID)
+	require.Len(t, info.Members, 1)
+	require.Equal(t, owner.User, info.Members[0].User)
+	require.Equal(t, ledger.RoleAdmin, info.Members[0].Role)
+
+	// former owner is still a group member and shares expenses
+	for _, u := range []*ledger.LoginResponse{owner, alice} {
+		groups, err := Client.Groups(u.Token)
+		require.NoError(t, err)
+		require.Len(t, groups, 1)
+		require.Equal(t, grp.ID, groups[0].ID)
+	}
+
+	// backdated expense made before transfer is shared with former owner
+	history, err := Client.GroupMembersHistory(grp.ID, alice.Token)
+	require.NoError(t, err)
+	periods := make(map[string]ledger.Member, len(history))
+	for _, m := range history {
+		periods[m.ID] = m
+	}
+	require.NotNil(t, periods[alice.User.ID].LeftAt)
+	require.Nil(t, periods[owner.User.ID].LeftAt)
+	require.False(t, periods[owner.User.ID].JoinedAt.After(periods[alice.User.ID].JoinedAt),
+		"former owner should be a member since ownership start")
+	require.NoError(t, Client.AddGroupExpenseAt(grp.ID, 200, "", periods[alice.User.ID].JoinedAt, alice.Token))
+
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 300, "", alice.Token))
+	balance, err := Client.Balance(owner.Token)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{alice.User.ID: -250}, balanceListToMap(balance, model.DefaultCurrency))
+
+	// former owner can't delete group or remove new owner
+	err = Client.DeleteGroup(grp.ID, owner.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+	err = Client.DeleteGroupMember(grp.ID, alice.User.ID, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: group creator cannot be removed from the group")
+
+	err = Client.DeleteGroupMember(grp.ID, owner.User.ID, alice.Token)
+	shouldContainError(t, err, "409 Conflict")
+	require.NoError(t, Client.DeleteGroupMemberWithOptions(grp.ID, owner.User.ID,
+		ledger.RemovalOptions{Force: true}, alice.Token))
+	require.NoError(t, Client.DeleteGroup(grp.ID, alice.Token))
+}
+
+func TestGroup_LeaveWithBalance(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "leaveowner", "leaveowner@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	outsider := mustCreateUser(t, "outsider", "outsider@mail.com")
+	grp, err := Client.CreateGroup("leave", owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, owner.Token, alice.User.ID, bob.User.ID))
+
+	err = Client.LeaveGroup(grp.ID, ledger.RemovalOptions{}, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: group owner cannot leave the group")
+	err = Client.LeaveGroup(grp.ID, ledger.RemovalOptions{}, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: user is not a member of the group")
+
+	// owner and bob owe 100 to alice
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 300, "", alice.Token))
+
+	err = Client.LeaveGroup(grp.ID, ledger.RemovalOptions{}, alice.Token)
+	shouldContainError(t, err, "409 Conflict")
+	apiErr, ok := err.(*ledger.ErrorResponse)
+	require.True(t, ok)
+
+	var debts []ledger.Balance
+	require.NoError(t, json.Unmarshal(apiErr.ErrorData.Data, &debts))
+	require.Len(t, debts, 2)
+	for _, d := range debts {
+		require.Equal(t, int64(100), d.Balance)
+	}
+
+	err = Client.DeleteGroupMember(grp.ID, bob.User.ID, owner.Token)
+	shouldContainError(t, err, "409 Conflict")
+
+	err = Client.LeaveGroup(grp.ID, ledger.RemovalOptions{TransferTo: outsider.User.ID}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request: balance can be transferred only to a group member")
+
+	// debts to alice are transferred to bob, so bob owes only 100 to alice outside of group
+	balanceBefore, err := Client.Balance(alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.LeaveGroup(grp.ID, ledger.RemovalOptions{TransferTo: bob.User.ID}, alice.Token))
+
+	balanceAfter, err := Client.Balance(alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, totalBalance(balanceBefore), totalBalance(balanceAfter))
+	for _, v := range balanceAfter["EUR"] {
+		switch v.UserID {
+		case bob.User.ID:
+			require.Equal(t, int64(200), v.Balance)
+		default:
+			require.Zerof(t, v.Balance, "unexpected alice balance with %s", v.UserID)
+		}
+	}
+
+	members, err := Client.GroupMembers(grp.ID, owner.Token)
+	require.NoError(t, err)
+	compareMembers(t, []ledger.User{bob.User}, members)
+
+	// owner owes to bob inside a group now
+	err = Client.DeleteGroupMember(grp.ID, bob.User.ID, owner.Token)
+	shouldContainError(t, err, "409 Conflict")
+	require.NoError(t, Client.DeleteGroupMemberWithOptions(grp.ID, bob.User.ID,
+		ledger.RemovalOptions{Force: true}, owner.Token))
+}
+
+func totalBalance(b ledger.Balances) map[string]int64 {
+	out := make(map[string]int64, len(b))
+	for cur, items := range b {
+		for _, v := range items {
+			out[cur] += v.Balance
+		}
+	}
+	return out
+}
+
+func TestGroup_UpdateSettings(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	str := func(s string) *string { return &s }
+	owner := mustCreateUser(t, "settingsowner", "settingsowner@mail.com")
+	admin := mustCreateUser(t, "admin", "admin@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	grp, err := Client.CreateGroup("settings", owner.Token)
+	require.NoError(t, err)
+	require.Equal(t, ledger.SplitEqual, grp.SplitMode)
+	require.Equal(t, ledger.RoundHalfUp, grp.Rounding)
+	require.NoError(t, Client.AddGroupMembersWithRole(grp.ID, ledger.RoleAdmin, owner.Token, admin.User.ID))
+	require.NoError(t, Client.AddGroupMembers(grp.ID, owner.Token, alice.User.ID))
+
+	_, err = Client.UpdateGroup(grp.ID, ledger.GroupUpdate{Name: str("renamed")}, alice.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+	_, err = Client.UpdateGroup(grp.ID, ledger.GroupUpdate{}, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: no group settings to update")
+	_, err = Client.UpdateGroup(grp.ID, ledger.GroupUpdate{Name: str("a")}, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: invalid request payload")
+	_, err = Client.UpdateGroup(grp.ID, ledger.GroupUpdate{SplitMode: str("shares")}, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: invalid request payload")
+
+	info, err := Client.GroupByID(grp.ID, owner.Token)
+	require.NoError(t, err)
+	updated, err := Client.UpdateGroupIfMatch(grp.ID, ledger.GroupUpdate{
+		Name:        str("renamed"),
+		Description: str("weekend trip"),
+		SplitMode:   str(ledger.SplitExcludePayer),
+		Rounding:    str(ledger.RoundUp),
+	}, info.ETag, admin.Token)
+	require.NoError(t, err)
+	require.Equal(t, "renamed", updated.Name)
+	require.Equal(t, "weekend trip", updated.Description)
+	require.Equal(t, "EUR", updated.Currency)
+	require.Equal(t, ledger.SplitExcludePayer, updated.SplitMode)
+	require.Equal(t, ledger.RoundUp, updated.Rounding)
+	require.Equal(t, info.Version+1, updated.Version)
+
+	_, err = Client.UpdateGroupIfMatch(grp.ID, ledger.GroupUpdate{Currency: str("USD")}, info.ETag, owner.Token)
+	shouldContainError(t, err, "412 Precondition Failed")
+
+	// payer is excluded and share is rounded up: 1001 / 2 = 501
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 1001, "", owner.Token))
+	balance, err := Client.Balance(owner.Token)
+	require.NoError(t, err)
+	for _, v := range balance["EUR"] {
+		require.Equalf(t, int64(501), v.Balance, "unexpected owner balance with %s", v.UserID)
+	}
+}
+
+func TestGroup_Archive(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "archiveowner", "archiveowner@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	grp, err := Client.CreateGroup("archive", owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, owner.Token, alice.User.ID))
+	mustAcceptJoinRequests(t, grp.ID, owner.Token)
+
+	_, err = Client.ArchiveGroup(grp.ID, alice.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+
+	archived, err := Client.ArchiveGroup(grp.ID, owner.Token)
+	require.NoError(t, err)
+	require.NotNil(t, archived.ArchivedAt)
+	_, err = Client.ArchiveGroup(grp.ID, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: group is already archived")
+
+	// archived group is hidden from default listing
+	groups, err := Client.Groups(alice.Token)
+	require.NoError(t, err)
+	require.Empty(t, groups)
+	groups, err = Client.GroupsIncludeArchived(alice.Token)
+	require.NoError(t, err)
+	require.Len(t, groups, 1)
+	require.Equal(t, grp.ID, groups[0].ID)
+
+	// archived group is read-only
+	err = Client.AddGroupExpense(grp.ID, 300, "", alice.Token)
+	shouldContainError(t, err, "409 Conflict: group is archived")
+	err = Client.AddGroupMembers(grp.ID, owner.Token, bob.User.ID)
+	shouldContainError(t, err, "409 Conflict: group is archived")
+	_, err = Client.CreateInvite(grp.ID, ledger.InviteParams{}, owner.Token)
+	shouldContainError(t, err, "409 Conflict: group is archived")
+	_, err = Client.RequestJoin(grp.ID, "", bob.Token)
+	shouldContainError(t, err, "409 Conflict: group is archived")
+	err = Client.LeaveGroup(grp.ID, ledger.RemovalOptions{}, alice.Token)
+	shouldContainError(t, err, "409 Conflict: group is archived")
+
+	members, err := Client.GroupMembers(grp.ID, alice.Token)
+	require.NoError(t, err)
+	compareMembers(t, []ledger.User{alice.User}, members)
+
+	unarchived, err := Client.UnarchiveGroup(grp.ID, owner.Token)
+	require.NoError(t, err)
+	require.Nil(t, unarchived.ArchivedAt)
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 300, "", alice.Token))
+}
+
+func TestGroup_SoftDelete(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "softdeleteowner", "softdeleteowner@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	grp, err := Client.CreateGroup("softdelete", owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, owner.Token, alice.User.ID))
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 300, "", owner.Token))
+
+	require.NoError(t, Client.DeleteGroup(grp.ID, owner.Token))
+	_, err = Client.GroupByID(grp.ID, owner.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	groups, err := Client.GroupsIncludeArchived(alice.Token)
+	require.NoError(t, err)
+	require.Empty(t, groups)
+
+	_, err = Client.RestoreGroup(grp.ID, alice.Token)
+	shouldContainError(t, err, "404 Not Found: deleted group not found or restore window is expired")
+
+	restored, err := Client.RestoreGroup(grp.ID, owner.Token)
+	require.NoError(t, err)
+	require.Equal(t, grp.ID, restored.ID)
+
+	// group history is kept after restore
+	members, err := Client.GroupMembers(grp.ID, owner.Token)
+	require.NoError(t, err)
+	compareMembers(t, []ledger.User{alice.User}, members)
+
+	// group with unsettled debts can't be deleted permanently
+	err = Client.DeleteGroupPermanently(grp.ID, owner.Token)
+	shouldContainError(t, err, "409 Conflict: group has outstanding balances")
+
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 300, "", alice.Token))
+	require.NoError(t, Client.DeleteGroupPermanently(grp.ID, owner.Token))
+	_, err = Client.RestoreGroup(grp.ID, owner.Token)
+	shouldContainError(t, err, "404 Not Found")
+
+	// journal keeps links to removed group, since they are part of the chain
+	var linked int
+	require.NoError(t, DB.Get(&linked, "SELECT COUNT(*) FROM journal_transactions WHERE group_id = $1", grp.ID))
+	require.NotZero(t, linked)
+}
+
+func TestGroup_MembershipHistory(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	carol := mustCreateUser(t, "carol", "carol@mail.com")
+	grp, err := Client.CreateGroup("trip", alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID))
+	time.Sleep(10 * time.Millisecond)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, carol.User.ID))
+
+	members, err := Client.GroupMembers(grp.ID, alice.Token)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	require.Equal(t, bob.User.ID, members[0].ID)
+	require.Equal(t, carol.User.ID, members[1].ID)
+	require.Nil(t, members[0].LeftAt)
+	require.True(t, members[0].JoinedAt.Before(members[1].JoinedAt))
+
+	// taxi expense made before carol joined is not shared with carol
+	taxiDate := members[1].JoinedAt.Add(-time.Millisecond)
+	require.NoError(t, Client.AddGroupExpenseAt(grp.ID, 3000, "", taxiDate, alice.Token))
+
+	err = Client.AddGroupExpenseAt(grp.ID, 3000, "", time.Now().Add(time.Hour), alice.Token)
+	shouldContainError(t, err, "400 Bad Request: expense date cannot be in the future")
+
+	require.NoError(t, Client.DeleteGroupMemberWithOptions(grp.ID, bob.User.ID,
+		ledger.RemovalOptions{Force: true}, alice.Token))
+
+	// current expense is not shared with bob who left
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 2000, "", alice.Token))
+	b, err := Client.Balance(alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{
+		bob.User.ID:   1500,
+		carol.User.ID: 1000,
+	}, balanceListToMap(b, model.DefaultCurrency))
+
+	members, err = Client.GroupMembers(grp.ID, alice.Token)
+	require.NoError(t, err)
+	compareMembers(t, []ledger.User{carol.User}, members)
+
+	history, err := Client.GroupMembersHistory(grp.ID, alice.Token)
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, bob.User.ID, history[0].ID)
+	require.NotNil(t, history[0].LeftAt)
+	require.Equal(t, carol.User.ID, history[1].ID)
+	require.Nil(t, history[1].LeftAt)
+
+	// rejoined member has a new membership period
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID))
+	history, err = Client.GroupMembersHistory(grp.ID, alice.Token)
+	require.NoError(t, err)
+	require.Len(t, history, 3)
+	require.Equal(t, bob.User.ID, history[2].ID)
+	require.Nil(t, history[2].LeftAt)
+}
+
+func TestGroup_Visibility(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "visowner", "visowner@mail.com")
+	member := mustCreateUser(t, "vismember", "vismember@mail.com")
+	viewer := mustCreateUser(t, "visviewer", "visviewer@mail.com")
+	outsider := mustCreateUser(t, "visoutsider", "visoutsider@mail.com")
+	grp, err := Client.CreateGroup("visibility", owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, owner.Token, member.User.ID))
+	require.NoError(t, Client.AddGroupMembersWithRole(grp.ID, ledger.RoleViewer, owner.Token, viewer.User.ID))
+
+	// group is visible to all members
+	for _, u := range []*ledger.LoginResponse{owner, member, viewer} {
+		info, err := Client.GroupByID(grp.ID, u.Token)
+		require.NoError(t, err)
+		require.Equal(t, grp.ID, info.ID)
+
+		members, err := Client.GroupMembers(grp.ID, u.Token)
+		require.NoError(t, err)
+		require.Len(t, members, 2)
+	}
+
+	// group existence is not revealed to outsiders
+	_, err = Client.GroupByID(grp.ID, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	_, err = Client.GroupMembers(grp.ID, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	_, err = Client.GroupMembersHistory(grp.ID, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+
+	// former members lose access
+	require.NoError(t, Client.DeleteGroupMember(grp.ID, member.User.ID, owner.Token))
+	_, err = Client.GroupByID(grp.ID, member.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	_, err = Client.GroupMembers(grp.ID, member.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+
+	if AdminEmail == "" {
+		t.Log("no administrators configured, skipping admin access check")
+		return
+	}
+
+	admin := mustCreateUser(t, "vissysadmin", AdminEmail)
+	info, err := Client.GroupByID(grp.ID, admin.Token)
+	require.NoError(t, err)
+	require.Equal(t, grp.ID, info.ID)
+	members, err := Client.GroupMembersHistory(grp.ID, admin.Token)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	_, err = Client.GroupByID(uuid.New().String(), admin.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+}
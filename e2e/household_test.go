@@ -0,0 +1,103 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/pkg/ledger"
+)
+
+func TestGroup_Households(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	carol := mustCreateUser(t, "carol", "carol@mail.com")
+	dave := mustCreateUser(t, "dave", "dave@mail.com")
+	outsider := mustCreateUser(t, "outsider", "outsider@mail.com")
+	grp, err := Client.CreateGroup("flat", alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID, carol.User.ID, dave.User.ID))
+
+	_, err = Client.CreateHousehold(grp.ID, ledger.HouseholdParams{
+		Name:    "bob and outsider",
+		Members: []string{bob.User.ID, outsider.User.ID},
+	}, bob.Token)
+	shouldContainError(t, err, "400 Bad Request: household members should be group members")
+	_, err = Client.CreateHousehold(grp.ID, ledger.HouseholdParams{
+		Name:    "bob and bob",
+		Members: []string{bob.User.ID, bob.User.ID},
+	}, bob.Token)
+	shouldContainError(t, err, "400 Bad Request: household should have at least 2 members")
+	_, err = Client.CreateHousehold(grp.ID, ledger.HouseholdParams{
+		Name:    "bob and carol",
+		Members: []string{bob.User.ID, carol.User.ID},
+	}, dave.Token)
+	shouldContainError(t, err, "403 Forbidden: only group admins can create a household for other members")
+	_, err = Client.CreateHousehold(grp.ID, ledger.HouseholdParams{
+		Name:     "bob and carol",
+		Members:  []string{bob.User.ID, carol.User.ID},
+		HolderID: dave.User.ID,
+	}, carol.Token)
+	shouldContainError(t, err, "400 Bad Request: household holder should be a household member")
+
+	couple, err := Client.CreateHousehold(grp.ID, ledger.HouseholdParams{
+		Name:    "bob and carol",
+		Members: []string{bob.User.ID, carol.User.ID},
+		Weight:  2,
+	}, carol.Token)
+	require.NoError(t, err)
+	require.Equal(t, grp.ID, couple.GroupID)
+	require.Equal(t, bob.User.ID, couple.HolderID)
+	require.Equal(t, carol.User.ID, couple.CreatedBy)
+	require.Equal(t, 2, couple.Weight)
+	require.ElementsMatch(t, []string{bob.User.ID, carol.User.ID}, couple.Members)
+
+	_, err = Client.CreateHousehold(grp.ID, ledger.HouseholdParams{
+		Name:    "carol and dave",
+		Members: []string{carol.User.ID, dave.User.ID},
+	}, dave.Token)
+	shouldContainError(t, err, "409 Conflict: member already belongs to a household")
+
+	households, err := Client.GroupHouseholds(grp.ID, dave.Token)
+	require.NoError(t, err)
+	require.Len(t, households, 1)
+	require.Equal(t, couple.ID, households[0].ID)
+
+	got, err := Client.GroupHousehold(grp.ID, couple.ID, alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, couple.Name, got.Name)
+
+	// household is charged with weight and its debt is held by household holder
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 4000, "", alice.Token))
+
+	// household member pays on behalf of household
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 3000, "", carol.Token))
+
+	cur := model.DefaultCurrency.String()
+	report, err := Client.GroupReport(grp.ID, "", dave.Token)
+	require.NoError(t, err)
+	require.ElementsMatch(t, []ledger.Debt{
+		{LenderID: alice.User.ID, DebtorID: bob.User.ID, Currency: cur, Amount: 1250},
+		{LenderID: alice.User.ID, DebtorID: dave.User.ID, Currency: cur, Amount: 1000},
+		{LenderID: bob.User.ID, DebtorID: dave.User.ID, Currency: cur, Amount: 750},
+	}, report.Debts)
+
+	// household members can't leave a group until household is deleted
+	err = Client.LeaveGroup(grp.ID, ledger.RemovalOptions{Force: true}, carol.Token)
+	shouldContainError(t, err, "409 Conflict: member belongs to a household, delete household first")
+
+	// households are visible only to group members
+	_, err = Client.GroupHouseholds(grp.ID, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	_, err = Client.GroupHousehold(grp.ID, couple.ID, outsider.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+
+	err = Client.DeleteHousehold(grp.ID, couple.ID, dave.Token)
+	shouldContainError(t, err, "403 Forbidden: only group admins can delete a household of other members")
+	require.NoError(t, Client.DeleteHousehold(grp.ID, couple.ID, carol.Token))
+	_, err = Client.GroupHousehold(grp.ID, couple.ID, carol.Token)
+	shouldContainError(t, err, "404 Not Found: household not found")
+	require.NoError(t, Client.LeaveGroup(grp.ID, ledger.RemovalOptions{}, carol.Token))
+}
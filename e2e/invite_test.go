@@ -0,0 +1,169 @@
+package e2e
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/pkg/ledger"
+)
+
+func TestInvite_Accept(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "inviteowner", "inviteowner@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	carol := mustCreateUser(t, "carol", "carol@mail.com")
+	grp, err := Client.CreateGroup("invites", owner.Token)
+	require.NoError(t, err)
+
+	inv, err := Client.CreateInvite(grp.ID, ledger.InviteParams{MaxUses: 2}, owner.Token)
+	require.NoError(t, err)
+	require.NotEmpty(t, inv.Token)
+	require.Equal(t, ledger.RoleMember, inv.Role)
+	require.True(t, inv.ExpiresAt.After(inv.CreatedAt))
+
+	joined, err := Client.AcceptInvite(inv.Token, alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, grp.ID, joined.ID)
+
+	_, err = Client.AcceptInvite(inv.Token, alice.Token)
+	shouldContainError(t, err, "400 Bad Request: user is already a member of the group")
+	_, err = Client.AcceptInvite(inv.Token, owner.Token)
+	shouldContainError(t, err, "400 Bad Request: user is already a member of the group")
+
+	// members can't manage invites
+	_, err = Client.CreateInvite(grp.ID, ledger.InviteParams{}, alice.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+	_, err = Client.GroupInvites(grp.ID, alice.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+
+	_, err = Client.AcceptInvite(inv.Token, bob.Token)
+	require.NoError(t, err)
+	_, err = Client.AcceptInvite(inv.Token, carol.Token)
+	shouldContainError(t, err, "400 Bad Request: invite usage limit is reached")
+
+	members, err := Client.GroupMembers(grp.ID, owner.Token)
+	require.NoError(t, err)
+	compareMembers(t, []ledger.User{alice.User, bob.User}, members)
+
+	invites, err := Client.GroupInvites(grp.ID, owner.Token)
+	require.NoError(t, err)
+	require.Len(t, invites, 1)
+	require.Equal(t, inv.Token, invites[0].Token)
+	require.Equal(t, 2, invites[0].Uses)
+
+	// revoked invite can't be used
+	viewerInv, err := Client.CreateInvite(grp.ID, ledger.InviteParams{Role: ledger.RoleViewer}, owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.RevokeInvite(grp.ID, viewerInv.Token, owner.Token))
+	_, err = Client.AcceptInvite(viewerInv.Token, carol.Token)
+	shouldContainError(t, err, "404 Not Found: invite not found or expired")
+	err = Client.RevokeInvite(grp.ID, viewerInv.Token, owner.Token)
+	shouldContainError(t, err, "404 Not Found: invite not found or expired")
+}
+
+func TestInvite_Roles(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "inviteowner", "inviteowner@mail.com")
+	admin := mustCreateUser(t, "admin", "admin@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	grp, err := Client.CreateGroup("invites", owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembersWithRole(grp.ID, ledger.RoleAdmin, owner.Token, admin.User.ID))
+
+	_, err = Client.CreateInvite(grp.ID, ledger.InviteParams{Role: ledger.RoleAdmin}, admin.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to manage group admins")
+	_, err = Client.CreateInvite(grp.ID, ledger.InviteParams{ExpiresIn: 365 * 24 * 3600}, admin.Token)
+	shouldContainError(t, err, "400 Bad Request: invite lifetime should be between")
+
+	ownerInv, err := Client.CreateInvite(grp.ID, ledger.InviteParams{Role: ledger.RoleAdmin}, owner.Token)
+	require.NoError(t, err)
+	err = Client.RevokeInvite(grp.ID, ownerInv.Token, admin.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to manage group admins")
+
+	inv, err := Client.CreateInvite(grp.ID, ledger.InviteParams{Role: ledger.RoleViewer, ExpiresIn: 60}, admin.Token)
+	require.NoError(t, err)
+	_, err = Client.AcceptInvite(inv.Token, alice.Token)
+	require.NoError(t, err)
+
+	info, err := Client.GroupByID(grp.ID, owner.Token)
+	require.NoError(t, err)
+	for _, m := range info.Members {
+		if m.ID == alice.User.ID {
+			require.Equal(t, ledger.RoleViewer, m.Role)
+		}
+	}
+
+	// invites of deleted group can't be used
+	require.NoError(t, Client.DeleteGroup(grp.ID, owner.Token))
+	_, err = Client.AcceptInvite(ownerInv.Token, alice.Token)
+	shouldContainError(t, err, "404 Not Found: invite not found or expired")
+}
+
+func TestInvite_ClaimPlaceholder(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	carol := mustCreateUser(t, "carol", "carol@mail.com")
+	grp, err := Client.CreateGroup("trip", alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID))
+
+	_, err = Client.AddPlaceholder(grp.ID, "ghost", bob.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+	ghost, err := Client.AddPlaceholder(grp.ID, "ghost", alice.Token)
+	require.NoError(t, err)
+	require.True(t, ghost.Placeholder)
+	require.Equal(t, ledger.RoleMember, ghost.Role)
+
+	// placeholder shares expenses like regular member
+	require.NoError(t, Client.AddGroupExpense(grp.ID, 3000, "", alice.Token))
+	b, err := Client.Balance(alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{
+		bob.User.ID: 1000,
+		ghost.ID:    1000,
+	}, balanceListToMap(b, model.DefaultCurrency))
+
+	_, err = Client.CreateInvite(grp.ID, ledger.InviteParams{PlaceholderID: bob.User.ID}, alice.Token)
+	shouldContainError(t, err, "404 Not Found: placeholder member not found or already claimed")
+	_, err = Client.CreateInvite(grp.ID, ledger.InviteParams{PlaceholderID: ghost.ID, MaxUses: 2}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request: placeholder invite can be used only once")
+
+	inv, err := Client.CreateInvite(grp.ID, ledger.InviteParams{PlaceholderID: ghost.ID}, alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, ghost.ID, inv.PlaceholderID)
+	require.Equal(t, 1, inv.MaxUses)
+
+	_, err = Client.AcceptInvite(inv.Token, carol.Token)
+	require.NoError(t, err)
+	_, err = Client.AcceptInvite(inv.Token, bob.Token)
+	shouldContainError(t, err, "400 Bad Request: user is already a member of the group")
+
+	// carol takes placeholder place in group and balance
+	members, err := Client.GroupMembers(grp.ID, alice.Token)
+	require.NoError(t, err)
+	compareMembers(t, []ledger.User{bob.User, carol.User}, members)
+
+	expectAlice := map[string]int64{bob.User.ID: 1000, carol.User.ID: 1000, ghost.ID: 0}
+	checkDatabaseBalance(t, alice.User.ID, model.DefaultCurrency, expectAlice)
+	checkCacheBalance(t, alice.User.ID, model.DefaultCurrency, expectAlice)
+
+	b, err = Client.Balance(carol.Token)
+	require.NoError(t, err)
+	require.Equal(t, map[string]int64{alice.User.ID: -1000}, balanceListToMap(b, model.DefaultCurrency))
+
+	checkDatabaseBalance(t, ghost.ID, model.DefaultCurrency, map[string]int64{alice.User.ID: 0})
+	exists, err := Redis.Exists(context.Background(), "balance:"+ghost.ID, "cached:"+ghost.ID).Result()
+	require.NoError(t, err)
+	require.Zero(t, exists, "placeholder balance should be removed from cache")
+
+	// claimed placeholder can't be claimed again
+	_, err = Client.CreateInvite(grp.ID, ledger.InviteParams{PlaceholderID: ghost.ID}, alice.Token)
+	shouldContainError(t, err, "404 Not Found: placeholder member not found or already claimed")
+}
@@ -0,0 +1,99 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/pkg/ledger"
+)
+
+// mustAcceptJoinRequests allows users outside a group to request to join it.
+func mustAcceptJoinRequests(t *testing.T, gid string, token ledger.Token) {
+	t.Helper()
+	policy := ledger.JoinPolicyRequest
+	grp, err := Client.UpdateGroup(gid, ledger.GroupUpdate{JoinPolicy: &policy}, token)
+	require.NoError(t, err)
+	require.Equal(t, ledger.JoinPolicyRequest, grp.JoinPolicy)
+}
+
+func TestJoinRequest_Approve(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	owner := mustCreateUser(t, "joinowner", "joinowner@mail.com")
+	admin := mustCreateUser(t, "admin", "admin@mail.com")
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+	grp, err := Client.CreateGroup("join", owner.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembersWithRole(grp.ID, ledger.RoleAdmin, owner.Token, admin.User.ID))
+	require.Equal(t, ledger.JoinPolicyClosed, grp.JoinPolicy)
+
+	// closed group is hidden from outsiders
+	_, err = Client.RequestJoin(grp.ID, "", alice.Token)
+	shouldContainError(t, err, "404 Not Found: group not found")
+	mustAcceptJoinRequests(t, grp.ID, owner.Token)
+
+	_, err = Client.RequestJoin(grp.ID, "", admin.Token)
+	shouldContainError(t, err, "400 Bad Request: user is already a member of the group")
+
+	aliceReq, err := Client.RequestJoin(grp.ID, "let me in", alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, ledger.JoinRequestPending, aliceReq.Status)
+	require.Equal(t, "let me in", aliceReq.Message)
+	_, err = Client.RequestJoin(grp.ID, "", alice.Token)
+	shouldContainError(t, err, "400 Bad Request: join request is already pending")
+
+	bobReq, err := Client.RequestJoin(grp.ID, "", bob.Token)
+	require.NoError(t, err)
+
+	// requester can't see or decide group requests
+	_, err = Client.GroupJoinRequests(grp.ID, "", alice.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+	_, err = Client.ApproveJoinRequest(grp.ID, aliceReq.ID, alice.Token)
+	shouldContainError(t, err, "403 Forbidden: you have no right to control this group")
+
+	reqs, err := Client.GroupJoinRequests(grp.ID, "", admin.Token)
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+
+	approved, err := Client.ApproveJoinRequest(grp.ID, aliceReq.ID, admin.Token)
+	require.NoError(t, err)
+	require.Equal(t, ledger.JoinRequestApproved, approved.Status)
+	require.Equal(t, admin.User.ID, approved.DecidedBy)
+	require.NotNil(t, approved.DecidedAt)
+
+	denied, err := Client.DenyJoinRequest(grp.ID, bobReq.ID, owner.Token)
+	require.NoError(t, err)
+	require.Equal(t, ledger.JoinRequestDenied, denied.Status)
+
+	_, err = Client.ApproveJoinRequest(grp.ID, bobReq.ID, owner.Token)
+	shouldContainError(t, err, "404 Not Found: join request not found or already decided")
+
+	members, err := Client.GroupMembers(grp.ID, owner.Token)
+	require.NoError(t, err)
+	require.Len(t, members, 2)
+	for _, m := range members {
+		require.NotEqual(t, bob.User.ID, m.ID)
+	}
+
+	reqs, err = Client.GroupJoinRequests(grp.ID, "", owner.Token)
+	require.NoError(t, err)
+	require.Empty(t, reqs)
+	reqs, err = Client.GroupJoinRequests(grp.ID, "all", owner.Token)
+	require.NoError(t, err)
+	require.Len(t, reqs, 2)
+
+	// requester can poll decision
+	bobReqs, err := Client.UserJoinRequests(bob.Token)
+	require.NoError(t, err)
+	require.Len(t, bobReqs, 1)
+	require.Equal(t, ledger.JoinRequestDenied, bobReqs[0].Status)
+
+	// denied user can request again
+	_, err = Client.RequestJoin(grp.ID, "please", bob.Token)
+	require.NoError(t, err)
+
+	// requester is notified about decision by email
+	require.Contains(t, lastEmail(t, alice.User.Email), `Your request to join group "join" is approved.`)
+	require.Contains(t, lastEmail(t, bob.User.Email), `Your request to join group "join" is denied.`)
+}
@@ -0,0 +1,85 @@
+package e2e
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLedger_VerifyChain(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+
+	grp, err := Client.CreateGroup("chain", alice.Token)
+	require.NoError(t, err)
+	require.NoError(t, Client.AddGroupMembers(grp.ID, alice.Token, bob.User.ID))
+
+	for _, amount := range []int64{1000, 2000, 3000} {
+		require.NoError(t, Client.AddGroupExpense(grp.ID, amount, "", alice.Token))
+	}
+
+	_, err = Client.VerifyLedger("", alice.Token)
+	shouldContainError(t, err, "403 Forbidden: administrative access required")
+
+	if AdminEmail == "" {
+		t.Skip("no administrators configured, skipping chain verification")
+	}
+
+	admin := mustCreateUser(t, "ledgeradmin", AdminEmail)
+	report, err := Client.VerifyLedger("", admin.Token)
+	require.NoError(t, err)
+	require.True(t, report.Valid, "chain should be valid: %#v", report.BrokenLink)
+	require.Equal(t, int64(3), report.Checked)
+	head := report.Head
+
+	_, err = Client.VerifyLedger("bad", admin.Token)
+	shouldContainError(t, err, "400 Bad Request: invalid head parameter value")
+
+	// Remove last transaction, chain stays consistent but doesn't contain recorded head anymore.
+	queries := []string{
+		"ALTER TABLE journal_postings DISABLE TRIGGER USER",
+		"ALTER TABLE journal_transactions DISABLE TRIGGER USER",
+		"DELETE FROM journal_postings WHERE transaction_id = (SELECT id FROM journal_transactions WHERE seq = 3)",
+		"DELETE FROM journal_transactions WHERE seq = 3",
+		"ALTER TABLE journal_transactions ENABLE TRIGGER USER",
+		"ALTER TABLE journal_postings ENABLE TRIGGER USER",
+	}
+	for _, q := range queries {
+		_, err = DB.Exec(q)
+		require.NoError(t, err, q)
+	}
+
+	report, err = Client.VerifyLedger("", admin.Token)
+	require.NoError(t, err)
+	require.True(t, report.Valid, "truncated chain is consistent without recorded head")
+	require.Equal(t, int64(2), report.Checked)
+
+	report, err = Client.VerifyLedger(head, admin.Token)
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Equal(t, int64(2), report.Checked)
+	require.NotNil(t, report.BrokenLink)
+	require.Contains(t, report.BrokenLink.Reason, "chain might be truncated")
+
+	// Tamper second transaction. Immutability triggers have to be disabled for that.
+	queries = []string{
+		"ALTER TABLE journal_postings DISABLE TRIGGER USER",
+		"UPDATE journal_postings SET amount = amount * 2 " +
+			"WHERE transaction_id = (SELECT id FROM journal_transactions WHERE seq = 2)",
+		"ALTER TABLE journal_postings ENABLE TRIGGER USER",
+	}
+	for _, q := range queries {
+		_, err = DB.Exec(q)
+		require.NoError(t, err, q)
+	}
+
+	report, err = Client.VerifyLedger("", admin.Token)
+	require.NoError(t, err)
+	require.False(t, report.Valid)
+	require.Equal(t, int64(1), report.Checked)
+	require.NotNil(t, report.BrokenLink)
+	require.Equal(t, int64(2), report.BrokenLink.Seq)
+	require.Contains(t, report.BrokenLink.Reason, "transaction hash mismatch")
+}
@@ -6,6 +6,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/jmoiron/sqlx"
 	"github.com/stretchr/testify/require"
 	"github.com/x1unix/sbda-ledger/internal/app"
+	"github.com/x1unix/sbda-ledger/internal/config"
 	"github.com/x1unix/sbda-ledger/pkg/ledger"
 )
 
@@ -24,6 +26,16 @@ var (
 	Client *ledger.Client
 	DB     *sqlx.DB
 	Redis  *redis.Client
+
+	// AdminEmail is email of a user with administrative access.
+	//
+	// Empty if no administrators are configured.
+	AdminEmail string
+
+	// MailOutboxDir is directory where API writes outgoing emails.
+	//
+	// Empty if API doesn't use file mail provider.
+	MailOutboxDir string
 )
 
 const testPassword = "123456"
@@ -42,6 +54,18 @@ func TestMain(m *testing.M) {
 		log.Fatal("Failed to read dev config:", err)
 	}
 
+	if len(cfg.Admin.Emails) > 0 {
+		AdminEmail = cfg.Admin.Emails[0]
+	}
+
+	if cfg.Mail.Provider == config.MailProviderFile {
+		// API is started from repository root
+		MailOutboxDir = cfg.Mail.OutboxDir
+		if !filepath.IsAbs(MailOutboxDir) {
+			MailOutboxDir = filepath.Join("..", MailOutboxDir)
+		}
+	}
+
 	Client = ledger.NewClient(&http.Client{}, formatClientUrl(cfg.Server.ListenAddress))
 	if err := Client.Ping(); err != nil {
 		log.Fatalf("Failed to ping test Ledger API: %s. Run 'make run' to start test API", err)
@@ -74,7 +98,9 @@ func TruncateData() error {
 	}
 
 	queries := []string{
-		"TRUNCATE TABLE loans",
+		"TRUNCATE TABLE journal_transactions CASCADE",
+		"TRUNCATE TABLE expenses CASCADE",
+		"TRUNCATE TABLE exchange_rates",
 		"TRUNCATE TABLE group_membership",
 		"TRUNCATE TABLE groups CASCADE",
 		"TRUNCATE TABLE users CASCADE",
@@ -0,0 +1,99 @@
+package e2e
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/pkg/ledger"
+)
+
+var confirmationTokenRe = regexp.MustCompile(`Confirmation token: (\S+)`)
+
+// lastEmail returns contents of the last email sent to address.
+func lastEmail(t *testing.T, email string) string {
+	t.Helper()
+	if MailOutboxDir == "" {
+		t.Skip("API doesn't use file mail provider")
+	}
+
+	files, err := filepath.Glob(filepath.Join(MailOutboxDir, "*-"+email+".eml"))
+	require.NoError(t, err)
+	require.NotEmpty(t, files, "no emails sent to %s", email)
+
+	sort.Strings(files)
+	data, err := ioutil.ReadFile(files[len(files)-1])
+	require.NoError(t, err)
+	return string(data)
+}
+
+// lastConfirmationToken returns email change token from the last email sent to address.
+func lastConfirmationToken(t *testing.T, email string) string {
+	t.Helper()
+	match := confirmationTokenRe.FindStringSubmatch(lastEmail(t, email))
+	require.NotNil(t, match, "email has no confirmation token")
+	return match[1]
+}
+
+func TestUser_UpdateProfile(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	alice := mustCreateUser(t, "alice", "alice@mail.com")
+	bob := mustCreateUser(t, "bob", "bob@mail.com")
+
+	_, err := Client.UpdateProfile(ledger.ProfileUpdate{}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request: no profile fields to update")
+	_, err = Client.UpdateProfile(ledger.ProfileUpdate{Name: "al"}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request")
+	_, err = Client.UpdateProfile(ledger.ProfileUpdate{Email: "not-an-email"}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request")
+	_, err = Client.UpdateProfile(ledger.ProfileUpdate{Email: "BOB@mail.com"}, alice.Token)
+	shouldContainError(t, err, "400 Bad Request: record already exists")
+
+	// name is changed immediately
+	profile, err := Client.UpdateProfile(ledger.ProfileUpdate{Name: "alice cooper"}, alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, "alice cooper", profile.Name)
+	require.Empty(t, profile.PendingEmail)
+
+	// email is changed only after confirmation
+	profile, err = Client.UpdateProfile(ledger.ProfileUpdate{Email: "Alice.Cooper@mail.com"}, alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, "alice@mail.com", profile.Email)
+	require.Equal(t, "alice.cooper@mail.com", profile.PendingEmail)
+
+	usr, err := Client.CurrentUser(alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, "alice@mail.com", usr.Email)
+
+	token := lastConfirmationToken(t, "alice.cooper@mail.com")
+	_, err = Client.ConfirmEmail(token, bob.Token)
+	shouldContainError(t, err, "404 Not Found: email change not found or expired")
+	_, err = Client.ConfirmEmail("foo", alice.Token)
+	shouldContainError(t, err, "404 Not Found: email change not found or expired")
+	_, err = Client.ConfirmEmail("user:"+alice.User.ID, alice.Token)
+	shouldContainError(t, err, "404 Not Found: email change not found or expired")
+
+	usr, err = Client.ConfirmEmail(token, alice.Token)
+	require.NoError(t, err)
+	require.Equal(t, "alice.cooper@mail.com", usr.Email)
+	require.Equal(t, "alice cooper", usr.Name)
+
+	// token can be used only once
+	_, err = Client.ConfirmEmail(token, alice.Token)
+	shouldContainError(t, err, "404 Not Found: email change not found or expired")
+
+	_, err = Client.Login(ledger.Credentials{Email: "alice.cooper@mail.com", Password: testPassword})
+	require.NoError(t, err)
+
+	// email taken after change request can't be confirmed
+	_, err = Client.UpdateProfile(ledger.ProfileUpdate{Email: "carol@mail.com"}, bob.Token)
+	require.NoError(t, err)
+	token = lastConfirmationToken(t, "carol@mail.com")
+	mustCreateUser(t, "carol", "carol@mail.com")
+	_, err = Client.ConfirmEmail(token, bob.Token)
+	shouldContainError(t, err, "400 Bad Request: record already exists")
+}
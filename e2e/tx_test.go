@@ -0,0 +1,73 @@
+package e2e
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/repository"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+func TestTxManager_WithinTransaction(t *testing.T) {
+	require.NoError(t, TruncateData(), "failed to truncate data before test")
+
+	ctx := context.Background()
+	txm := repository.NewTxManager(DB)
+	users := repository.NewUserRepository(DB)
+	newUser := user.User{Props: user.Props{Email: "alice@mail.com", Name: "alice"}}
+	require.NoError(t, newUser.SetPassword(testPassword))
+
+	// rolled back transaction
+	errAbort := errors.New("abort")
+	committed := false
+	err := txm.WithinTransaction(ctx, func(ctx context.Context) error {
+		_, err := users.AddUser(ctx, newUser)
+		require.NoError(t, err)
+
+		// nested call is part of outer transaction
+		err = txm.WithinTransaction(ctx, func(ctx context.Context) error {
+			exists, err := users.Exists(ctx, newUser.Email)
+			require.NoError(t, err)
+			require.True(t, exists, "uncommitted user should be visible in transaction")
+			return nil
+		})
+		require.NoError(t, err)
+
+		txm.AfterCommit(ctx, func() {
+			committed = true
+		})
+		return errAbort
+	})
+	require.Equal(t, errAbort, err)
+	require.False(t, committed, "after commit hook called on rollback")
+
+	exists, err := users.Exists(ctx, newUser.Email)
+	require.NoError(t, err)
+	require.False(t, exists, "user should not be saved after rollback")
+
+	// committed transaction
+	err = txm.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := users.AddUser(ctx, newUser); err != nil {
+			return err
+		}
+
+		txm.AfterCommit(ctx, func() {
+			committed = true
+		})
+		require.False(t, committed, "after commit hook called before commit")
+		return nil
+	})
+	require.NoError(t, err)
+	require.True(t, committed, "after commit hook not called")
+
+	exists, err = users.Exists(ctx, newUser.Email)
+	require.NoError(t, err)
+	require.True(t, exists, "user should be saved after commit")
+
+	// duplicate user
+	_, err = users.AddUser(ctx, newUser)
+	require.Equal(t, service.ErrExists, err)
+}
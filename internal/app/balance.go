@@ -0,0 +1,25 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/x1unix/sbda-ledger/internal/config"
+	"github.com/x1unix/sbda-ledger/internal/repository"
+	"github.com/x1unix/sbda-ledger/internal/service"
+	"go.uber.org/zap"
+)
+
+// ProvideBalanceStorage returns users balance storage and loans journal storage according to config.
+//
+// Journal storage depends on balance storage, since materialized balance
+// is updated in the same transaction as journal postings.
+func ProvideBalanceStorage(logger *zap.Logger, cfg config.Balance, conn *Connectors) (service.BalanceStorage, *repository.LoansRepository, error) {
+	switch cfg.Storage {
+	case config.BalanceStorageRedis, "":
+		return repository.NewBalanceRepository(logger, conn.Redis), repository.NewLoansRepository(conn.DB), nil
+	case config.BalanceStoragePostgres:
+		return repository.NewPairBalanceRepository(conn.DB), repository.NewMaterializedLoansRepository(conn.DB), nil
+	default:
+		return nil, nil, fmt.Errorf("unsupported balance storage %q", cfg.Storage)
+	}
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/x1unix/sbda-ledger/internal/config"
+	"github.com/x1unix/sbda-ledger/internal/mail"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+// ProvideMailer returns mailer according to config.
+func ProvideMailer(cfg config.Mail) (service.Mailer, error) {
+	switch cfg.Provider {
+	case config.MailProviderFile, "":
+		return mail.NewFileMailer(cfg.OutboxDir, cfg.From)
+	case config.MailProviderSMTP:
+		return mail.NewSMTPMailer(cfg.SMTP.Address, cfg.From, cfg.SMTP.Username, cfg.SMTP.Password)
+	default:
+		return nil, fmt.Errorf("unsupported mail provider %q", cfg.Provider)
+	}
+}
@@ -0,0 +1,21 @@
+package app
+
+import (
+	"fmt"
+
+	"github.com/x1unix/sbda-ledger/internal/config"
+	"github.com/x1unix/sbda-ledger/internal/repository"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+// ProvideRateProvider returns exchange rate provider according to config.
+func ProvideRateProvider(cfg config.Rates, conn *Connectors) (service.RateProvider, error) {
+	switch cfg.Provider {
+	case config.RatesProviderDB, "":
+		return repository.NewRatesRepository(conn.DB), nil
+	case config.RatesProviderFile:
+		return repository.NewFileRates(cfg.File)
+	default:
+		return nil, fmt.Errorf("unsupported exchange rate provider %q", cfg.Provider)
+	}
+}
@@ -2,6 +2,7 @@ package app
 
 import (
 	"context"
+	"fmt"
 	"net/http"
 	"sync"
 
@@ -15,26 +16,60 @@ import (
 )
 
 type Service struct {
-	server *web.Server
-	logger *zap.Logger
+	server         *web.Server
+	logger         *zap.Logger
+	balanceChecker *service.BalanceChecker
+	balanceCheck   config.BalanceCheck
+	balanceOutbox  *service.BalanceOutbox
 }
 
-func NewService(baseCtx context.Context, logger *zap.Logger, conn *Connectors, cfg *config.Config) *Service {
+func NewService(baseCtx context.Context, logger *zap.Logger, conn *Connectors, cfg *config.Config) (*Service, error) {
 	srv := web.NewServer(cfg.Server.ListenParams())
 
-	balanceStore := repository.NewBalanceRepository(logger, conn.Redis)
-	loansStore := repository.NewLoansRepository(conn.DB)
+	rateProvider, err := ProvideRateProvider(cfg.Rates, conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize exchange rate provider: %w", err)
+	}
+
+	balanceStore, loansStore, err := ProvideBalanceStorage(logger, cfg.Balance, conn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize balance storage: %w", err)
+	}
+
+	mailer, err := ProvideMailer(cfg.Mail)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize mailer: %w", err)
+	}
+
 	groupStore := repository.NewGroupRepository(conn.DB)
+	expenseStore := repository.NewExpenseRepository(conn.DB)
 	userStore := repository.NewUserRepository(conn.DB)
 	sessionStore := repository.NewSessionRepository(conn.Redis)
+	inviteStore := repository.NewInviteRepository(conn.Redis)
+	emailChangeStore := repository.NewEmailChangeRepository(conn.Redis)
+	joinRequestStore := repository.NewJoinRequestRepository(conn.DB)
+	activityStore := repository.NewActivityRepository(conn.DB)
+	eventStore := repository.NewEventRepository(conn.DB)
+	householdStore := repository.NewHouseholdRepository(conn.DB)
+	outboxStore := repository.NewOutboxRepository(conn.DB)
+	txManager := repository.NewTxManager(conn.DB)
 
-	userSvc := service.NewUsersService(logger, userStore)
+	userSvc := service.NewUsersService(logger, userStore, emailChangeStore, mailer, txManager, cfg.Admin.Emails)
 	authSvc := service.NewAuthService(logger, userSvc, sessionStore)
-	loanSvc := service.NewLoanService(baseCtx, logger, balanceStore, loansStore)
-	grpSvc := service.NewGroupService(logger, groupStore, loanSvc)
+	balanceOutbox := service.NewBalanceOutbox(logger, outboxStore, balanceStore)
+	loanSvc := service.NewLoanService(baseCtx, logger, balanceStore, loansStore, balanceOutbox, activityStore, txManager)
+	grpSvc := service.NewGroupService(logger, groupStore, expenseStore, rateProvider, loanSvc, loansStore, userSvc, activityStore, eventStore, householdStore, txManager)
+	eventSvc := service.NewEventService(logger, groupStore, eventStore, expenseStore, loansStore, userSvc)
+	householdSvc := service.NewHouseholdService(logger, groupStore, householdStore, userSvc)
+	placeholderSvc := service.NewPlaceholderService(logger, groupStore, userStore, loanSvc, loansStore, balanceStore, activityStore, txManager)
+	inviteSvc := service.NewInviteService(logger, groupStore, inviteStore, placeholderSvc, activityStore, txManager)
+	joinRequestSvc := service.NewJoinRequestService(logger, groupStore, joinRequestStore, userStore, mailer, activityStore, txManager)
+	chainVerifier := service.NewChainVerifier(logger, loansStore)
+	balanceChecker := service.NewBalanceChecker(logger, balanceStore, loansStore, userStore)
 
 	hWrapper := web.NewWrapper(logger.Named("http"))
 	requireAuth := hWrapper.MiddlewareFunc(middleware.NewAuthMiddleware(authSvc))
+	requireAdmin := hWrapper.MiddlewareFunc(middleware.NewAdminMiddleware(userSvc))
 
 	// General
 	srv.Router.Methods(http.MethodGet).
@@ -71,16 +106,81 @@ func NewService(baseCtx context.Context, logger *zap.Logger, conn *Connectors, c
 		HandlerFunc(hWrapper.WrapResourceHandler(groupHandler.CreateGroup))
 	groupRouter.Path("/groups/{groupId}").Methods(http.MethodGet).
 		HandlerFunc(hWrapper.WrapResourceHandler(groupHandler.GetGroupInfo))
+	groupRouter.Path("/groups/{groupId}").Methods(http.MethodPatch).
+		HandlerFunc(hWrapper.WrapResourceHandler(groupHandler.UpdateGroup))
 	groupRouter.Path("/groups/{groupId}").Methods(http.MethodDelete).
 		HandlerFunc(hWrapper.WrapHandler(groupHandler.DeleteGroup))
+	groupRouter.Path("/groups/{groupId}/archive").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(groupHandler.ArchiveGroup))
+	groupRouter.Path("/groups/{groupId}/unarchive").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(groupHandler.UnarchiveGroup))
+	groupRouter.Path("/groups/{groupId}/restore").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(groupHandler.RestoreGroup))
 	groupRouter.Path("/groups/{groupId}/expenses").Methods(http.MethodPost).
 		HandlerFunc(hWrapper.WrapHandler(groupHandler.LogExpense))
+	groupRouter.Path("/groups/{groupId}/activity").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(groupHandler.GetActivity))
 	groupRouter.Path("/groups/{groupId}/members").Methods(http.MethodGet).
 		HandlerFunc(hWrapper.WrapResourceHandler(groupHandler.GetMembers))
 	groupRouter.Path("/groups/{groupId}/members").Methods(http.MethodPost).
 		HandlerFunc(hWrapper.WrapHandler(groupHandler.AddMembers))
+	groupRouter.Path("/groups/{groupId}/members/self").Methods(http.MethodDelete).
+		HandlerFunc(hWrapper.WrapHandler(groupHandler.LeaveGroup))
 	groupRouter.Path("/groups/{groupId}/members/{userId}").Methods(http.MethodDelete).
 		HandlerFunc(hWrapper.WrapHandler(groupHandler.RemoveMember))
+	groupRouter.Path("/groups/{groupId}/members/{userId}/role").Methods(http.MethodPut).
+		HandlerFunc(hWrapper.WrapHandler(groupHandler.SetMemberRole))
+	groupRouter.Path("/groups/{groupId}/transfer").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapHandler(groupHandler.TransferOwnership))
+
+	// Group events
+	eventHandler := handler.NewEventHandler(eventSvc)
+	groupRouter.Path("/groups/{groupId}/events").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(eventHandler.GetEvents))
+	groupRouter.Path("/groups/{groupId}/events").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(eventHandler.CreateEvent))
+	groupRouter.Path("/groups/{groupId}/events/{eventId}").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(eventHandler.GetEvent))
+	groupRouter.Path("/groups/{groupId}/report").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(eventHandler.GetReport))
+
+	// Group households
+	householdHandler := handler.NewHouseholdHandler(householdSvc)
+	groupRouter.Path("/groups/{groupId}/households").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(householdHandler.GetHouseholds))
+	groupRouter.Path("/groups/{groupId}/households").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(householdHandler.CreateHousehold))
+	groupRouter.Path("/groups/{groupId}/households/{householdId}").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(householdHandler.GetHousehold))
+	groupRouter.Path("/groups/{groupId}/households/{householdId}").Methods(http.MethodDelete).
+		HandlerFunc(hWrapper.WrapHandler(householdHandler.DeleteHousehold))
+
+	// Group placeholder members
+	placeholderHandler := handler.NewPlaceholderHandler(placeholderSvc)
+	groupRouter.Path("/groups/{groupId}/placeholders").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(placeholderHandler.AddPlaceholder))
+
+	// Group invites
+	inviteHandler := handler.NewInviteHandler(inviteSvc)
+	groupRouter.Path("/groups/{groupId}/invites").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(inviteHandler.GetInvites))
+	groupRouter.Path("/groups/{groupId}/invites").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(inviteHandler.CreateInvite))
+	groupRouter.Path("/groups/{groupId}/invites/{token}").Methods(http.MethodDelete).
+		HandlerFunc(hWrapper.WrapHandler(inviteHandler.RevokeInvite))
+	groupRouter.Path("/invites/{token}/accept").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(inviteHandler.AcceptInvite))
+
+	// Group join requests
+	joinRequestHandler := handler.NewJoinRequestHandler(joinRequestSvc)
+	groupRouter.Path("/groups/{groupId}/join-requests").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(joinRequestHandler.GetGroupRequests))
+	groupRouter.Path("/groups/{groupId}/join-requests").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(joinRequestHandler.RequestJoin))
+	groupRouter.Path("/groups/{groupId}/join-requests/{requestId}/approve").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(joinRequestHandler.ApproveRequest))
+	groupRouter.Path("/groups/{groupId}/join-requests/{requestId}/deny").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(joinRequestHandler.DenyRequest))
 
 	// Users
 	usrHandler := handler.NewUserHandler(userSvc, loanSvc)
@@ -90,15 +190,36 @@ func NewService(baseCtx context.Context, logger *zap.Logger, conn *Connectors, c
 		HandlerFunc(hWrapper.WrapResourceHandler(usrHandler.GetUsersList))
 	usrRouter.Path("/users/self").Methods(http.MethodGet).
 		HandlerFunc(hWrapper.WrapResourceHandler(usrHandler.GetCurrentUser))
+	usrRouter.Path("/users/self").Methods(http.MethodPatch).
+		HandlerFunc(hWrapper.WrapResourceHandler(usrHandler.UpdateProfile))
+	usrRouter.Path("/users/self/email/confirm").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(usrHandler.ConfirmEmail))
 	usrRouter.Path("/users/self/balance").Methods(http.MethodGet).
 		HandlerFunc(hWrapper.WrapResourceHandler(usrHandler.GetBalance))
+	usrRouter.Path("/users/self/join-requests").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(joinRequestHandler.GetUserRequests))
 	usrRouter.Path("/users/{userId}").Methods(http.MethodGet).
 		HandlerFunc(hWrapper.WrapResourceHandler(usrHandler.GetByID))
 
+	// Administration
+	ledgerHandler := handler.NewLedgerHandler(chainVerifier)
+	adminHandler := handler.NewAdminHandler(balanceChecker)
+	adminRouter := srv.Router.PathPrefix("/admin").Subrouter()
+	adminRouter.Use(requireAuth, requireAdmin)
+	adminRouter.Path("/balance/check").Methods(http.MethodPost).
+		HandlerFunc(hWrapper.WrapResourceHandler(adminHandler.CheckBalance))
+	adminRouter.Path("/metrics").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapHandler(adminHandler.Metrics))
+	adminRouter.Path("/ledger/verify").Methods(http.MethodGet).
+		HandlerFunc(hWrapper.WrapResourceHandler(ledgerHandler.VerifyChain))
+
 	return &Service{
-		server: srv,
-		logger: logger,
-	}
+		server:         srv,
+		logger:         logger,
+		balanceChecker: balanceChecker,
+		balanceCheck:   cfg.BalanceCheck,
+		balanceOutbox:  balanceOutbox,
+	}, nil
 }
 
 // Start starts the service
@@ -116,6 +237,11 @@ func (s Service) Start(ctx context.Context) {
 		}
 	}()
 
+	go s.balanceOutbox.Start(ctx, service.OutboxPollInterval)
+	if interval := s.balanceCheck.Interval.Duration; interval > 0 {
+		go s.balanceChecker.Start(ctx, interval, s.balanceCheck.Rebuild)
+	}
+
 	go func() {
 		<-ctx.Done()
 		if err := s.server.Shutdown(ctx); err != nil {
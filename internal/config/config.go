@@ -64,11 +64,86 @@ func (r Redis) RedisOptions() *redis.Options {
 	}
 }
 
+const (
+	// RatesProviderDB is exchange rate provider which uses database table
+	RatesProviderDB = "db"
+
+	// RatesProviderFile is exchange rate provider which uses a local file
+	RatesProviderFile = "file"
+)
+
+// Rates is exchange rates provider configuration
+type Rates struct {
+	Provider string `envconfig:"LGR_RATES_PROVIDER" default:"db" yaml:"provider"`
+	File     string `envconfig:"LGR_RATES_FILE" yaml:"file"`
+}
+
+const (
+	// BalanceStorageRedis keeps users balance cache in Redis
+	BalanceStorageRedis = "redis"
+
+	// BalanceStoragePostgres keeps materialized users balance in database
+	BalanceStoragePostgres = "postgres"
+)
+
+// Balance is users balance storage configuration
+type Balance struct {
+	Storage string `envconfig:"LGR_BALANCE_STORAGE" default:"redis" yaml:"storage"`
+}
+
+// Admin is administrative access configuration
+type Admin struct {
+	// Emails is list of emails of users with administrative access.
+	Emails []string `envconfig:"LGR_ADMIN_EMAILS" yaml:"emails"`
+}
+
+// BalanceCheck is periodic balance cache consistency check configuration
+type BalanceCheck struct {
+	// Interval is check interval. Periodic check is disabled if interval is zero.
+	Interval Duration `envconfig:"LGR_BALANCE_CHECK_INTERVAL" yaml:"interval"`
+
+	// Rebuild enables rebuild of inconsistent balance cache.
+	Rebuild bool `envconfig:"LGR_BALANCE_CHECK_REBUILD" yaml:"rebuild"`
+}
+
+const (
+	// MailProviderSMTP sends emails using SMTP server
+	MailProviderSMTP = "smtp"
+
+	// MailProviderFile writes emails to outbox directory, can be used for offline use
+	MailProviderFile = "file"
+)
+
+// SMTP is SMTP server configuration
+type SMTP struct {
+	Address  string `envconfig:"LGR_SMTP_ADDRESS" yaml:"address"`
+	Username string `envconfig:"LGR_SMTP_USER" yaml:"username"`
+	Password string `envconfig:"LGR_SMTP_PASSWORD" yaml:"password"`
+}
+
+// Mail is outgoing email configuration
+type Mail struct {
+	Provider string `envconfig:"LGR_MAIL_PROVIDER" default:"file" yaml:"provider"`
+
+	// From is sender address
+	From string `envconfig:"LGR_MAIL_FROM" default:"ledger@localhost" yaml:"from"`
+
+	// OutboxDir is directory where emails are written by "file" provider.
+	OutboxDir string `envconfig:"LGR_MAIL_OUTBOX_DIR" default:"mail" yaml:"outbox_dir"`
+
+	SMTP SMTP `yaml:"smtp"`
+}
+
 type Config struct {
-	Production bool         `envconfig:"LGR_PRODUCTION" default:"false" yaml:"production"`
-	Server     ServerConfig `yaml:"server"`
-	DB         Database     `yaml:"db"`
-	Redis      Redis        `yaml:"redis"`
+	Production   bool         `envconfig:"LGR_PRODUCTION" default:"false" yaml:"production"`
+	Server       ServerConfig `yaml:"server"`
+	DB           Database     `yaml:"db"`
+	Redis        Redis        `yaml:"redis"`
+	Rates        Rates        `yaml:"rates"`
+	Balance      Balance      `yaml:"balance"`
+	Admin        Admin        `yaml:"admin"`
+	BalanceCheck BalanceCheck `yaml:"balance_check"`
+	Mail         Mail         `yaml:"mail"`
 }
 
 func FromFile(cfgPath string) (*Config, error) {
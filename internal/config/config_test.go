@@ -44,6 +44,17 @@ func TestFromFile(t *testing.T) {
 					DB:       1111,
 					Password: "pass",
 				},
+				Rates: Rates{
+					Provider: RatesProviderDB,
+				},
+				Balance: Balance{
+					Storage: BalanceStorageRedis,
+				},
+				Mail: Mail{
+					Provider:  MailProviderFile,
+					From:      "ledger@localhost",
+					OutboxDir: "mail",
+				},
 			},
 		},
 		{
@@ -61,6 +72,17 @@ func TestFromFile(t *testing.T) {
 				Redis: Redis{
 					Address: "localhost:6379",
 				},
+				Rates: Rates{
+					Provider: RatesProviderDB,
+				},
+				Balance: Balance{
+					Storage: BalanceStorageRedis,
+				},
+				Mail: Mail{
+					Provider:  MailProviderFile,
+					From:      "ledger@localhost",
+					OutboxDir: "mail",
+				},
 			},
 		},
 		{
@@ -80,6 +102,17 @@ func TestFromFile(t *testing.T) {
 					Address:  "localhost:6379",
 					Password: "redispass",
 				},
+				Rates: Rates{
+					Provider: RatesProviderDB,
+				},
+				Balance: Balance{
+					Storage: BalanceStorageRedis,
+				},
+				Mail: Mail{
+					Provider:  MailProviderFile,
+					From:      "ledger@localhost",
+					OutboxDir: "mail",
+				},
 			},
 			envs: map[string]string{
 				envPrefixed("REDIS_DB"):       "1234",
@@ -127,6 +160,17 @@ func TestFromEnv(t *testing.T) {
 				Redis: Redis{
 					Address: "localhost:6379",
 				},
+				Rates: Rates{
+					Provider: RatesProviderDB,
+				},
+				Balance: Balance{
+					Storage: BalanceStorageRedis,
+				},
+				Mail: Mail{
+					Provider:  MailProviderFile,
+					From:      "ledger@localhost",
+					OutboxDir: "mail",
+				},
 			},
 		},
 		{
@@ -146,6 +190,17 @@ func TestFromEnv(t *testing.T) {
 					Address:  "localhost:16379",
 					Password: "fisheye",
 				},
+				Rates: Rates{
+					Provider: RatesProviderDB,
+				},
+				Balance: Balance{
+					Storage: BalanceStorageRedis,
+				},
+				Mail: Mail{
+					Provider:  MailProviderFile,
+					From:      "ledger@localhost",
+					OutboxDir: "mail",
+				},
 			},
 			envs: map[string]string{
 				envPrefixed("HTTP_ADDR"):      ":10541",
@@ -0,0 +1,53 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// FileMailer writes emails to outbox directory instead of sending them.
+//
+// Each message is written to a separate "<timestamp>-<recipient>.eml" file.
+// Can be used for offline use and in tests.
+type FileMailer struct {
+	dir  string
+	from string
+}
+
+// NewFileMailer is FileMailer constructor.
+//
+// Outbox directory is created if it doesn't exist.
+func NewFileMailer(dir, from string) (*FileMailer, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create mail outbox directory: %w", err)
+	}
+
+	return &FileMailer{dir: dir, from: from}, nil
+}
+
+// SendMail implements service.Mailer
+func (m FileMailer) SendMail(_ context.Context, msg Message) error {
+	now := time.Now()
+	fileName := fmt.Sprintf("%d-%s.eml", now.UnixNano(), outboxFileName(msg.To))
+	err := ioutil.WriteFile(filepath.Join(m.dir, fileName), msg.format(m.from, now), 0644)
+	if err != nil {
+		return fmt.Errorf("failed to write email to outbox: %w", err)
+	}
+
+	return nil
+}
+
+// outboxFileName replaces characters which are not allowed in file names
+func outboxFileName(addr string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '/' || r == '\\' || r == os.PathSeparator {
+			return '_'
+		}
+		return r
+	}, strings.ToLower(addr))
+}
@@ -0,0 +1,33 @@
+package mail
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFileMailer_SendMail(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "outbox")
+	m, err := NewFileMailer(dir, "ledger@localhost")
+	require.NoError(t, err)
+
+	msg := Message{To: "Alice@mail.com", Subject: "Hello", Body: "token: foo"}
+	require.NoError(t, m.SendMail(context.Background(), msg))
+
+	files, err := filepath.Glob(filepath.Join(dir, "*-alice@mail.com.eml"))
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+
+	data, err := ioutil.ReadFile(files[0])
+	require.NoError(t, err)
+
+	str := string(data)
+	require.Contains(t, str, "From: ledger@localhost\r\n")
+	require.Contains(t, str, "To: Alice@mail.com\r\n")
+	require.Contains(t, str, "Subject: Hello\r\n")
+	require.True(t, strings.HasSuffix(str, "\r\n\r\ntoken: foo"))
+}
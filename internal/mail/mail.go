@@ -0,0 +1,29 @@
+// Package mail provides outgoing email transports.
+package mail
+
+import (
+	"bytes"
+	"fmt"
+	"time"
+)
+
+// Message is outgoing plain text email message
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// format returns message in RFC 5322 format
+func (m Message) format(from string, date time.Time) []byte {
+	buff := new(bytes.Buffer)
+	fmt.Fprintf(buff, "From: %s\r\n", from)
+	fmt.Fprintf(buff, "To: %s\r\n", m.To)
+	fmt.Fprintf(buff, "Subject: %s\r\n", m.Subject)
+	fmt.Fprintf(buff, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	buff.WriteString("MIME-Version: 1.0\r\n")
+	buff.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buff.WriteString("\r\n")
+	buff.WriteString(m.Body)
+	return buff.Bytes()
+}
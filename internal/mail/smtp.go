@@ -0,0 +1,43 @@
+package mail
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/smtp"
+	"time"
+)
+
+// SMTPMailer sends emails using SMTP server
+type SMTPMailer struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTPMailer is SMTPMailer constructor.
+//
+// Plain auth is used if username is not empty.
+func NewSMTPMailer(addr, from, username, password string) (*SMTPMailer, error) {
+	host, _, err := net.SplitHostPort(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid SMTP server address %q: %w", addr, err)
+	}
+
+	m := &SMTPMailer{addr: addr, from: from}
+	if username != "" {
+		m.auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return m, nil
+}
+
+// SendMail implements service.Mailer
+func (m SMTPMailer) SendMail(_ context.Context, msg Message) error {
+	err := smtp.SendMail(m.addr, m.auth, m.from, []string{msg.To}, msg.format(m.from, time.Now()))
+	if err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
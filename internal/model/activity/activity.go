@@ -0,0 +1,298 @@
+package activity
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+const (
+	// DefaultLimit is default number of events in a feed page.
+	DefaultLimit = 50
+
+	// MaxLimit is max number of events in a feed page.
+	MaxLimit = 200
+)
+
+// EventID is activity event ID.
+//
+// Event IDs are increasing, so event ID is used as feed cursor.
+type EventID = int64
+
+// EventType is group activity event type.
+type EventType string
+
+const (
+	// EventExpenseCreated is a new expense shared in a group.
+	EventExpenseCreated EventType = "expense_created"
+
+	// EventExpenseVoided is refund of previously shared expense.
+	EventExpenseVoided EventType = "expense_voided"
+
+	// EventMemberAdded is new members added to a group.
+	EventMemberAdded EventType = "member_added"
+
+	// EventMemberRemoved is member removed from a group by another member.
+	EventMemberRemoved EventType = "member_removed"
+
+	// EventMemberLeft is member which left a group.
+	EventMemberLeft EventType = "member_left"
+
+	// EventSettlement is debt repayment between group members.
+	EventSettlement EventType = "settlement"
+
+	// EventBalanceTransferred is balance transfer from one group member to another.
+	EventBalanceTransferred EventType = "balance_transferred"
+
+	// EventOwnershipTransferred is group ownership transfer to another group member.
+	EventOwnershipTransferred EventType = "ownership_transferred"
+)
+
+var eventTypes = map[EventType]struct{}{
+	EventExpenseCreated:       {},
+	EventExpenseVoided:        {},
+	EventMemberAdded:          {},
+	EventMemberRemoved:        {},
+	EventMemberLeft:           {},
+	EventSettlement:           {},
+	EventBalanceTransferred:   {},
+	EventOwnershipTransferred: {},
+}
+
+// IsValid checks if event type is known
+func (t EventType) IsValid() bool {
+	_, ok := eventTypes[t]
+	return ok
+}
+
+// TransactionEvents maps group journal transaction kinds to event types.
+//
+// Expense transactions are not listed as expense events are recorded with expense details.
+var TransactionEvents = map[loan.TransactionKind]EventType{
+	loan.KindSettlement: EventSettlement,
+	loan.KindRefund:     EventExpenseVoided,
+	loan.KindTransfer:   EventBalanceTransferred,
+}
+
+// Payload is event payload in JSON format.
+type Payload []byte
+
+// NewPayload returns payload from value encoded as JSON.
+func NewPayload(v interface{}) (Payload, error) {
+	return json.Marshal(v)
+}
+
+// MarshalJSON implements json.Marshaler
+func (p Payload) MarshalJSON() ([]byte, error) {
+	if len(p) == 0 {
+		return []byte("{}"), nil
+	}
+
+	return p, nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (p *Payload) UnmarshalJSON(data []byte) error {
+	*p = append((*p)[0:0], data...)
+	return nil
+}
+
+// Value implements driver.Valuer
+func (p Payload) Value() (driver.Value, error) {
+	return p.MarshalJSON()
+}
+
+// Scan implements sql.Scanner
+func (p *Payload) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return p.UnmarshalJSON(v)
+	case string:
+		return p.UnmarshalJSON([]byte(v))
+	default:
+		return fmt.Errorf("cannot scan %T into activity.Payload", src)
+	}
+}
+
+// Event is group activity feed entry.
+type Event struct {
+	// ID is event ID.
+	ID EventID `json:"id" db:"id"`
+
+	// GroupID is ID of group where event happened.
+	GroupID user.GroupID `json:"group_id" db:"group_id"`
+
+	// ActorID is ID of user who caused the event.
+	//
+	// Empty if event was caused by system.
+	ActorID *user.ID `json:"actor_id,omitempty" db:"actor_id"`
+
+	// Type is event type.
+	Type EventType `json:"type" db:"type"`
+
+	// Payload contains event details, payload structure depends on event type.
+	Payload Payload `json:"payload" db:"payload"`
+
+	// CreatedAt is event date.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// NewEvent constructs a new event with payload encoded as JSON.
+func NewEvent(gid user.GroupID, actor *user.ID, t EventType, payload interface{}) (*Event, error) {
+	p, err := NewPayload(payload)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode %s event payload: %w", t, err)
+	}
+
+	return &Event{GroupID: gid, ActorID: actor, Type: t, Payload: p}, nil
+}
+
+// ExpensePayload is payload of expense events.
+type ExpensePayload struct {
+	// ExpenseID is expense ID.
+	ExpenseID loan.ExpenseID `json:"expense_id"`
+
+	// EventID is ID of group event where expense was registered.
+	EventID *user.EventID `json:"event_id,omitempty"`
+
+	// PayerID is ID of user who paid the bill.
+	PayerID user.ID `json:"payer_id"`
+
+	// Amount is expense amount in group currency.
+	Amount loan.Amount `json:"amount"`
+
+	// Currency is group currency.
+	Currency model.Currency `json:"currency"`
+
+	// OriginalAmount is expense amount as it was provided by payer.
+	OriginalAmount loan.Amount `json:"original_amount"`
+
+	// OriginalCurrency is currency of original amount.
+	OriginalCurrency model.Currency `json:"original_currency"`
+
+	// SpentAt is expense date.
+	SpentAt time.Time `json:"spent_at"`
+
+	// Share is amount of debt per expense share.
+	Share loan.Amount `json:"share"`
+
+	// Debtors is list of members who share expense.
+	//
+	// Household is listed as household holder.
+	Debtors []user.ID `json:"debtors"`
+
+	// Shares is debt of each debtor. Household debt is weighted by household weight.
+	Shares []loan.ExpenseShare `json:"shares"`
+}
+
+// MembersPayload is payload of member_added event.
+type MembersPayload struct {
+	// Members is list of added members.
+	Members []user.ID `json:"members"`
+
+	// Role is role of added members.
+	Role user.Role `json:"role"`
+
+	// PlaceholderID is ID of placeholder member replaced by added member (optional).
+	PlaceholderID *user.ID `json:"placeholder_id,omitempty"`
+}
+
+// OwnershipPayload is payload of ownership_transferred event.
+type OwnershipPayload struct {
+	// FormerOwnerID is ID of former group owner.
+	FormerOwnerID user.ID `json:"former_owner_id"`
+
+	// OwnerID is ID of new group owner.
+	OwnerID user.ID `json:"owner_id"`
+}
+
+// MemberRemovalPayload is payload of member_removed and member_left events.
+type MemberRemovalPayload struct {
+	// MemberID is ID of removed member.
+	MemberID user.ID `json:"member_id"`
+
+	// TransferTo is ID of member which received balance of removed member.
+	TransferTo *user.ID `json:"transfer_to,omitempty"`
+
+	// Forced is set if member removal was forced regardless of outstanding balance.
+	Forced bool `json:"forced,omitempty"`
+}
+
+// TransactionPayload is payload of events caused by journal transactions.
+type TransactionPayload struct {
+	// TransactionID is journal transaction ID.
+	TransactionID loan.TransactionID `json:"transaction_id"`
+
+	// Kind is journal transaction kind.
+	Kind loan.TransactionKind `json:"kind"`
+
+	// ExpenseID is ID of expense related to transaction.
+	ExpenseID *loan.ExpenseID `json:"expense_id,omitempty"`
+
+	// Postings is list of transaction postings.
+	Postings []loan.Posting `json:"postings"`
+}
+
+// Filter is activity feed query filter.
+type Filter struct {
+	// Types is list of event types to return. All events are returned if empty.
+	Types []EventType
+
+	// Cursor is ID of the last received event.
+	//
+	// Only events older than cursor are returned. Newest events are returned if cursor is zero.
+	Cursor EventID
+
+	// Limit is max number of events to return.
+	Limit int
+}
+
+// Page is activity feed page.
+type Page struct {
+	// Events is list of events, newest first.
+	Events []Event `json:"events"`
+
+	// NextCursor is cursor to fetch next page. Empty if there are no more events.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// NewPage returns feed page from list of events.
+//
+// Events list is expected to contain one event more than filter limit
+// if there is a next page.
+func NewPage(events []Event, limit int) Page {
+	if events == nil {
+		events = []Event{}
+	}
+
+	if len(events) <= limit {
+		return Page{Events: events}
+	}
+
+	events = events[:limit]
+	return Page{
+		Events:     events,
+		NextCursor: FormatCursor(events[len(events)-1].ID),
+	}
+}
+
+// FormatCursor returns page cursor from event ID.
+func FormatCursor(id EventID) string {
+	return strconv.FormatInt(id, 10)
+}
+
+// ParseCursor parses page cursor.
+func ParseCursor(s string) (EventID, error) {
+	id, err := strconv.ParseInt(s, 10, 64)
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid cursor %q", s)
+	}
+
+	return id, nil
+}
@@ -0,0 +1,76 @@
+package activity
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/jackc/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewPage(t *testing.T) {
+	events := []Event{{ID: 5}, {ID: 4}, {ID: 3}}
+	cases := map[string]struct {
+		events     []Event
+		limit      int
+		wantLen    int
+		wantCursor string
+	}{
+		"empty": {
+			limit: 2,
+		},
+		"last page": {
+			events:  events[:2],
+			limit:   2,
+			wantLen: 2,
+		},
+		"has next page": {
+			events:     events,
+			limit:      2,
+			wantLen:    2,
+			wantCursor: "4",
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			page := NewPage(c.events, c.limit)
+			require.NotNil(t, page.Events)
+			require.Len(t, page.Events, c.wantLen)
+			require.Equal(t, c.wantCursor, page.NextCursor)
+		})
+	}
+}
+
+func TestParseCursor(t *testing.T) {
+	id, err := ParseCursor(FormatCursor(42))
+	require.NoError(t, err)
+	require.Equal(t, EventID(42), id)
+
+	for _, v := range []string{"", "foo", "0", "-1"} {
+		_, err = ParseCursor(v)
+		require.Errorf(t, err, "cursor %q should be invalid", v)
+	}
+}
+
+func TestPayload(t *testing.T) {
+	gid := pgtype.UUID{Status: pgtype.Present}
+	e, err := NewEvent(gid, nil, EventMemberAdded, MembersPayload{Role: "member"})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(e)
+	require.NoError(t, err)
+
+	var decoded Event
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	require.JSONEq(t, `{"members":null,"role":"member"}`, string(decoded.Payload))
+
+	data, err = json.Marshal(Event{GroupID: gid})
+	require.NoError(t, err)
+	require.Contains(t, string(data), `"payload":{}`)
+
+	var scanned Payload
+	require.NoError(t, scanned.Scan(`{"a":1}`))
+	require.Equal(t, `{"a":1}`, string(scanned))
+	require.Error(t, scanned.Scan(1))
+}
@@ -0,0 +1,83 @@
+package balance
+
+import (
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+// Discrepancy is a mismatch between cached and actual balance of user with a counterparty.
+type Discrepancy struct {
+	// UserID is ID of user which balance is inconsistent.
+	UserID user.ID `json:"user_id"`
+
+	// CounterpartyID is ID of user related to balance record.
+	CounterpartyID user.ID `json:"counterparty_id"`
+
+	// Currency is balance currency.
+	Currency model.Currency `json:"currency"`
+
+	// Cached is balance value in cache.
+	Cached loan.Amount `json:"cached"`
+
+	// Actual is balance value calculated from journal.
+	Actual loan.Amount `json:"actual"`
+}
+
+// CheckReport is balance cache consistency check result.
+type CheckReport struct {
+	// Checked is number of checked users.
+	Checked int `json:"checked"`
+
+	// NotCached is number of users without cached balance.
+	NotCached int `json:"not_cached"`
+
+	// Inconsistent is number of users with inconsistent balance cache.
+	Inconsistent int `json:"inconsistent"`
+
+	// Rebuilt is number of users which balance cache was rebuilt.
+	Rebuilt int `json:"rebuilt"`
+
+	// Discrepancies is list of found discrepancies.
+	Discrepancies []Discrepancy `json:"discrepancies"`
+}
+
+type balanceKey struct {
+	userID   user.ID
+	currency model.Currency
+}
+
+// Compare compares cached user balance with actual balance and returns list of discrepancies.
+//
+// Missing balance record is equal to zero balance.
+func Compare(uid user.ID, cached, actual []loan.Balance) []Discrepancy {
+	values := make(map[balanceKey]*Discrepancy, len(actual))
+	keys := make([]balanceKey, 0, len(actual))
+	get := func(b loan.Balance) *Discrepancy {
+		key := balanceKey{userID: b.UserID, currency: b.Currency}
+		d, ok := values[key]
+		if !ok {
+			d = &Discrepancy{UserID: uid, CounterpartyID: b.UserID, Currency: b.Currency}
+			values[key] = d
+			keys = append(keys, key)
+		}
+		return d
+	}
+
+	for _, b := range actual {
+		get(b).Actual += b.Balance
+	}
+
+	for _, b := range cached {
+		get(b).Cached += b.Balance
+	}
+
+	var out []Discrepancy
+	for _, key := range keys {
+		if d := values[key]; d.Cached != d.Actual {
+			out = append(out, *d)
+		}
+	}
+
+	return out
+}
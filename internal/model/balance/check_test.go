@@ -0,0 +1,40 @@
+package balance
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+)
+
+func TestCompare(t *testing.T) {
+	ids, err := model.DecodeUUIDs(
+		"4d3c1b0a-0000-4000-8000-000000000001",
+		"4d3c1b0a-0000-4000-8000-000000000002",
+		"4d3c1b0a-0000-4000-8000-000000000003",
+	)
+	require.NoError(t, err)
+	alice, bob, charlie := ids[0], ids[1], ids[2]
+
+	actual := []loan.Balance{
+		{UserID: bob, Currency: "EUR", Balance: 100},
+		{UserID: bob, Currency: "USD", Balance: 0},
+		{UserID: charlie, Currency: "EUR", Balance: -50},
+	}
+
+	require.Empty(t, Compare(alice, actual, actual))
+
+	// zero balance equals to missing record
+	require.Empty(t, Compare(alice, actual[:1], actual[:2]))
+
+	cached := []loan.Balance{
+		{UserID: bob, Currency: "EUR", Balance: 100},
+		{UserID: charlie, Currency: "EUR", Balance: -40},
+		{UserID: charlie, Currency: "CHF", Balance: 10},
+	}
+	require.Equal(t, []Discrepancy{
+		{UserID: alice, CounterpartyID: charlie, Currency: "EUR", Cached: -40, Actual: -50},
+		{UserID: alice, CounterpartyID: charlie, Currency: "CHF", Cached: 10, Actual: 0},
+	}, Compare(alice, cached, actual))
+}
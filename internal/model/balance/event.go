@@ -0,0 +1,82 @@
+package balance
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+// Deltas is list of user balance changes.
+type Deltas []loan.Balance
+
+// Value implements driver.Valuer
+func (d Deltas) Value() (driver.Value, error) {
+	return json.Marshal(d)
+}
+
+// Scan implements sql.Scanner
+func (d *Deltas) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case []byte:
+		return json.Unmarshal(v, d)
+	case string:
+		return json.Unmarshal([]byte(v), d)
+	default:
+		return fmt.Errorf("cannot scan %T into balance.Deltas", src)
+	}
+}
+
+// Event is balance cache update event.
+//
+// Event contains balance changes of a single user caused by journal transaction.
+type Event struct {
+	// TransactionID is ID of journal transaction which caused balance change.
+	TransactionID loan.TransactionID `db:"transaction_id"`
+
+	// Seq is position of transaction in journal.
+	Seq int64 `db:"seq"`
+
+	// UserID is ID of user which balance is changed.
+	UserID user.ID `db:"user_id"`
+
+	// Deltas is list of balance changes.
+	Deltas Deltas `db:"deltas"`
+
+	// Attempts is number of failed attempts to apply event.
+	Attempts int `db:"attempts"`
+
+	// CreatedAt is event creation date.
+	CreatedAt time.Time `db:"created_at"`
+}
+
+// Key returns event idempotency key.
+//
+// Key is unique only in scope of a user.
+func (e Event) Key() string {
+	return user.IDToString(e.TransactionID)
+}
+
+// EventsFromTransaction returns balance cache update events for each user affected by transaction.
+//
+// Transaction should be already saved to journal.
+func EventsFromTransaction(t loan.Transaction) []Event {
+	deltas := t.BalanceDeltas()
+	events := make([]Event, 0, len(deltas))
+
+	// keep events order stable, postings order is used for this
+	for _, p := range t.Postings {
+		d, ok := deltas[p.UserID]
+		if !ok {
+			continue
+		}
+
+		events = append(events, Event{TransactionID: t.ID, Seq: t.Seq, UserID: p.UserID, Deltas: d})
+		delete(deltas, p.UserID)
+	}
+
+	return events
+}
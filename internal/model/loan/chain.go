@@ -0,0 +1,128 @@
+package loan
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"database/sql/driver"
+	"encoding/hex"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+// chainTimeFormat is transaction date format used in hash payload.
+//
+// Postgres keeps time with microsecond precision.
+const chainTimeFormat = "2006-01-02T15:04:05.000000Z"
+
+// GenesisHash is previous hash value of a first transaction in a chain.
+var GenesisHash = make(Hash, sha256.Size)
+
+// Hash is SHA-256 hash of journal transaction.
+type Hash []byte
+
+// Equal checks if hashes are equal
+func (h Hash) Equal(v Hash) bool {
+	return bytes.Equal(h, v)
+}
+
+// String implements fmt.Stringer
+func (h Hash) String() string {
+	return hex.EncodeToString(h)
+}
+
+// ParseHash decodes hex-encoded hash.
+func ParseHash(s string) (Hash, error) {
+	h, err := hex.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(h) != sha256.Size {
+		return nil, fmt.Errorf("invalid hash length %d, expected %d bytes", len(h), sha256.Size)
+	}
+
+	return h, nil
+}
+
+// MarshalText implements encoding.TextMarshaler
+func (h Hash) MarshalText() ([]byte, error) {
+	return []byte(h.String()), nil
+}
+
+// Value implements driver.Valuer
+func (h Hash) Value() (driver.Value, error) {
+	return []byte(h), nil
+}
+
+// Scan implements sql.Scanner
+func (h *Hash) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+	case []byte:
+		// driver may reuse buffer, so value should be copied
+		*h = append(Hash(nil), v...)
+	default:
+		return fmt.Errorf("cannot scan %T into loan.Hash", src)
+	}
+
+	return nil
+}
+
+// ComputeHash calculates transaction hash from transaction contents,
+// postings and hash of previous transaction in a chain.
+//
+// Hash payload format should be in sync with "000025_journal_chain_links" migration.
+//
+// Expense link is not hashed, as it's cleared when expense is removed.
+// Group and event links are kept after group removal, so they are part of the hash.
+func (t Transaction) ComputeHash() Hash {
+	h := sha256.New()
+	_, _ = fmt.Fprintf(h, "%d\n%s\n%s\n%s\n%s\n%s\n%s\n", t.Seq, t.PrevHash, user.IDToString(t.ID),
+		t.Kind, t.CreatedAt.UTC().Format(chainTimeFormat), optionalIDToString(t.GroupID), optionalIDToString(t.EventID))
+
+	for _, p := range t.Postings {
+		_, _ = fmt.Fprintf(h, "%s:%s:%s:%d\n", user.IDToString(p.UserID),
+			user.IDToString(p.CounterpartyID), p.Currency, p.Amount)
+	}
+
+	return h.Sum(nil)
+}
+
+// optionalIDToString returns string representation of ID or empty string if ID is nil.
+func optionalIDToString(id *pgtype.UUID) string {
+	if id == nil {
+		return ""
+	}
+
+	return user.IDToString(*id)
+}
+
+// BrokenLink describes first broken link in transactions chain.
+type BrokenLink struct {
+	// Seq is position of broken transaction in a chain.
+	Seq int64 `json:"seq"`
+
+	// TransactionID is ID of broken transaction.
+	TransactionID TransactionID `json:"transaction_id"`
+
+	// Reason is human-readable reason of failure.
+	Reason string `json:"reason"`
+}
+
+// ChainReport is journal chain verification result.
+type ChainReport struct {
+	// Valid is chain verification status.
+	Valid bool `json:"valid"`
+
+	// Checked is number of verified transactions.
+	Checked int64 `json:"checked"`
+
+	// Head is hash of last valid transaction in a chain.
+	Head Hash `json:"head,omitempty"`
+
+	// BrokenLink is first broken link in a chain (if any).
+	BrokenLink *BrokenLink `json:"broken_link,omitempty"`
+}
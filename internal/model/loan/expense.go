@@ -0,0 +1,59 @@
+package loan
+
+import (
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+type ExpenseID = pgtype.UUID
+
+// Expense is an expense paid by group member which is shared across the group.
+type Expense struct {
+	// ID is expense ID
+	ID ExpenseID `json:"id" db:"id"`
+
+	// GroupID is ID of group where expense was registered.
+	GroupID user.GroupID `json:"group_id" db:"group_id"`
+
+	// EventID is ID of group event where expense was registered (optional).
+	EventID *user.EventID `json:"event_id,omitempty" db:"event_id"`
+
+	// PayerID is ID of user who paid the bill.
+	PayerID user.ID `json:"payer_id" db:"payer_id"`
+
+	// Amount is expense amount in cents converted to group base currency.
+	Amount Amount `json:"amount" db:"amount"`
+
+	// Currency is group base currency.
+	Currency model.Currency `json:"currency" db:"currency"`
+
+	// OriginalAmount is expense amount in cents as it was provided by payer.
+	OriginalAmount Amount `json:"original_amount" db:"original_amount"`
+
+	// OriginalCurrency is currency of original amount.
+	OriginalCurrency model.Currency `json:"original_currency" db:"original_currency"`
+
+	// Rate is exchange rate from original currency to group currency
+	// captured at posting time.
+	Rate float64 `json:"rate" db:"rate"`
+
+	// SpentAt is date when expense was made.
+	//
+	// Expense is shared between members who were active at this date.
+	SpentAt time.Time `json:"spent_at" db:"spent_at"`
+
+	// CreatedAt is expense creation date.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// ExpenseShare is part of expense charged to a debtor.
+type ExpenseShare struct {
+	// DebtorID is ID of user who owes the share.
+	DebtorID user.ID `json:"debtor_id"`
+
+	// Amount is share amount in expense currency.
+	Amount Amount `json:"amount"`
+}
@@ -0,0 +1,228 @@
+package loan
+
+import (
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+var ErrUnbalancedTransaction = errors.New("journal transaction is not balanced")
+
+type TransactionID = pgtype.UUID
+
+// TransactionKind describes operation which caused journal transaction.
+type TransactionKind string
+
+const (
+	// KindExpense is expense shared between group members.
+	KindExpense TransactionKind = "expense"
+
+	// KindSettlement is debt repayment between users.
+	KindSettlement TransactionKind = "settlement"
+
+	// KindRefund is refund of previously registered expense.
+	KindRefund TransactionKind = "refund"
+
+	// KindLoan is plain loan from one user to another.
+	KindLoan TransactionKind = "loan"
+
+	// KindTransfer is transfer of user debts inside a group to another group member.
+	KindTransfer TransactionKind = "transfer"
+)
+
+// Posting is a single journal entry which changes balance of user account against counterparty.
+//
+// Positive amount means that counterparty owes money to user,
+// negative amount means that user owes money to counterparty.
+type Posting struct {
+	// UserID is account owner.
+	UserID user.ID `json:"user_id" db:"user_id"`
+
+	// CounterpartyID is ID of user which balance is tracked by account.
+	CounterpartyID user.ID `json:"counterparty_id" db:"counterparty_id"`
+
+	// Currency is posting currency.
+	Currency model.Currency `json:"currency" db:"currency"`
+
+	// Amount is posting amount in minor units.
+	Amount Amount `json:"amount" db:"amount"`
+}
+
+// Transaction is double-entry journal transaction.
+//
+// Postings of transaction in each currency must sum to zero.
+type Transaction struct {
+	// ID is transaction ID.
+	ID TransactionID `json:"id" db:"id"`
+
+	// Kind is transaction kind.
+	Kind TransactionKind `json:"kind" db:"kind"`
+
+	// ExpenseID is ID of expense which caused the transaction (optional).
+	ExpenseID *ExpenseID `json:"expense_id,omitempty" db:"expense_id"`
+
+	// GroupID is ID of group where transaction was registered (optional).
+	//
+	// Only postings of group transactions are included into user balance inside a group.
+	GroupID *user.GroupID `json:"group_id,omitempty" db:"group_id"`
+
+	// EventID is ID of group event where transaction was registered (optional).
+	//
+	// Only postings of event transactions are included into user balance inside an event.
+	EventID *user.EventID `json:"event_id,omitempty" db:"event_id"`
+
+	// CreatedAt is transaction creation date and time.
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// Seq is transaction position in a chain.
+	Seq int64 `json:"seq" db:"seq"`
+
+	// PrevHash is hash of previous transaction in a chain.
+	PrevHash Hash `json:"prev_hash" db:"prev_hash"`
+
+	// Hash is transaction hash, see Transaction.ComputeHash.
+	Hash Hash `json:"hash" db:"hash"`
+
+	// Postings is list of transaction postings.
+	Postings []Posting `json:"postings" db:"-"`
+}
+
+// AddLoan appends a pair of postings which record a loan from lender to debtor.
+func (t *Transaction) AddLoan(lender, debtor user.ID, amount Amount, cur model.Currency) {
+	t.Postings = append(t.Postings,
+		Posting{UserID: lender, CounterpartyID: debtor, Currency: cur, Amount: amount},
+		Posting{UserID: debtor, CounterpartyID: lender, Currency: cur, Amount: -amount},
+	)
+}
+
+// Validate checks if transaction postings in each currency sum to zero.
+func (t Transaction) Validate() error {
+	if len(t.Postings) == 0 {
+		return errors.New("journal transaction has no postings")
+	}
+
+	sums := make(map[model.Currency]Amount, 1)
+	for _, p := range t.Postings {
+		if p.Amount == 0 {
+			return errors.New("journal posting amount is zero")
+		}
+
+		sums[p.Currency] += p.Amount
+	}
+
+	for _, sum := range sums {
+		if sum != 0 {
+			return ErrUnbalancedTransaction
+		}
+	}
+
+	return nil
+}
+
+// BalanceDeltas returns balance changes for each user caused by transaction.
+func (t Transaction) BalanceDeltas() map[user.ID][]Balance {
+	out := make(map[user.ID][]Balance, len(t.Postings))
+	for _, p := range t.Postings {
+		out[p.UserID] = append(out[p.UserID], Balance{
+			UserID:   p.CounterpartyID,
+			Currency: p.Currency,
+			Balance:  p.Amount,
+		})
+	}
+
+	return out
+}
+
+// DebtTransfer returns transactions which transfer user balance inside a group to another group member.
+//
+// Balance of user inside a group is moved to receiver, so counterparties owe to (or are owed by) receiver instead.
+// Moved amount is recorded as balance between user and receiver outside a group,
+// so total balance of each user is not changed.
+//
+// Returned list contains a group transaction and optional transaction outside a group.
+func DebtTransfer(gid user.GroupID, from, to user.ID, balance []Balance) []Transaction {
+	groupTx := Transaction{Kind: KindTransfer, GroupID: &gid}
+	totals := make(map[model.Currency]Amount, 1)
+	for _, b := range balance {
+		if b.Balance == 0 {
+			continue
+		}
+
+		groupTx.moveBalance(from, to, b)
+		totals[b.Currency] += b.Balance
+	}
+
+	if len(groupTx.Postings) == 0 {
+		return nil
+	}
+
+	out := []Transaction{groupTx}
+	settleTx := Transaction{Kind: KindTransfer}
+	for _, cur := range sortedCurrencies(totals) {
+		if amount := totals[cur]; amount != 0 {
+			settleTx.AddLoan(from, to, amount, cur)
+		}
+	}
+
+	if len(settleTx.Postings) > 0 {
+		out = append(out, settleTx)
+	}
+
+	return out
+}
+
+// BalanceClaim returns transactions which move whole user balance to another user.
+//
+// Used when placeholder member is claimed by registered user, so receiver takes place of user
+// in all balances. A separate transaction is created for each group to keep group balances.
+// Balance between user and receiver is closed.
+func BalanceClaim(from, to user.ID, balance []GroupBalance) []Transaction {
+	var out []Transaction
+	txIndex := make(map[string]int, 1)
+	for _, b := range balance {
+		if b.Balance.Balance == 0 {
+			continue
+		}
+
+		key := ""
+		if b.GroupID != nil {
+			key = user.IDToString(*b.GroupID)
+		}
+
+		i, ok := txIndex[key]
+		if !ok {
+			i = len(out)
+			txIndex[key] = i
+			out = append(out, Transaction{Kind: KindTransfer, GroupID: b.GroupID})
+		}
+
+		out[i].moveBalance(from, to, b.Balance)
+	}
+
+	return out
+}
+
+// moveBalance appends postings which close user balance with counterparty
+// and open the same balance between counterparty and receiver.
+func (t *Transaction) moveBalance(from, to user.ID, b Balance) {
+	t.AddLoan(b.UserID, from, b.Balance, b.Currency)
+	if b.UserID != to {
+		t.AddLoan(to, b.UserID, b.Balance, b.Currency)
+	}
+}
+
+func sortedCurrencies(m map[model.Currency]Amount) []model.Currency {
+	out := make([]model.Currency, 0, len(m))
+	for cur := range m {
+		out = append(out, cur)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i] < out[j]
+	})
+	return out
+}
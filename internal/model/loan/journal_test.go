@@ -0,0 +1,189 @@
+package loan
+
+import (
+	"crypto/sha256"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+func TestTransaction_Validate(t *testing.T) {
+	ids, err := model.DecodeUUIDs(
+		"4d3c1b0a-0000-4000-8000-000000000001",
+		"4d3c1b0a-0000-4000-8000-000000000002",
+	)
+	require.NoError(t, err)
+	alice, bob := ids[0], ids[1]
+
+	var tx Transaction
+	require.Error(t, tx.Validate(), "empty transaction should be rejected")
+
+	tx.AddLoan(alice, bob, 100, "EUR")
+	tx.AddLoan(bob, alice, 50, "USD")
+	require.NoError(t, tx.Validate())
+
+	deltas := tx.BalanceDeltas()
+	require.Equal(t, []Balance{
+		{UserID: bob, Currency: "EUR", Balance: 100},
+		{UserID: bob, Currency: "USD", Balance: -50},
+	}, deltas[alice])
+
+	tx.Postings = append(tx.Postings, Posting{UserID: alice, CounterpartyID: bob, Currency: "USD", Amount: 1})
+	require.Equal(t, ErrUnbalancedTransaction, tx.Validate())
+}
+
+func TestTransaction_ComputeHash(t *testing.T) {
+	ids, err := model.DecodeUUIDs(
+		"4d3c1b0a-0000-4000-8000-000000000001",
+		"4d3c1b0a-0000-4000-8000-000000000002",
+		"4d3c1b0a-0000-4000-8000-000000000003",
+		"4d3c1b0a-0000-4000-8000-000000000004",
+	)
+	require.NoError(t, err)
+
+	tx := Transaction{
+		ID:        ids[2],
+		Kind:      KindExpense,
+		GroupID:   &ids[3],
+		CreatedAt: time.Date(2021, 1, 2, 3, 4, 5, 6000, time.UTC),
+		Seq:       1,
+		PrevHash:  GenesisHash,
+	}
+	tx.AddLoan(ids[0], ids[1], 100, "EUR")
+
+	// payload should match one generated by "000025_journal_chain_links" migration
+	const payload = "1\n" +
+		"0000000000000000000000000000000000000000000000000000000000000000\n" +
+		"4d3c1b0a-0000-4000-8000-000000000003\n" +
+		"expense\n" +
+		"2021-01-02T03:04:05.000006Z\n" +
+		"4d3c1b0a-0000-4000-8000-000000000004\n" +
+		"\n" +
+		"4d3c1b0a-0000-4000-8000-000000000001:4d3c1b0a-0000-4000-8000-000000000002:EUR:100\n" +
+		"4d3c1b0a-0000-4000-8000-000000000002:4d3c1b0a-0000-4000-8000-000000000001:EUR:-100\n"
+	expect := sha256.Sum256([]byte(payload))
+	require.Equal(t, Hash(expect[:]), tx.ComputeHash())
+
+	tx.GroupID = nil
+	require.NotEqual(t, Hash(expect[:]), tx.ComputeHash(), "hash should change when group link is cleared")
+
+	tx.GroupID = &ids[3]
+	tx.Postings[0].Amount = 200
+	require.NotEqual(t, Hash(expect[:]), tx.ComputeHash(), "hash should change when posting is changed")
+}
+
+func TestDebtTransfer(t *testing.T) {
+	ids, err := model.DecodeUUIDs(
+		"4d3c1b0a-0000-4000-8000-000000000001",
+		"4d3c1b0a-0000-4000-8000-000000000002",
+		"4d3c1b0a-0000-4000-8000-000000000003",
+		"4d3c1b0a-0000-4000-8000-000000000004",
+	)
+	require.NoError(t, err)
+	gid, alice, bob, carol := ids[0], ids[1], ids[2], ids[3]
+
+	require.Empty(t, DebtTransfer(gid, alice, bob, nil))
+
+	// carol owes 300 EUR to alice, alice owes 100 EUR and 50 USD to bob
+	txs := DebtTransfer(gid, alice, bob, []Balance{
+		{UserID: carol, Currency: "EUR", Balance: 300},
+		{UserID: bob, Currency: "EUR", Balance: -100},
+		{UserID: bob, Currency: "USD", Balance: -50},
+	})
+	require.Len(t, txs, 2)
+
+	group, settle := txs[0], txs[1]
+	require.Equal(t, KindTransfer, group.Kind)
+	require.Equal(t, &gid, group.GroupID)
+	require.NoError(t, group.Validate())
+	require.Nil(t, settle.GroupID)
+	require.NoError(t, settle.Validate())
+
+	sum := func(txs []Transaction, uid user.ID) map[string]Amount {
+		out := make(map[string]Amount)
+		for _, t := range txs {
+			for _, p := range t.Postings {
+				if p.UserID == uid {
+					out[user.IDToString(p.CounterpartyID)+":"+string(p.Currency)] += p.Amount
+				}
+			}
+		}
+		return out
+	}
+
+	// alice balance inside a group is closed
+	require.Equal(t, map[string]Amount{
+		user.IDToString(carol) + ":EUR": -300,
+		user.IDToString(bob) + ":EUR":   100,
+		user.IDToString(bob) + ":USD":   50,
+	}, sum([]Transaction{group}, alice))
+
+	// carol owes to bob instead of alice,
+	// bob owes 200 EUR to alice and alice owes 50 USD to bob outside a group
+	require.Equal(t, map[string]Amount{
+		user.IDToString(alice) + ":EUR": 300,
+		user.IDToString(bob) + ":EUR":   -300,
+	}, sum(txs, carol))
+	require.Equal(t, map[string]Amount{
+		user.IDToString(bob) + ":EUR": 200,
+		user.IDToString(bob) + ":USD": -50,
+	}, sum([]Transaction{settle}, alice))
+}
+
+func TestBalanceClaim(t *testing.T) {
+	ids, err := model.DecodeUUIDs(
+		"4d3c1b0a-0000-4000-8000-000000000001",
+		"4d3c1b0a-0000-4000-8000-000000000002",
+		"4d3c1b0a-0000-4000-8000-000000000003",
+		"4d3c1b0a-0000-4000-8000-000000000004",
+		"4d3c1b0a-0000-4000-8000-000000000005",
+	)
+	require.NoError(t, err)
+	gid1, gid2, ghost, alice, bob := ids[0], ids[1], ids[2], ids[3], ids[4]
+
+	require.Empty(t, BalanceClaim(ghost, alice, nil))
+
+	// in group 1 bob owes 300 EUR to ghost and ghost owes 100 EUR to alice,
+	// in group 2 ghost owes 50 USD to bob.
+	txs := BalanceClaim(ghost, alice, []GroupBalance{
+		{GroupID: &gid1, Balance: Balance{UserID: alice, Currency: "EUR", Balance: -100}},
+		{GroupID: &gid1, Balance: Balance{UserID: bob, Currency: "EUR", Balance: 300}},
+		{GroupID: &gid2, Balance: Balance{UserID: bob, Currency: "USD", Balance: -50}},
+		{GroupID: &gid2, Balance: Balance{UserID: alice, Currency: "USD", Balance: 0}},
+	})
+	require.Len(t, txs, 2)
+	require.Equal(t, &gid1, txs[0].GroupID)
+	require.Equal(t, &gid2, txs[1].GroupID)
+
+	sum := func(t Transaction) map[string]Amount {
+		out := make(map[string]Amount)
+		for _, p := range t.Postings {
+			out[user.IDToString(p.UserID)+">"+user.IDToString(p.CounterpartyID)+":"+string(p.Currency)] += p.Amount
+		}
+		return out
+	}
+
+	for _, tx := range txs {
+		require.Equal(t, KindTransfer, tx.Kind)
+		require.NoError(t, tx.Validate())
+	}
+
+	// ghost balance is closed, bob owes to alice instead of ghost
+	require.Equal(t, map[string]Amount{
+		user.IDToString(alice) + ">" + user.IDToString(ghost) + ":EUR": -100,
+		user.IDToString(ghost) + ">" + user.IDToString(alice) + ":EUR": 100,
+		user.IDToString(bob) + ">" + user.IDToString(ghost) + ":EUR":   300,
+		user.IDToString(ghost) + ">" + user.IDToString(bob) + ":EUR":   -300,
+		user.IDToString(alice) + ">" + user.IDToString(bob) + ":EUR":   300,
+		user.IDToString(bob) + ">" + user.IDToString(alice) + ":EUR":   -300,
+	}, sum(txs[0]))
+	require.Equal(t, map[string]Amount{
+		user.IDToString(bob) + ">" + user.IDToString(ghost) + ":USD": -50,
+		user.IDToString(ghost) + ">" + user.IDToString(bob) + ":USD": 50,
+		user.IDToString(alice) + ">" + user.IDToString(bob) + ":USD": -50,
+		user.IDToString(bob) + ">" + user.IDToString(alice) + ":USD": 50,
+	}, sum(txs[1]))
+}
@@ -0,0 +1,30 @@
+package loan
+
+import (
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+// Total is total amount of expenses in a currency.
+type Total struct {
+	// Currency is expenses currency.
+	Currency model.Currency `json:"currency" db:"currency"`
+
+	// Amount is total expenses amount.
+	Amount Amount `json:"amount" db:"amount"`
+}
+
+// Report is summary of expenses and outstanding debts of a group or a group event.
+type Report struct {
+	// GroupID is group ID.
+	GroupID user.GroupID `json:"group_id"`
+
+	// EventID is ID of group event. Empty if report includes whole group.
+	EventID *user.EventID `json:"event_id,omitempty"`
+
+	// Spent is total amount of expenses in each currency.
+	Spent []Total `json:"spent"`
+
+	// Debts is list of outstanding debts between users.
+	Debts []Debt `json:"debts"`
+}
@@ -1,14 +1,58 @@
 package loan
 
-import "github.com/x1unix/sbda-ledger/internal/model/user"
+import (
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
 
 // Balance is dept balance (saldo) for specific user.
 //
-// Basically is summary of loans given to specific user and debts of that user.
+// Basically is summary of journal postings against specific user.
 type Balance struct {
 	// UserID is ID of related user
 	UserID user.ID `json:"user_id" db:"user_id"`
 
+	// Currency is balance currency.
+	//
+	// Postings in different currencies are never mixed in one balance.
+	Currency model.Currency `json:"currency" db:"currency"`
+
 	// Balance is summary of loans given to specific user and debts of that user.
 	Balance Amount `json:"balance" db:"balance"`
 }
+
+// GroupBalance is user balance built from transactions of specific group.
+type GroupBalance struct {
+	Balance
+
+	// GroupID is ID of group. Nil value means balance outside groups.
+	GroupID *user.GroupID `json:"group_id,omitempty" db:"group_id"`
+}
+
+// Debt is outstanding debt of one user to another.
+type Debt struct {
+	// LenderID is ID of user who lent money
+	LenderID user.ID `json:"lender_id" db:"lender_id"`
+
+	// DebtorID is ID of user who owes money
+	DebtorID user.ID `json:"debtor_id" db:"debtor_id"`
+
+	// Currency is debt currency
+	Currency model.Currency `json:"currency" db:"currency"`
+
+	// Amount is positive debt amount
+	Amount Amount `json:"amount" db:"amount"`
+}
+
+// Balances is list of balances grouped by currency.
+type Balances = map[model.Currency][]Balance
+
+// GroupByCurrency groups list of balance records by currency.
+func GroupByCurrency(items []Balance) Balances {
+	out := make(Balances)
+	for _, b := range items {
+		out[b.Currency] = append(out[b.Currency], b)
+	}
+
+	return out
+}
@@ -0,0 +1,369 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/x1unix/sbda-ledger/internal/web"
+)
+
+const decimalSeparator = "."
+
+var (
+	ErrAmountOverflow   = web.NewErrBadRequest("amount is out of range")
+	ErrCurrencyMismatch = errors.New("currency mismatch")
+)
+
+// Money is monetary amount in specific currency.
+//
+// Amount is stored in minor units of currency (e.g. cents for EUR).
+//
+// In JSON, amount is represented as decimal string in major units:
+//
+//	{"amount": "12.50", "currency": "EUR"}
+type Money struct {
+	// Amount is amount in minor units
+	Amount int64
+
+	// Currency is amount currency
+	Currency Currency
+}
+
+// NewMoney constructs money from amount in minor units
+func NewMoney(amount int64, cur Currency) Money {
+	return Money{Amount: amount, Currency: cur}
+}
+
+// ParseMoney parses decimal amount string in major units (e.g. "12.50").
+//
+// Amount can't have more digits after decimal separator than currency minor units.
+func ParseMoney(str string, cur Currency) (Money, error) {
+	if !cur.IsValid() {
+		return Money{}, web.NewErrBadRequest("unsupported currency %q", cur)
+	}
+
+	str = strings.TrimSpace(str)
+	if str == "" {
+		return Money{}, web.NewErrBadRequest("empty amount")
+	}
+
+	isNegative := false
+	unsigned := str
+	switch str[0] {
+	case '-':
+		isNegative = true
+		unsigned = str[1:]
+	case '+':
+		unsigned = str[1:]
+	}
+
+	intPart, fracPart := unsigned, ""
+	if i := strings.Index(unsigned, decimalSeparator); i >= 0 {
+		intPart, fracPart = unsigned[:i], unsigned[i+1:]
+	}
+
+	if (intPart == "" && fracPart == "") || !isDigits(intPart) || !isDigits(fracPart) {
+		return Money{}, web.NewErrBadRequest("invalid amount %q", str)
+	}
+
+	minorUnits := cur.MinorUnits()
+	if len(fracPart) > minorUnits {
+		return Money{}, web.NewErrBadRequest("%s amount can't have more than %d digits after decimal separator",
+			cur, minorUnits)
+	}
+
+	digits := intPart + fracPart + strings.Repeat("0", minorUnits-len(fracPart))
+	if digits = strings.TrimLeft(digits, "0"); digits == "" {
+		return NewMoney(0, cur), nil
+	}
+
+	if isNegative {
+		digits = "-" + digits
+	}
+
+	amount, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(amount, cur), nil
+}
+
+// IsPositive checks if amount is greater than zero
+func (m Money) IsPositive() bool {
+	return m.Amount > 0
+}
+
+// FormatAmount returns amount as decimal string in major units (e.g. "12.50").
+func (m Money) FormatAmount() string {
+	// uint64 is used to keep absolute value of math.MinInt64
+	abs := uint64(m.Amount)
+	if m.Amount < 0 {
+		abs = -abs
+	}
+
+	str := strconv.FormatUint(abs, 10)
+	if minorUnits := m.Currency.MinorUnits(); minorUnits > 0 {
+		if len(str) <= minorUnits {
+			str = strings.Repeat("0", minorUnits-len(str)+1) + str
+		}
+
+		pos := len(str) - minorUnits
+		str = str[:pos] + decimalSeparator + str[pos:]
+	}
+
+	if m.Amount < 0 {
+		return "-" + str
+	}
+
+	return str
+}
+
+// String implements fmt.Stringer
+func (m Money) String() string {
+	return m.FormatAmount() + " " + m.Currency.String()
+}
+
+// Add returns sum of two amounts in the same currency.
+//
+// Returns ErrAmountOverflow on integer overflow.
+func (m Money) Add(v Money) (Money, error) {
+	if m.Currency != v.Currency {
+		return Money{}, fmt.Errorf("%w: can't add %s to %s", ErrCurrencyMismatch, v.Currency, m.Currency)
+	}
+
+	sum := m.Amount + v.Amount
+	if (v.Amount > 0 && sum < m.Amount) || (v.Amount < 0 && sum > m.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(sum, m.Currency), nil
+}
+
+// Sub returns difference of two amounts in the same currency.
+//
+// Returns ErrAmountOverflow on integer overflow.
+func (m Money) Sub(v Money) (Money, error) {
+	if m.Currency != v.Currency {
+		return Money{}, fmt.Errorf("%w: can't subtract %s from %s", ErrCurrencyMismatch, v.Currency, m.Currency)
+	}
+
+	diff := m.Amount - v.Amount
+	if (v.Amount > 0 && diff > m.Amount) || (v.Amount < 0 && diff < m.Amount) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(diff, m.Currency), nil
+}
+
+// Mul multiplies amount by n.
+//
+// Returns ErrAmountOverflow on integer overflow.
+func (m Money) Mul(n int64) (Money, error) {
+	if m.Amount == 0 || n == 0 {
+		return NewMoney(0, m.Currency), nil
+	}
+
+	product := m.Amount * n
+	if product/n != m.Amount || (m.Amount == -1 && n == math.MinInt64) || (n == -1 && m.Amount == math.MinInt64) {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(product, m.Currency), nil
+}
+
+// RoundingPolicy is rounding mode used when amount can't be divided precisely
+type RoundingPolicy string
+
+const (
+	// RoundHalfUp rounds result half away from zero
+	RoundHalfUp RoundingPolicy = "half_up"
+
+	// RoundUp rounds result away from zero
+	RoundUp RoundingPolicy = "up"
+
+	// RoundDown rounds result towards zero
+	RoundDown RoundingPolicy = "down"
+
+	// DefaultRounding is rounding policy used when policy is not specified explicitly.
+	DefaultRounding = RoundHalfUp
+)
+
+// OrDefault returns DefaultRounding if rounding policy is empty
+func (p RoundingPolicy) OrDefault() RoundingPolicy {
+	if p == "" {
+		return DefaultRounding
+	}
+
+	return p
+}
+
+// DivRoundPolicy divides amount by n and rounds result using specified rounding policy.
+//
+// N should be greater than zero.
+func (m Money) DivRoundPolicy(n int64, policy RoundingPolicy) Money {
+	quo, rem := m.Amount/n, m.Amount%n
+	if rem == 0 {
+		return NewMoney(quo, m.Currency)
+	}
+
+	switch policy.OrDefault() {
+	case RoundDown:
+		return NewMoney(quo, m.Currency)
+	case RoundUp:
+		if m.Amount < 0 {
+			return NewMoney(quo-1, m.Currency)
+		}
+
+		return NewMoney(quo+1, m.Currency)
+	default:
+		return m.DivRound(n)
+	}
+}
+
+// DivRound divides amount by n and rounds result half away from zero.
+//
+// Minor unit is a quantum value, so result of division can't be precise.
+// N should be greater than zero.
+func (m Money) DivRound(n int64) Money {
+	quo, rem := m.Amount/n, m.Amount%n
+	if rem < 0 {
+		rem = -rem
+	}
+
+	// compare remainder with a half of divisor without overflow
+	if rem >= n-rem {
+		if m.Amount < 0 {
+			quo--
+		} else {
+			quo++
+		}
+	}
+
+	return NewMoney(quo, m.Currency)
+}
+
+// Convert converts amount to other currency using exchange rate.
+//
+// Difference in minor units between currencies is taken into account
+// and result is rounded to the closest minor unit.
+//
+// Returns ErrAmountOverflow if result doesn't fit into amount.
+func (m Money) Convert(to Currency, rate float64) (Money, error) {
+	if m.Currency == to && rate == 1 {
+		return m, nil
+	}
+
+	scale := math.Pow10(to.MinorUnits() - m.Currency.MinorUnits())
+	result := math.Round(float64(m.Amount) * rate * scale)
+	if math.IsNaN(result) || result >= math.MaxInt64 || result < math.MinInt64 {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(int64(result), to), nil
+}
+
+type moneyJSON struct {
+	Amount   Decimal  `json:"amount"`
+	Currency Currency `json:"currency"`
+}
+
+// MarshalJSON implements json.Marshaler
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(struct {
+		Amount   string   `json:"amount"`
+		Currency Currency `json:"currency"`
+	}{
+		Amount:   m.FormatAmount(),
+		Currency: m.Currency,
+	})
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (m *Money) UnmarshalJSON(data []byte) error {
+	var v moneyJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	val, err := v.Amount.Money(v.Currency)
+	if err != nil {
+		return err
+	}
+
+	*m = val
+	return nil
+}
+
+// Decimal is monetary amount value received from JSON payload.
+//
+// Decimal accepts decimal strings in major units (e.g. "12.50")
+// and JSON integers in minor units (e.g. 1250) for backward compatibility.
+//
+// Currency is required to get an exact amount, use Decimal.Money for conversion.
+type Decimal struct {
+	value      string
+	minorUnits bool
+}
+
+// IsEmpty checks if value is empty
+func (d Decimal) IsEmpty() bool {
+	return d.value == ""
+}
+
+// Money returns amount in specified currency
+func (d Decimal) Money(cur Currency) (Money, error) {
+	if !d.minorUnits {
+		return ParseMoney(d.value, cur)
+	}
+
+	if !cur.IsValid() {
+		return Money{}, web.NewErrBadRequest("unsupported currency %q", cur)
+	}
+
+	amount, err := strconv.ParseInt(d.value, 10, 64)
+	if err != nil {
+		return Money{}, ErrAmountOverflow
+	}
+
+	return NewMoney(amount, cur), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler
+func (d *Decimal) UnmarshalJSON(data []byte) error {
+	str := string(data)
+	if str == "null" {
+		*d = Decimal{}
+		return nil
+	}
+
+	if strings.HasPrefix(str, `"`) {
+		if err := json.Unmarshal(data, &str); err != nil {
+			return err
+		}
+
+		*d = Decimal{value: str}
+		return nil
+	}
+
+	if _, err := strconv.ParseInt(str, 10, 64); err != nil {
+		return fmt.Errorf("amount in minor units should be an integer, use decimal string instead: %s", str)
+	}
+
+	*d = Decimal{value: str, minorUnits: true}
+	return nil
+}
+
+func isDigits(str string) bool {
+	for _, c := range str {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,255 @@
+package model
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMoney(t *testing.T) {
+	cases := map[string]struct {
+		str     string
+		cur     Currency
+		want    int64
+		wantErr string
+	}{
+		"cents": {
+			str:  "12.50",
+			cur:  "EUR",
+			want: 1250,
+		},
+		"no fraction": {
+			str:  "12",
+			cur:  "EUR",
+			want: 1200,
+		},
+		"short fraction": {
+			str:  "0.5",
+			cur:  "EUR",
+			want: 50,
+		},
+		"leading separator": {
+			str:  ".05",
+			cur:  "USD",
+			want: 5,
+		},
+		"negative": {
+			str:  "-1.01",
+			cur:  "EUR",
+			want: -101,
+		},
+		"zero": {
+			str:  "0.00",
+			cur:  "EUR",
+			want: 0,
+		},
+		"no minor units": {
+			str:  "1500",
+			cur:  "JPY",
+			want: 1500,
+		},
+		"three minor units": {
+			str:  "1.234",
+			cur:  "BHD",
+			want: 1234,
+		},
+		"max value": {
+			str:  "92233720368547758.07",
+			cur:  "EUR",
+			want: math.MaxInt64,
+		},
+		"min value": {
+			str:  "-92233720368547758.08",
+			cur:  "EUR",
+			want: math.MinInt64,
+		},
+		"overflow": {
+			str:     "92233720368547758.08",
+			cur:     "EUR",
+			wantErr: "amount is out of range",
+		},
+		"too many digits": {
+			str:     "1.234",
+			cur:     "EUR",
+			wantErr: "EUR amount can't have more than 2 digits after decimal separator",
+		},
+		"fraction for JPY": {
+			str:     "1.5",
+			cur:     "JPY",
+			wantErr: "JPY amount can't have more than 0 digits after decimal separator",
+		},
+		"empty": {
+			cur:     "EUR",
+			wantErr: "empty amount",
+		},
+		"separator only": {
+			str:     ".",
+			cur:     "EUR",
+			wantErr: `invalid amount "."`,
+		},
+		"garbage": {
+			str:     "1e3",
+			cur:     "EUR",
+			wantErr: `invalid amount "1e3"`,
+		},
+		"bad currency": {
+			str:     "1",
+			cur:     "FOO",
+			wantErr: `unsupported currency "FOO"`,
+		},
+	}
+
+	for k, v := range cases {
+		t.Run(k, func(t *testing.T) {
+			got, err := ParseMoney(v.str, v.cur)
+			if v.wantErr != "" {
+				require.EqualError(t, err, v.wantErr)
+				return
+			}
+
+			require.NoError(t, err)
+			require.Equal(t, NewMoney(v.want, v.cur), got)
+		})
+	}
+}
+
+func TestMoney_FormatAmount(t *testing.T) {
+	cases := []struct {
+		val  Money
+		want string
+	}{
+		{val: NewMoney(1250, "EUR"), want: "12.50"},
+		{val: NewMoney(5, "EUR"), want: "0.05"},
+		{val: NewMoney(0, "EUR"), want: "0.00"},
+		{val: NewMoney(-101, "EUR"), want: "-1.01"},
+		{val: NewMoney(1500, "JPY"), want: "1500"},
+		{val: NewMoney(1, "BHD"), want: "0.001"},
+		{val: NewMoney(math.MinInt64, "EUR"), want: "-92233720368547758.08"},
+	}
+
+	for _, v := range cases {
+		t.Run(v.want, func(t *testing.T) {
+			require.Equal(t, v.want, v.val.FormatAmount())
+
+			parsed, err := ParseMoney(v.want, v.val.Currency)
+			require.NoError(t, err)
+			require.Equal(t, v.val, parsed)
+		})
+	}
+}
+
+func TestMoney_Arithmetic(t *testing.T) {
+	eur := func(v int64) Money {
+		return NewMoney(v, "EUR")
+	}
+
+	got, err := eur(100).Add(eur(50))
+	require.NoError(t, err)
+	require.Equal(t, eur(150), got)
+
+	got, err = eur(100).Sub(eur(150))
+	require.NoError(t, err)
+	require.Equal(t, eur(-50), got)
+
+	got, err = eur(-50).Mul(3)
+	require.NoError(t, err)
+	require.Equal(t, eur(-150), got)
+
+	_, err = eur(math.MaxInt64).Add(eur(1))
+	require.Equal(t, ErrAmountOverflow, err)
+
+	_, err = eur(math.MinInt64).Sub(eur(1))
+	require.Equal(t, ErrAmountOverflow, err)
+
+	_, err = eur(math.MinInt64).Mul(-1)
+	require.Equal(t, ErrAmountOverflow, err)
+
+	_, err = eur(math.MaxInt64 / 2).Mul(3)
+	require.Equal(t, ErrAmountOverflow, err)
+
+	_, err = eur(1).Add(NewMoney(1, "USD"))
+	require.True(t, errors.Is(err, ErrCurrencyMismatch))
+}
+
+func TestMoney_DivRound(t *testing.T) {
+	cases := []struct {
+		amount int64
+		n      int64
+		want   int64
+	}{
+		{amount: 1000, n: 3, want: 333},
+		{amount: 1000, n: 6, want: 167},
+		{amount: 5, n: 2, want: 3},
+		{amount: -5, n: 2, want: -3},
+		{amount: -1000, n: 3, want: -333},
+		{amount: math.MaxInt64, n: 2, want: math.MaxInt64/2 + 1},
+	}
+
+	for _, v := range cases {
+		got := NewMoney(v.amount, "EUR").DivRound(v.n)
+		require.Equal(t, v.want, got.Amount, "%d / %d", v.amount, v.n)
+	}
+}
+
+func TestMoney_DivRoundPolicy(t *testing.T) {
+	cases := []struct {
+		amount int64
+		n      int64
+		policy RoundingPolicy
+		want   int64
+	}{
+		{amount: 1000, n: 3, policy: RoundUp, want: 334},
+		{amount: 1000, n: 3, policy: RoundDown, want: 333},
+		{amount: 1000, n: 3, policy: RoundHalfUp, want: 333},
+		{amount: 1000, n: 6, policy: RoundDown, want: 166},
+		{amount: 1000, n: 6, policy: "", want: 167},
+		{amount: -5, n: 2, policy: RoundUp, want: -3},
+		{amount: -5, n: 2, policy: RoundDown, want: -2},
+		{amount: 900, n: 3, policy: RoundUp, want: 300},
+	}
+
+	for _, v := range cases {
+		got := NewMoney(v.amount, "EUR").DivRoundPolicy(v.n, v.policy)
+		require.Equal(t, v.want, got.Amount, "%d / %d (%s)", v.amount, v.n, v.policy)
+	}
+}
+
+func TestMoney_Convert(t *testing.T) {
+	got, err := NewMoney(5000, "USD").Convert("EUR", 0.8)
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(4000, "EUR"), got)
+
+	// 10.00 EUR -> 1600 JPY
+	got, err = NewMoney(1000, "EUR").Convert("JPY", 160)
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(1600, "JPY"), got)
+
+	// 1.000 BHD -> 2.44 EUR
+	got, err = NewMoney(1000, "BHD").Convert("EUR", 2.4401)
+	require.NoError(t, err)
+	require.Equal(t, NewMoney(244, "EUR"), got)
+
+	_, err = NewMoney(math.MaxInt64, "EUR").Convert("JPY", 160)
+	require.Equal(t, ErrAmountOverflow, err)
+}
+
+func TestMoney_JSON(t *testing.T) {
+	src := NewMoney(1250, "EUR")
+	data, err := json.Marshal(src)
+	require.NoError(t, err)
+	require.JSONEq(t, `{"amount":"12.50","currency":"EUR"}`, string(data))
+
+	var got Money
+	require.NoError(t, json.Unmarshal(data, &got))
+	require.Equal(t, src, got)
+
+	// integer amount is treated as minor units
+	require.NoError(t, json.Unmarshal([]byte(`{"amount":1250,"currency":"EUR"}`), &got))
+	require.Equal(t, src, got)
+
+	require.Error(t, json.Unmarshal([]byte(`{"amount":12.5,"currency":"EUR"}`), &got))
+	require.Error(t, json.Unmarshal([]byte(`{"amount":"12.505","currency":"EUR"}`), &got))
+}
@@ -1,7 +1,18 @@
 package request
 
-import "github.com/x1unix/sbda-ledger/internal/model/loan"
+import (
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
 
 type BalanceStatus struct {
-	Status []loan.Balance `json:"status"`
+	Status loan.Balances `json:"status"`
+}
+
+type BalanceCheckRequest struct {
+	// UserIDs is list of users to check. All users are checked if empty.
+	UserIDs []user.ID `json:"user_ids"`
+
+	// Rebuild enables rebuild of inconsistent balance cache.
+	Rebuild bool `json:"rebuild"`
 }
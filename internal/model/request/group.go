@@ -1,12 +1,102 @@
 package request
 
 import (
-	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"time"
+
+	"github.com/x1unix/sbda-ledger/internal/model"
 	"github.com/x1unix/sbda-ledger/internal/model/user"
 )
 
 type GroupCreateRequest struct {
-	Name string `json:"name" validate:"required,min=3,max=64"`
+	Name        string `json:"name" validate:"required,min=3,max=64"`
+	Description string `json:"description" validate:"max=256"`
+
+	// Currency is optional group base currency. model.DefaultCurrency is used if empty.
+	Currency model.Currency `json:"currency" validate:"omitempty,currency"`
+
+	// SplitMode is optional default expense split mode. user.DefaultSplitMode is used if empty.
+	SplitMode user.SplitMode `json:"split_mode" validate:"omitempty,oneof=equal exclude_payer"`
+
+	// Rounding is optional expense share rounding policy. model.DefaultRounding is used if empty.
+	Rounding model.RoundingPolicy `json:"rounding" validate:"omitempty,oneof=half_up up down"`
+
+	// JoinPolicy is optional group join policy. user.DefaultJoinPolicy is used if empty.
+	JoinPolicy user.JoinPolicy `json:"join_policy" validate:"omitempty,oneof=closed request"`
+}
+
+// Settings returns group settings from request
+func (r GroupCreateRequest) Settings() user.GroupSettings {
+	return user.GroupSettings{
+		Name:        r.Name,
+		Description: r.Description,
+		Currency:    r.Currency,
+		SplitMode:   r.SplitMode,
+		Rounding:    r.Rounding,
+		JoinPolicy:  r.JoinPolicy,
+	}
+}
+
+// GroupUpdateRequest is partial group settings update.
+//
+// Only present fields are changed.
+type GroupUpdateRequest struct {
+	Name        *string               `json:"name"`
+	Description *string               `json:"description"`
+	Currency    *model.Currency       `json:"currency"`
+	SplitMode   *user.SplitMode       `json:"split_mode"`
+	Rounding    *model.RoundingPolicy `json:"rounding"`
+	JoinPolicy  *user.JoinPolicy      `json:"join_policy"`
+}
+
+// Validate validates present fields using GroupCreateRequest rules
+func (r GroupUpdateRequest) Validate() error {
+	var (
+		dst    GroupCreateRequest
+		fields []string
+	)
+
+	if r.Name != nil {
+		dst.Name = *r.Name
+		fields = append(fields, "Name")
+	}
+	if r.Description != nil {
+		dst.Description = *r.Description
+		fields = append(fields, "Description")
+	}
+	if r.Currency != nil {
+		dst.Currency = *r.Currency
+		fields = append(fields, "Currency")
+	}
+	if r.SplitMode != nil {
+		dst.SplitMode = *r.SplitMode
+		fields = append(fields, "SplitMode")
+	}
+	if r.Rounding != nil {
+		dst.Rounding = *r.Rounding
+		fields = append(fields, "Rounding")
+	}
+	if r.JoinPolicy != nil {
+		dst.JoinPolicy = *r.JoinPolicy
+		fields = append(fields, "JoinPolicy")
+	}
+
+	if len(fields) == 0 {
+		return nil
+	}
+
+	return model.ValidatePartial(dst, fields...)
+}
+
+// Update returns group settings update from request
+func (r GroupUpdateRequest) Update() user.GroupSettingsUpdate {
+	return user.GroupSettingsUpdate{
+		Name:        r.Name,
+		Description: r.Description,
+		Currency:    r.Currency,
+		SplitMode:   r.SplitMode,
+		Rounding:    r.Rounding,
+		JoinPolicy:  r.JoinPolicy,
+	}
 }
 
 type GroupsResponse struct {
@@ -14,5 +104,122 @@ type GroupsResponse struct {
 }
 
 type AmountRequest struct {
-	Amount loan.Amount `json:"amount" validate:"required,min=1"`
+	// Amount is expense amount in major units as decimal string (e.g. "12.50").
+	//
+	// Integer value in minor units is also accepted for backward compatibility.
+	Amount model.Decimal `json:"amount"`
+
+	// Currency is optional expense currency. Group base currency is used if empty.
+	Currency model.Currency `json:"currency" validate:"omitempty,currency"`
+
+	// Date is optional expense date. Expense is shared between members who were active at this date.
+	Date *time.Time `json:"date"`
+
+	// EventID is optional ID of group event. Event expense is shared only between event participants.
+	EventID *user.EventID `json:"event_id"`
+}
+
+type EventRequest struct {
+	Name        string `json:"name" validate:"required,min=3,max=64"`
+	Description string `json:"description" validate:"max=256"`
+
+	// Participants is optional list of group members who share event expenses.
+	// Event is shared by all group members if list is empty.
+	Participants []user.ID `json:"participants"`
+}
+
+// Event returns group event from request
+func (r EventRequest) Event() user.Event {
+	return user.Event{
+		Name:         r.Name,
+		Description:  r.Description,
+		Participants: r.Participants,
+	}
+}
+
+type EventsResponse struct {
+	Events user.Events `json:"events"`
+}
+
+type HouseholdRequest struct {
+	Name string `json:"name" validate:"required,min=3,max=64"`
+
+	// Members is list of group members who share household balance.
+	Members []user.ID `json:"members" validate:"required,min=2"`
+
+	// HolderID is optional ID of household member who holds household balance.
+	// The first member is household holder if empty.
+	HolderID user.ID `json:"holder_id"`
+
+	// Weight is optional number of expense shares charged to household.
+	// Household is charged as a single participant if empty.
+	Weight int `json:"weight" validate:"omitempty,min=1,max=100"`
+}
+
+// Household returns group household from request
+func (r HouseholdRequest) Household() user.Household {
+	return user.Household{
+		Name:     r.Name,
+		Members:  r.Members,
+		HolderID: r.HolderID,
+		Weight:   r.Weight,
+	}
+}
+
+type HouseholdsResponse struct {
+	Households user.Households `json:"households"`
+}
+
+type AddMembersRequest struct {
+	IDs []user.ID `json:"ids" validate:"required,min=1"`
+
+	// Role is optional members role. user.RoleMember is used if empty.
+	Role user.Role `json:"role" validate:"omitempty,oneof=admin member viewer"`
+}
+
+type RoleRequest struct {
+	Role user.Role `json:"role" validate:"required,oneof=admin member viewer"`
+}
+
+type MembersList struct {
+	Users user.Members `json:"users"`
+}
+
+type TransferRequest struct {
+	// UserID is new group owner ID.
+	UserID user.ID `json:"user_id"`
+}
+
+type InviteRequest struct {
+	// Role is optional role of joined members. user.RoleMember is used if empty.
+	Role user.Role `json:"role" validate:"omitempty,oneof=admin member viewer"`
+
+	// MaxUses is max number of invite uses. Zero value means unlimited.
+	MaxUses int `json:"max_uses" validate:"min=0"`
+
+	// ExpiresIn is invite lifetime in seconds. Default lifetime is used if zero.
+	ExpiresIn int64 `json:"expires_in" validate:"min=0"`
+
+	// PlaceholderID is optional ID of placeholder member claimed by invite.
+	PlaceholderID *user.ID `json:"placeholder_id"`
+}
+
+// PlaceholderRequest is request to add placeholder member to a group
+type PlaceholderRequest struct {
+	// Name is placeholder display name
+	Name string `json:"name" validate:"required,min=3,max=64,name"`
+}
+
+type InvitesResponse struct {
+	Invites user.Invites `json:"invites"`
+}
+
+// JoinRequestCreate is request to join a group
+type JoinRequestCreate struct {
+	// Message is optional message for group admins
+	Message string `json:"message" validate:"max=256"`
+}
+
+type JoinRequestsResponse struct {
+	Requests user.JoinRequests `json:"requests"`
 }
@@ -0,0 +1,72 @@
+package request
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+func TestGroupUpdateRequest_Validate(t *testing.T) {
+	str := func(s string) *string { return &s }
+	cur := func(c model.Currency) *model.Currency { return &c }
+	split := func(m user.SplitMode) *user.SplitMode { return &m }
+	rounding := func(p model.RoundingPolicy) *model.RoundingPolicy { return &p }
+	policy := func(p user.JoinPolicy) *user.JoinPolicy { return &p }
+
+	cases := map[string]struct {
+		req     GroupUpdateRequest
+		wantErr string
+	}{
+		"empty": {},
+		"only description": {
+			req: GroupUpdateRequest{Description: str("")},
+		},
+		"valid struct": {
+			req: GroupUpdateRequest{
+				Name:       str("trip"),
+				Currency:   cur("USD"),
+				SplitMode:  split(user.SplitExcludePayer),
+				Rounding:   rounding(model.RoundDown),
+				JoinPolicy: policy(user.JoinPolicyRequest),
+			},
+		},
+		"empty name": {
+			req:     GroupUpdateRequest{Name: str("")},
+			wantErr: "Key: 'GroupCreateRequest.name' Error:Field validation for 'name' failed on the 'required' tag",
+		},
+		"short name": {
+			req:     GroupUpdateRequest{Name: str("ab"), Description: str("")},
+			wantErr: "Key: 'GroupCreateRequest.name' Error:Field validation for 'name' failed on the 'min' tag",
+		},
+		"invalid currency": {
+			req:     GroupUpdateRequest{Currency: cur("XXX")},
+			wantErr: "Key: 'GroupCreateRequest.currency' Error:Field validation for 'currency' failed on the 'currency' tag",
+		},
+		"invalid split mode": {
+			req:     GroupUpdateRequest{SplitMode: split("shares")},
+			wantErr: "Key: 'GroupCreateRequest.split_mode' Error:Field validation for 'split_mode' failed on the 'oneof' tag",
+		},
+		"invalid rounding": {
+			req:     GroupUpdateRequest{Rounding: rounding("ceil")},
+			wantErr: "Key: 'GroupCreateRequest.rounding' Error:Field validation for 'rounding' failed on the 'oneof' tag",
+		},
+		"invalid join policy": {
+			req:     GroupUpdateRequest{JoinPolicy: policy("open")},
+			wantErr: "Key: 'GroupCreateRequest.join_policy' Error:Field validation for 'join_policy' failed on the 'oneof' tag",
+		},
+	}
+
+	for k, v := range cases {
+		t.Run(k, func(t *testing.T) {
+			err := v.req.Validate()
+			if v.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.EqualError(t, err, v.wantErr)
+		})
+	}
+}
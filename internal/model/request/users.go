@@ -9,3 +9,29 @@ type UserIDs struct {
 type UsersList struct {
 	Users user.Users `json:"users"`
 }
+
+// ProfileUpdateRequest is partial user profile update
+type ProfileUpdateRequest struct {
+	Name  *string `json:"name"`
+	Email *string `json:"email"`
+}
+
+// Update returns profile update from request
+func (r ProfileUpdateRequest) Update() user.ProfileUpdate {
+	return user.ProfileUpdate{
+		Name:  r.Name,
+		Email: r.Email,
+	}
+}
+
+// ProfileResponse is user profile with pending email change
+type ProfileResponse struct {
+	*user.User
+
+	// PendingEmail is a new email which waits for confirmation
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+type ConfirmEmailRequest struct {
+	Token string `json:"token" validate:"required"`
+}
@@ -0,0 +1,48 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// emailChangeTokenSize is size of random email change confirmation token in bytes
+const emailChangeTokenSize = 18
+
+// EmailChange is pending change of user email.
+//
+// Email is changed only after user confirms it with a token sent to a new email.
+type EmailChange struct {
+	// Token is secret confirmation token
+	Token string `json:"token"`
+
+	// UserID is ID of user who requested email change
+	UserID ID `json:"user_id"`
+
+	// Email is a new user email
+	Email string `json:"email"`
+
+	// ExpiresAt is confirmation token expiration date
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// NewEmailChange returns a new pending email change with random token
+func NewEmailChange(uid ID, email string, ttl time.Duration) (*EmailChange, error) {
+	buff := make([]byte, emailChangeTokenSize)
+	if _, err := rand.Read(buff); err != nil {
+		return nil, fmt.Errorf("failed to generate email change token: %w", err)
+	}
+
+	return &EmailChange{
+		Token:     base64.RawURLEncoding.EncodeToString(buff),
+		UserID:    uid,
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	}, nil
+}
+
+// TTL returns time until confirmation token expiration
+func (c EmailChange) TTL() time.Duration {
+	return time.Until(c.ExpiresAt)
+}
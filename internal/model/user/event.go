@@ -0,0 +1,58 @@
+package user
+
+import (
+	"time"
+
+	"github.com/jackc/pgtype"
+)
+
+// EventID is group event ID
+type EventID = pgtype.UUID
+
+type Events = []Event
+
+// Event is a sub-ledger inside a group, like a trip or an occasion.
+//
+// Event participants are group members. Event without participants is shared by all group members.
+type Event struct {
+	ID          EventID `json:"id" db:"id"`
+	GroupID     GroupID `json:"group_id" db:"group_id"`
+	Name        string  `json:"name" db:"name"`
+	Description string  `json:"description" db:"description"`
+
+	// Participants is list of group members who share event expenses.
+	//
+	// Empty list means that event is shared by all group members.
+	Participants []ID `json:"participants" db:"-"`
+
+	// CreatedBy is ID of user who created the event
+	CreatedBy *ID `json:"created_by,omitempty" db:"created_by"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// IsShared returns true if event is shared by all group members
+func (e Event) IsShared() bool {
+	return len(e.Participants) == 0
+}
+
+// FilterParticipants returns members who participate in the event.
+func (e Event) FilterParticipants(members []ID) []ID {
+	if e.IsShared() {
+		return members
+	}
+
+	participants := make(map[[16]byte]struct{}, len(e.Participants))
+	for _, uid := range e.Participants {
+		participants[uid.Bytes] = struct{}{}
+	}
+
+	out := make([]ID, 0, len(e.Participants))
+	for _, uid := range members {
+		if _, ok := participants[uid.Bytes]; ok {
+			out = append(out, uid)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,40 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/jackc/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEvent_FilterParticipants(t *testing.T) {
+	newID := func(b byte) ID {
+		return ID{Bytes: [16]byte{b}, Status: pgtype.Present}
+	}
+
+	members := []ID{newID(1), newID(2), newID(3)}
+	cases := map[string]struct {
+		participants []ID
+		want         []ID
+	}{
+		"shared event": {
+			want: members,
+		},
+		"subset": {
+			participants: []ID{newID(3), newID(1)},
+			want:         []ID{newID(1), newID(3)},
+		},
+		"former members are skipped": {
+			participants: []ID{newID(2), newID(4)},
+			want:         []ID{newID(2)},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			e := Event{Participants: c.participants}
+			require.Equal(t, c.participants == nil, e.IsShared())
+			require.Equal(t, c.want, e.FilterParticipants(members))
+		})
+	}
+}
@@ -1,17 +1,171 @@
 package user
 
-import "github.com/jackc/pgtype"
+import (
+	"time"
+
+	"github.com/jackc/pgtype"
+	"github.com/x1unix/sbda-ledger/internal/model"
+)
 
 type GroupID = pgtype.UUID
 type Groups = []Group
 
+// SplitMode is mode of expense split between group members
+type SplitMode string
+
+const (
+	// SplitEqual splits expense equally between all group members including payer
+	SplitEqual SplitMode = "equal"
+
+	// SplitExcludePayer splits expense equally between all group members except payer
+	SplitExcludePayer SplitMode = "exclude_payer"
+
+	// DefaultSplitMode is split mode used when mode is not specified explicitly.
+	DefaultSplitMode = SplitEqual
+)
+
+// OrDefault returns DefaultSplitMode if split mode is empty
+func (m SplitMode) OrDefault() SplitMode {
+	if m == "" {
+		return DefaultSplitMode
+	}
+
+	return m
+}
+
+// JoinPolicy controls whether users outside a group can request to join it
+type JoinPolicy string
+
+const (
+	// JoinPolicyClosed doesn't accept join requests, members are added only by admins or invites
+	JoinPolicyClosed JoinPolicy = "closed"
+
+	// JoinPolicyRequest accepts join requests from any user, requests are approved by group admins
+	JoinPolicyRequest JoinPolicy = "request"
+
+	// DefaultJoinPolicy is join policy used when policy is not specified explicitly.
+	DefaultJoinPolicy = JoinPolicyClosed
+)
+
+// OrDefault returns DefaultJoinPolicy if join policy is empty
+func (p JoinPolicy) OrDefault() JoinPolicy {
+	if p == "" {
+		return DefaultJoinPolicy
+	}
+
+	return p
+}
+
+// AcceptsRequests checks if group accepts join requests
+func (p JoinPolicy) AcceptsRequests() bool {
+	return p == JoinPolicyRequest
+}
+
+// GroupSettings is group settings editable by group owner and admins
+type GroupSettings struct {
+	Name        string `json:"name" db:"name"`
+	Description string `json:"description" db:"description"`
+
+	// Currency is group base currency.
+	//
+	// All group expenses are converted to base currency.
+	Currency model.Currency `json:"currency" db:"currency"`
+
+	// SplitMode is default expense split mode.
+	SplitMode SplitMode `json:"split_mode" db:"split_mode"`
+
+	// Rounding is rounding policy of expense share.
+	Rounding model.RoundingPolicy `json:"rounding" db:"rounding"`
+
+	// JoinPolicy controls whether users outside a group can request to join it.
+	JoinPolicy JoinPolicy `json:"join_policy" db:"join_policy"`
+}
+
+// WithDefaults returns settings with default values for empty currency, split mode, rounding and join policies
+func (s GroupSettings) WithDefaults() GroupSettings {
+	s.Currency = s.Currency.OrDefault()
+	s.SplitMode = s.SplitMode.OrDefault()
+	s.Rounding = s.Rounding.OrDefault()
+	s.JoinPolicy = s.JoinPolicy.OrDefault()
+	return s
+}
+
+// GroupSettingsUpdate is partial group settings update.
+//
+// Only non-nil fields are changed.
+type GroupSettingsUpdate struct {
+	Name        *string
+	Description *string
+	Currency    *model.Currency
+	SplitMode   *SplitMode
+	Rounding    *model.RoundingPolicy
+	JoinPolicy  *JoinPolicy
+}
+
+// IsEmpty returns true if update doesn't change anything
+func (u GroupSettingsUpdate) IsEmpty() bool {
+	return u.Name == nil && u.Description == nil && u.Currency == nil &&
+		u.SplitMode == nil && u.Rounding == nil && u.JoinPolicy == nil
+}
+
+// Apply returns settings with applied changes
+func (u GroupSettingsUpdate) Apply(s GroupSettings) GroupSettings {
+	if u.Name != nil {
+		s.Name = *u.Name
+	}
+	if u.Description != nil {
+		s.Description = *u.Description
+	}
+	if u.Currency != nil {
+		s.Currency = *u.Currency
+	}
+	if u.SplitMode != nil {
+		s.SplitMode = *u.SplitMode
+	}
+	if u.Rounding != nil {
+		s.Rounding = *u.Rounding
+	}
+	if u.JoinPolicy != nil {
+		s.JoinPolicy = *u.JoinPolicy
+	}
+
+	return s.WithDefaults()
+}
+
 type Group struct {
 	ID      pgtype.UUID `json:"id" db:"id"`
-	Name    string      `json:"name" db:"name"`
 	OwnerID ID          `json:"owner_id" db:"owner_id"`
+	GroupSettings
+
+	// Version is group version, incremented on each group change.
+	Version int64 `json:"version" db:"version"`
+
+	// ArchivedAt is group archival date. Archived groups are read-only.
+	ArchivedAt *time.Time `json:"archived_at,omitempty" db:"archived_at"`
+}
+
+// IsArchived returns true if group is archived
+func (g Group) IsArchived() bool {
+	return g.ArchivedAt != nil
 }
 
 type GroupInfo struct {
 	Group
-	Members Users `json:"members"`
+	Members Members `json:"members"`
+}
+
+type Members = []Member
+
+// Member is group member
+type Member struct {
+	User
+
+	// Role is member role in group
+	Role Role `json:"role" db:"role"`
+
+	// JoinedAt is start date of membership period
+	JoinedAt time.Time `json:"joined_at" db:"joined_at"`
+
+	// LeftAt is end date of membership period. Nil value means that membership is active.
+	LeftAt *time.Time `json:"left_at,omitempty" db:"left_at"`
 }
@@ -0,0 +1,103 @@
+package user
+
+import (
+	"time"
+
+	"github.com/jackc/pgtype"
+)
+
+const (
+	// DefaultHouseholdWeight is weight of household charged as a single participant.
+	DefaultHouseholdWeight = 1
+
+	// MaxHouseholdWeight is max number of expense shares charged to household.
+	MaxHouseholdWeight = 100
+)
+
+// HouseholdID is household ID
+type HouseholdID = pgtype.UUID
+
+type Households = []Household
+
+// Household is a set of group members, like a couple, which shares one balance inside a group.
+//
+// Household is charged as a single expense participant with specified weight.
+// All household debts are held by household holder, so debts net at household level.
+type Household struct {
+	ID      HouseholdID `json:"id" db:"id"`
+	GroupID GroupID     `json:"group_id" db:"group_id"`
+	Name    string      `json:"name" db:"name"`
+
+	// HolderID is ID of household member who holds household balance.
+	HolderID ID `json:"holder_id" db:"holder_id"`
+
+	// Weight is number of expense shares charged to household.
+	Weight int `json:"weight" db:"weight"`
+
+	// Members is list of household members including holder.
+	Members []ID `json:"members" db:"-"`
+
+	// CreatedBy is ID of user who created the household
+	CreatedBy *ID `json:"created_by,omitempty" db:"created_by"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+}
+
+// HasMember checks if user is household member
+func (h Household) HasMember(uid ID) bool {
+	for _, id := range h.Members {
+		if id.Bytes == uid.Bytes {
+			return true
+		}
+	}
+
+	return false
+}
+
+// FindHousehold returns household of a member or nil if member doesn't belong to any household.
+func FindHousehold(households Households, uid ID) *Household {
+	for i, h := range households {
+		if h.HasMember(uid) {
+			return &households[i]
+		}
+	}
+
+	return nil
+}
+
+// SplitUnit is expense participant which holds a single balance.
+//
+// Split unit is either a single group member or a household.
+type SplitUnit struct {
+	// HolderID is ID of user who holds unit balance.
+	HolderID ID
+
+	// Weight is number of expense shares charged to unit.
+	Weight int
+}
+
+// SplitUnits groups expense participants into split units.
+//
+// Participants from the same household are merged into a single unit held by household holder,
+// household holder is charged even if holder doesn't participate in expense.
+// Other participants are units of weight 1. Units keep order of participants list.
+func SplitUnits(participants []ID, households Households) []SplitUnit {
+	seen := make(map[[16]byte]struct{}, len(households))
+	units := make([]SplitUnit, 0, len(participants))
+	for _, uid := range participants {
+		h := FindHousehold(households, uid)
+		if h == nil {
+			units = append(units, SplitUnit{HolderID: uid, Weight: DefaultHouseholdWeight})
+			continue
+		}
+
+		if _, ok := seen[h.ID.Bytes]; ok {
+			continue
+		}
+
+		seen[h.ID.Bytes] = struct{}{}
+		units = append(units, SplitUnit{HolderID: h.HolderID, Weight: h.Weight})
+	}
+
+	return units
+}
@@ -0,0 +1,46 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/jackc/pgtype"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitUnits(t *testing.T) {
+	newID := func(b byte) ID {
+		return ID{Bytes: [16]byte{b}, Status: pgtype.Present}
+	}
+
+	households := Households{
+		{ID: newID(10), HolderID: newID(2), Weight: 2, Members: []ID{newID(2), newID(3)}},
+		{ID: newID(11), HolderID: newID(5), Weight: 1, Members: []ID{newID(5), newID(6)}},
+	}
+
+	cases := map[string]struct {
+		participants []ID
+		want         []SplitUnit
+	}{
+		"no households": {
+			participants: []ID{newID(1), newID(4)},
+			want:         []SplitUnit{{newID(1), 1}, {newID(4), 1}},
+		},
+		"household members are merged": {
+			participants: []ID{newID(1), newID(3), newID(2), newID(5), newID(6)},
+			want:         []SplitUnit{{newID(1), 1}, {newID(2), 2}, {newID(5), 1}},
+		},
+		"holder is charged for household": {
+			participants: []ID{newID(6), newID(3)},
+			want:         []SplitUnit{{newID(5), 1}, {newID(2), 2}},
+		},
+	}
+
+	for name, c := range cases {
+		t.Run(name, func(t *testing.T) {
+			require.Equal(t, c.want, SplitUnits(c.participants, households))
+		})
+	}
+
+	require.Equal(t, newID(11), FindHousehold(households, newID(6)).ID)
+	require.Nil(t, FindHousehold(households, newID(1)))
+}
@@ -0,0 +1,72 @@
+package user
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"time"
+)
+
+// inviteTokenSize is size of random invite token in bytes
+const inviteTokenSize = 18
+
+// Invite is shareable group invitation.
+//
+// Users join a group with invite role by accepting invite token.
+type Invite struct {
+	// Token is secret invite token
+	Token string `json:"token"`
+
+	// GroupID is ID of group to join
+	GroupID GroupID `json:"group_id"`
+
+	// CreatedBy is ID of invite author
+	CreatedBy ID `json:"created_by"`
+
+	// Role is role of joined members
+	Role Role `json:"role"`
+
+	// PlaceholderID is ID of placeholder member claimed by user who accepts invite (optional).
+	//
+	// User takes place of placeholder in a group instead of joining with invite role.
+	PlaceholderID *ID `json:"placeholder_id,omitempty"`
+
+	// MaxUses is max number of invite uses. Zero value means unlimited.
+	MaxUses int `json:"max_uses"`
+
+	// Uses is number of invite uses
+	Uses int `json:"uses"`
+
+	// CreatedAt is invite creation date
+	CreatedAt time.Time `json:"created_at"`
+
+	// ExpiresAt is invite expiration date
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Invites is list of invites
+type Invites = []Invite
+
+// NewInvite returns a new group invite with random token
+func NewInvite(gid GroupID, author ID, role Role, maxUses int, ttl time.Duration) (*Invite, error) {
+	buff := make([]byte, inviteTokenSize)
+	if _, err := rand.Read(buff); err != nil {
+		return nil, fmt.Errorf("failed to generate invite token: %w", err)
+	}
+
+	now := time.Now()
+	return &Invite{
+		Token:     base64.RawURLEncoding.EncodeToString(buff),
+		GroupID:   gid,
+		CreatedBy: author,
+		Role:      role,
+		MaxUses:   maxUses,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
+
+// TTL returns time until invite expiration
+func (i Invite) TTL() time.Duration {
+	return time.Until(i.ExpiresAt)
+}
@@ -0,0 +1,44 @@
+package user
+
+import (
+	"time"
+
+	"github.com/jackc/pgtype"
+)
+
+// JoinRequestID is group join request ID
+type JoinRequestID = pgtype.UUID
+
+// JoinRequestStatus is group join request status
+type JoinRequestStatus string
+
+const (
+	// JoinRequestPending is status of request waiting for decision
+	JoinRequestPending JoinRequestStatus = "pending"
+
+	// JoinRequestApproved is status of approved request
+	JoinRequestApproved JoinRequestStatus = "approved"
+
+	// JoinRequestDenied is status of denied request
+	JoinRequestDenied JoinRequestStatus = "denied"
+)
+
+// JoinRequest is user request to join a group
+type JoinRequest struct {
+	ID      JoinRequestID     `json:"id" db:"id"`
+	GroupID GroupID           `json:"group_id" db:"group_id"`
+	UserID  ID                `json:"user_id" db:"user_id"`
+	Message string            `json:"message" db:"message"`
+	Status  JoinRequestStatus `json:"status" db:"status"`
+
+	CreatedAt time.Time `json:"created_at" db:"created_at"`
+
+	// DecidedBy is ID of admin who approved or denied request
+	DecidedBy *ID `json:"decided_by,omitempty" db:"decided_by"`
+
+	// DecidedAt is date of decision
+	DecidedAt *time.Time `json:"decided_at,omitempty" db:"decided_at"`
+}
+
+// JoinRequests is list of join requests
+type JoinRequests = []JoinRequest
@@ -0,0 +1,97 @@
+package user
+
+// Role is group member role
+type Role string
+
+const (
+	// RoleOwner is group creator role.
+	//
+	// Owner role is not stored in members list and can't be assigned.
+	RoleOwner Role = "owner"
+
+	// RoleAdmin is group administrator, can manage members and expenses.
+	RoleAdmin Role = "admin"
+
+	// RoleMember is regular group member, can post expenses.
+	RoleMember Role = "member"
+
+	// RoleViewer is read-only group member.
+	RoleViewer Role = "viewer"
+)
+
+// Permission is action allowed to group member
+type Permission string
+
+const (
+	// PermRead allows to read group information, members and expenses.
+	PermRead Permission = "read"
+
+	// PermPostExpense allows to add expenses.
+	PermPostExpense Permission = "post_expense"
+
+	// PermEditExpense allows to edit expenses of other members.
+	PermEditExpense Permission = "edit_expense"
+
+	// PermManageMembers allows to add and remove members and viewers
+	// and change roles between member and viewer.
+	PermManageMembers Permission = "manage_members"
+
+	// PermManageAdmins allows to add, remove, grant and revoke admins.
+	PermManageAdmins Permission = "manage_admins"
+
+	// PermEditGroup allows to change group settings.
+	PermEditGroup Permission = "edit_group"
+
+	// PermDeleteGroup allows to delete a group.
+	PermDeleteGroup Permission = "delete_group"
+
+	// PermTransferOwnership allows to hand group over to another member.
+	PermTransferOwnership Permission = "transfer_ownership"
+)
+
+// permissions is group roles permission matrix
+var permissions = map[Role][]Permission{
+	RoleOwner: {
+		PermRead, PermPostExpense, PermEditExpense, PermEditGroup,
+		PermManageMembers, PermManageAdmins, PermDeleteGroup, PermTransferOwnership,
+	},
+	RoleAdmin:  {PermRead, PermPostExpense, PermEditExpense, PermEditGroup, PermManageMembers},
+	RoleMember: {PermRead, PermPostExpense},
+	RoleViewer: {PermRead},
+}
+
+// IsValid checks if role is known
+func (r Role) IsValid() bool {
+	_, ok := permissions[r]
+	return ok
+}
+
+// IsAssignable checks if role can be assigned to a group member
+func (r Role) IsAssignable() bool {
+	return r.IsValid() && r != RoleOwner
+}
+
+// Can checks if role has specified permission
+func (r Role) Can(p Permission) bool {
+	for _, v := range permissions[r] {
+		if v == p {
+			return true
+		}
+	}
+
+	return false
+}
+
+// CanAssign checks if role is allowed to assign specified role
+// or change role of a member with specified role.
+func (r Role) CanAssign(role Role) bool {
+	if !role.IsAssignable() {
+		return false
+	}
+
+	if role == RoleAdmin {
+		return r.Can(PermManageAdmins)
+	}
+
+	return r.Can(PermManageMembers)
+}
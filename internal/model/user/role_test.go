@@ -0,0 +1,59 @@
+package user
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRole_Can(t *testing.T) {
+	allPerms := []Permission{
+		PermRead, PermPostExpense, PermEditExpense, PermEditGroup,
+		PermManageMembers, PermManageAdmins, PermDeleteGroup, PermTransferOwnership,
+	}
+
+	cases := map[Role][]Permission{
+		RoleOwner:  allPerms,
+		RoleAdmin:  {PermRead, PermPostExpense, PermEditExpense, PermEditGroup, PermManageMembers},
+		RoleMember: {PermRead, PermPostExpense},
+		RoleViewer: {PermRead},
+		"unknown":  nil,
+	}
+
+	for role, want := range cases {
+		t.Run(string(role), func(t *testing.T) {
+			allowed := make(map[Permission]bool, len(want))
+			for _, p := range want {
+				allowed[p] = true
+			}
+
+			for _, p := range allPerms {
+				require.Equalf(t, allowed[p], role.Can(p), "unexpected %q permission", p)
+			}
+		})
+	}
+}
+
+func TestRole_CanAssign(t *testing.T) {
+	cases := []struct {
+		actor Role
+		role  Role
+		want  bool
+	}{
+		{actor: RoleOwner, role: RoleAdmin, want: true},
+		{actor: RoleOwner, role: RoleMember, want: true},
+		{actor: RoleOwner, role: RoleViewer, want: true},
+		{actor: RoleOwner, role: RoleOwner, want: false},
+		{actor: RoleAdmin, role: RoleAdmin, want: false},
+		{actor: RoleAdmin, role: RoleMember, want: true},
+		{actor: RoleAdmin, role: RoleViewer, want: true},
+		{actor: RoleMember, role: RoleMember, want: false},
+		{actor: RoleMember, role: RoleViewer, want: false},
+		{actor: RoleViewer, role: RoleViewer, want: false},
+		{actor: RoleOwner, role: "unknown", want: false},
+	}
+
+	for _, v := range cases {
+		require.Equalf(t, v.want, v.actor.CanAssign(v.role), "%s assigns %s", v.actor, v.role)
+	}
+}
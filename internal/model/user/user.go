@@ -30,6 +30,30 @@ type Props struct {
 	Name  string `json:"name" db:"name" validate:"required,min=3,max=64,name"`
 }
 
+// ProfileUpdate is partial user profile update.
+//
+// Only non-nil fields are changed.
+type ProfileUpdate struct {
+	Name  *string
+	Email *string
+}
+
+// IsEmpty returns true if update doesn't change anything
+func (u ProfileUpdate) IsEmpty() bool {
+	return u.Name == nil && u.Email == nil
+}
+
+// Apply returns profile props with applied changes
+func (u ProfileUpdate) Apply(p Props) Props {
+	if u.Name != nil {
+		p.Name = *u.Name
+	}
+	if u.Email != nil {
+		p.Email = *u.Email
+	}
+	return p
+}
+
 type Users = []User
 
 type User struct {
@@ -40,6 +64,19 @@ type User struct {
 
 	// PasswordHash contains encrypted password and salt in bcrypt format
 	PasswordHash string `json:"-" db:"password"`
+
+	// Placeholder marks unregistered user without email and password.
+	//
+	// Placeholder is added to a group by group admin and can be claimed later by registered user.
+	Placeholder bool `json:"placeholder,omitempty" db:"placeholder"`
+
+	// ClaimedBy is ID of user who claimed placeholder.
+	ClaimedBy *ID `json:"claimed_by,omitempty" db:"claimed_by"`
+}
+
+// IsUnclaimedPlaceholder checks if user is placeholder which is not claimed yet.
+func (u User) IsUnclaimedPlaceholder() bool {
+	return u.Placeholder && u.ClaimedBy == nil
 }
 
 // SetPassword encrypts and updates user password
@@ -28,6 +28,7 @@ func init() {
 	})
 
 	must(Validator.RegisterValidation("name", nameValidator))
+	must(Validator.RegisterValidation("currency", currencyValidator))
 }
 
 type validatorErrors struct {
@@ -59,6 +60,10 @@ func nameValidator(fl validator.FieldLevel) bool {
 	return nameRegEx.MatchString(val)
 }
 
+func currencyValidator(fl validator.FieldLevel) bool {
+	return Currency(fl.Field().String()).IsValid()
+}
+
 func must(err error) {
 	if err != nil {
 		panic(err)
@@ -77,7 +82,17 @@ type validationError struct {
 //
 // Wraps Validator.Struct method and returns API-compatible error.
 func Validate(v interface{}) error {
-	err := Validator.Struct(v)
+	return wrapValidationError(Validator.Struct(v))
+}
+
+// ValidatePartial validates only specified struct fields and returns an error on failure.
+//
+// Fields are referenced by struct field names.
+func ValidatePartial(v interface{}, fields ...string) error {
+	return wrapValidationError(Validator.StructPartial(v, fields...))
+}
+
+func wrapValidationError(err error) error {
 	if err == nil {
 		return nil
 	}
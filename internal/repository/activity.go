@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model/activity"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+const (
+	tableGroupActivity = "group_activity"
+
+	colActorID = "actor_id"
+	colType    = "type"
+	colPayload = "payload"
+)
+
+// ActivityRepository stores group activity feed in database
+type ActivityRepository struct {
+	db *sqlx.DB
+}
+
+// NewActivityRepository is ActivityRepository constructor
+func NewActivityRepository(db *sqlx.DB) *ActivityRepository {
+	return &ActivityRepository{db: db}
+}
+
+// AddEvent implements service.ActivityRecorder
+func (r ActivityRepository) AddEvent(ctx context.Context, e activity.Event) error {
+	q, args, err := psql.Insert(tableGroupActivity).SetMap(map[string]interface{}{
+		colGroupID: e.GroupID,
+		colActorID: e.ActorID,
+		colType:    e.Type,
+		colPayload: e.Payload,
+	}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	_, err = conn(ctx, r.db).ExecContext(ctx, q, args...)
+	return err
+}
+
+// GroupEvents implements service.ActivityStore
+func (r ActivityRepository) GroupEvents(ctx context.Context, gid user.GroupID, f activity.Filter) ([]activity.Event, error) {
+	cond := squirrel.And{squirrel.Eq{colGroupID: gid}}
+	if len(f.Types) > 0 {
+		cond = append(cond, squirrel.Eq{colType: f.Types})
+	}
+
+	if f.Cursor > 0 {
+		cond = append(cond, squirrel.Lt{colID: f.Cursor})
+	}
+
+	q, args, err := psql.
+		Select(colID, colGroupID, colActorID, colType, colPayload, colCreatedAt).
+		From(tableGroupActivity).Where(cond).
+		OrderBy(colID + " DESC").Limit(uint64(f.Limit)).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []activity.Event
+	err = conn(ctx, r.db).SelectContext(ctx, &out, q, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return out, err
+}
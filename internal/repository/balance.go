@@ -2,11 +2,15 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-redis/redis/v8"
 	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/balance"
 	"github.com/x1unix/sbda-ledger/internal/model/loan"
 	"github.com/x1unix/sbda-ledger/internal/model/user"
 	"github.com/x1unix/sbda-ledger/internal/service"
@@ -14,11 +18,109 @@ import (
 )
 
 const (
-	keyPrefixBalance = "balance:"
-	keyPrefixCached  = "cached:"
+	keyPrefixBalance    = "balance:"
+	keyPrefixCached     = "cached:"
+	keyPrefixApplied    = "applied:"
+	keyPrefixPopulating = "populating:"
+	keyPrefixPending    = "pending:"
+
+	balanceFieldSeparator = ":"
+
+	// appliedEventTTL is how long applied event mark is kept.
+	//
+	// Events are redelivered only if outbox wasn't cleaned after apply,
+	// so mark doesn't have to live long.
+	appliedEventTTL = 24 * time.Hour
+
+	// populationTTL is max balance population duration.
+	//
+	// Population is aborted if not completed in time, since events
+	// received after population mark expiration are lost.
+	populationTTL = 30 * time.Second
 )
 
+// applyEventScript atomically applies balance update event if event was not applied before.
+//
+// Balance is updated only if user balance is cached and event is not included
+// into cached balance snapshot. If balance is being populated, event is
+// recorded to be replayed on top of balance snapshot.
+//
+// KEYS: applied event mark, cache flag, balance hash, population mark, pending events list.
+// ARGV: mark TTL, event journal position, pending event entry, followed by field and delta pairs.
+//
+// Returns 0 if event was already applied.
+var applyEventScript = redis.NewScript(`
+if not redis.call('SET', KEYS[1], 1, 'NX', 'EX', ARGV[1]) then
+	return 0
+end
+
+local cachedSeq = redis.call('GET', KEYS[2])
+if cachedSeq then
+	if tonumber(ARGV[2]) <= (tonumber(cachedSeq) or 0) then
+		return 1
+	end
+
+	for i = 4, #ARGV, 2 do
+		redis.call('HINCRBY', KEYS[3], ARGV[i], ARGV[i + 1])
+	end
+	return 1
+end
+
+local ttl = redis.call('PTTL', KEYS[4])
+if ttl > 0 then
+	redis.call('RPUSH', KEYS[5], ARGV[3])
+	redis.call('PEXPIRE', KEYS[5], ttl)
+end
+return 1
+`)
+
+// completePopulationScript stores balance snapshot and replays events
+// received during population which are not included into snapshot.
+//
+// KEYS: cache flag, balance hash, population mark, pending events list.
+// ARGV: snapshot journal position, followed by field and balance pairs.
+//
+// Returns 0 if balance is already cached or population mark expired.
+var completePopulationScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 or redis.call('EXISTS', KEYS[3]) == 0 then
+	return 0
+end
+
+local seq = tonumber(ARGV[1])
+redis.call('DEL', KEYS[2])
+for i = 2, #ARGV, 2 do
+	redis.call('HSET', KEYS[2], ARGV[i], ARGV[i + 1])
+end
+
+for _, item in ipairs(redis.call('LRANGE', KEYS[4], 0, -1)) do
+	local event = cjson.decode(item)
+	if event.seq > seq then
+		for _, d in ipairs(event.deltas) do
+			redis.call('HINCRBY', KEYS[2], d[1], d[2])
+		end
+	end
+end
+
+redis.call('SET', KEYS[1], seq)
+redis.call('DEL', KEYS[3], KEYS[4])
+return 1
+`)
+
+// pendingEvent is balance update event received during balance population.
+type pendingEvent struct {
+	Seq int64 `json:"seq"`
+
+	// Deltas is list of field and delta pairs.
+	//
+	// Delta is encoded as string since Lua numbers can't keep int64 precisely.
+	Deltas [][2]string `json:"deltas"`
+}
+
 // BalanceRepository keeps user balance in Redis cache.
+//
+// User balance is stored in a hash at "balance:<uid>" key.
+// Each hash field contains balance with other user in specific currency,
+// and has "<currency>:<uid>" format, so balances in different currencies don't mix.
 type BalanceRepository struct {
 	log   *zap.Logger
 	redis redis.Cmdable
@@ -29,13 +131,6 @@ func NewBalanceRepository(log *zap.Logger, r redis.Cmdable) *BalanceRepository {
 	return &BalanceRepository{redis: r, log: log.Named("balance_cache")}
 }
 
-// HasBalance implements service.BalanceStore
-func (r BalanceRepository) HasBalance(ctx context.Context, uid user.ID) (bool, error) {
-	key := formatBalanceKey(uid)
-	v, err := r.redis.Exists(ctx, key).Result()
-	return v > 0, err
-}
-
 // GetBalance implements service.BalanceStore
 func (r BalanceRepository) GetBalance(ctx context.Context, uid user.ID) ([]loan.Balance, error) {
 	key := formatBalanceKey(uid)
@@ -70,58 +165,65 @@ func (r BalanceRepository) GetBalance(ctx context.Context, uid user.ID) ([]loan.
 	return nil, nil
 }
 
-// SetBalance implements service.BalanceStore
-func (r BalanceRepository) SetBalance(ctx context.Context, uid user.ID, balance ...loan.Balance) error {
-	key := formatBalanceKey(uid)
-	if err := r.setUserCacheFlag(ctx, uid, true); err != nil {
-		return fmt.Errorf("failed to set cache flag: %w", err)
+// BeginPopulation implements service.BalanceStore
+func (r BalanceRepository) BeginPopulation(ctx context.Context, uid user.ID) error {
+	if err := r.redis.Set(ctx, formatPopulatingKey(uid), 1, populationTTL).Err(); err != nil {
+		return fmt.Errorf("failed to set balance population mark: %w", err)
 	}
 
-	kvargs := make([]interface{}, 0, len(balance)*2)
-	for _, v := range balance {
-		kvargs = append(kvargs, user.IDToString(v.UserID), v.Balance)
+	return nil
+}
+
+// CompletePopulation implements service.BalanceStore
+func (r BalanceRepository) CompletePopulation(ctx context.Context, uid user.ID, seq int64, items ...loan.Balance) (bool, error) {
+	keys := []string{formatCachedKey(uid), formatBalanceKey(uid), formatPopulatingKey(uid), formatPendingKey(uid)}
+	args := make([]interface{}, 0, len(items)*2+1)
+	args = append(args, seq)
+	for _, v := range items {
+		args = append(args, formatBalanceField(v), v.Balance)
 	}
 
-	if err := r.redis.HMSet(ctx, key, kvargs).Err(); err != nil {
+	ok, err := completePopulationScript.Run(ctx, r.redis, keys, args...).Int()
+	if err != nil {
 		r.mustClearBalance(ctx, uid)
-		return fmt.Errorf("failed to set user balance: %w", err)
+		return false, fmt.Errorf("failed to set user balance: %w", err)
 	}
 
-	return nil
+	if ok == 0 {
+		return false, nil
+	}
+
+	r.log.Debug("populated user balance cache", zap.Any("uid", uid), zap.Int64("seq", seq))
+	return true, nil
 }
 
-func (r BalanceRepository) setUserCacheFlag(ctx context.Context, uid user.ID, val bool) error {
-	key := formatCachedKey(uid)
-	if val {
-		if err := r.redis.Set(ctx, key, true, 0).Err(); err != nil {
-			return fmt.Errorf("failed to set user cache flag: %w", err)
-		}
-		r.log.Debug("marked user balance as cached", zap.Any("uid", uid))
-		return nil
+// ApplyEvent implements service.BalanceStore
+func (r BalanceRepository) ApplyEvent(ctx context.Context, e balance.Event) (bool, error) {
+	pending := pendingEvent{Seq: e.Seq, Deltas: make([][2]string, len(e.Deltas))}
+	args := make([]interface{}, 0, len(e.Deltas)*2+3)
+	for i, delta := range e.Deltas {
+		field := formatBalanceField(delta)
+		pending.Deltas[i] = [2]string{field, strconv.FormatInt(delta.Balance, 10)}
+		args = append(args, field, delta.Balance)
 	}
 
-	if err := r.redis.Del(ctx, key).Err(); err != nil {
-		return fmt.Errorf("failed to delete user cache flag: %w", err)
+	entry, err := json.Marshal(pending)
+	if err != nil {
+		return false, err
 	}
 
-	r.log.Debug("removed user cache flag", zap.Any("uid", uid))
-	return nil
-}
-
-// UpdateBalance implements service.BalanceStore
-func (r BalanceRepository) UpdateBalance(ctx context.Context, uid user.ID, deltas ...loan.Balance) error {
-	key := formatBalanceKey(uid)
-	pipe := r.redis.Pipeline()
-
-	for _, delta := range deltas {
-		pipe.HIncrBy(ctx, key, user.IDToString(delta.UserID), delta.Balance)
+	keys := []string{
+		formatAppliedKey(e.UserID, e.Key()), formatCachedKey(e.UserID), formatBalanceKey(e.UserID),
+		formatPopulatingKey(e.UserID), formatPendingKey(e.UserID),
 	}
+	args = append([]interface{}{int(appliedEventTTL.Seconds()), e.Seq, entry}, args...)
 
-	if _, err := pipe.Exec(ctx); err != nil {
-		return fmt.Errorf("failed to commit balance delta for user %q to cache: %w", uid, err)
+	applied, err := applyEventScript.Run(ctx, r.redis, keys, args...).Int()
+	if err != nil {
+		return false, fmt.Errorf("failed to apply balance deltas for user %q: %w", user.IDToString(e.UserID), err)
 	}
 
-	return nil
+	return applied == 1, nil
 }
 
 // ClearBalance implements service.BalanceStore
@@ -148,22 +250,58 @@ func formatCachedKey(uid user.ID) string {
 	return keyPrefixCached + user.IDToString(uid)
 }
 
+func formatPopulatingKey(uid user.ID) string {
+	return keyPrefixPopulating + user.IDToString(uid)
+}
+
+func formatPendingKey(uid user.ID) string {
+	return keyPrefixPending + user.IDToString(uid)
+}
+
+func formatAppliedKey(uid user.ID, eventKey string) string {
+	return keyPrefixApplied + user.IDToString(uid) + ":" + eventKey
+}
+
+func formatBalanceField(b loan.Balance) string {
+	return b.Currency.String() + balanceFieldSeparator + user.IDToString(b.UserID)
+}
+
+func parseBalanceField(field string) (model.Currency, *user.ID, error) {
+	chunks := strings.SplitN(field, balanceFieldSeparator, 2)
+	if len(chunks) != 2 {
+		return "", nil, fmt.Errorf("malformed balance field %q", field)
+	}
+
+	cur := model.Currency(chunks[0])
+	if !cur.IsValid() {
+		return "", nil, fmt.Errorf("invalid currency %q in balance field %q", cur, field)
+	}
+
+	id, err := model.DecodeUUID(chunks[1])
+	if err != nil {
+		return "", nil, fmt.Errorf("failed to parse actor ID %q: %w", chunks[1], err)
+	}
+
+	return cur, id, nil
+}
+
 func allResultToBalance(items map[string]string) ([]loan.Balance, error) {
 	out := make([]loan.Balance, 0, len(items))
-	for actorID, balance := range items {
-		id, err := model.DecodeUUID(actorID)
+	for field, balance := range items {
+		cur, id, err := parseBalanceField(field)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse actor ID %q: %w", id, err)
+			return nil, err
 		}
 
 		balanceVal, err := strconv.ParseInt(balance, 10, 64)
 		if err != nil {
-			return nil, fmt.Errorf("failed to parse balance %q for actor ID %q: %w", balance, actorID, err)
+			return nil, fmt.Errorf("failed to parse balance %q for field %q: %w", balance, field, err)
 		}
 
 		out = append(out, loan.Balance{
-			UserID:  *id,
-			Balance: balanceVal,
+			UserID:   *id,
+			Currency: cur,
+			Balance:  balanceVal,
 		})
 	}
 
@@ -0,0 +1,115 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+// Token and user keys have distinct prefixes, so token can't point to a user key.
+const (
+	keyPrefixEmailChange     = "email_change:token:"
+	keyPrefixUserEmailChange = "email_change:user:"
+)
+
+// addEmailChangeScript saves email change and replaces previous pending change of the user.
+//
+// User key contains key of the current user email change.
+//
+// KEYS: email change key, user key.
+// ARGV: email change data, TTL in milliseconds.
+var addEmailChangeScript = redis.NewScript(`
+local prev = redis.call('GET', KEYS[2])
+if prev then
+	redis.call('DEL', prev)
+end
+redis.call('SET', KEYS[1], ARGV[1], 'PX', ARGV[2])
+redis.call('SET', KEYS[2], KEYS[1], 'PX', ARGV[2])
+return 1
+`)
+
+// removeEmailChangeScript removes email change and user key if it points to removed change.
+//
+// KEYS: email change key, user key.
+//
+// Returns number of removed changes.
+var removeEmailChangeScript = redis.NewScript(`
+local removed = redis.call('DEL', KEYS[1])
+if redis.call('GET', KEYS[2]) == KEYS[1] then
+	redis.call('DEL', KEYS[2])
+end
+return removed
+`)
+
+// EmailChangeRepository stores pending email changes in Redis.
+//
+// Email changes are removed by Redis on expiration.
+type EmailChangeRepository struct {
+	redis redis.Cmdable
+}
+
+// NewEmailChangeRepository is EmailChangeRepository constructor
+func NewEmailChangeRepository(r redis.Cmdable) *EmailChangeRepository {
+	return &EmailChangeRepository{redis: r}
+}
+
+// AddEmailChange implements service.EmailChangeStore
+func (r EmailChangeRepository) AddEmailChange(ctx context.Context, c user.EmailChange) error {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return fmt.Errorf("failed to marshal email change: %w", err)
+	}
+
+	keys := []string{emailChangeKey(c.Token), userEmailChangeKey(c.UserID)}
+	if err = addEmailChangeScript.Run(ctx, r.redis, keys, data, c.TTL().Milliseconds()).Err(); err != nil {
+		return fmt.Errorf("failed to save email change: %w", err)
+	}
+
+	return nil
+}
+
+// EmailChange implements service.EmailChangeStore
+func (r EmailChangeRepository) EmailChange(ctx context.Context, token string) (*user.EmailChange, error) {
+	data, err := r.redis.Get(ctx, emailChangeKey(token)).Bytes()
+	if err == redis.Nil {
+		return nil, service.ErrEmailChangeNotFound
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get email change: %w", err)
+	}
+
+	c := new(user.EmailChange)
+	if err = json.Unmarshal(data, c); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal email change: %w", err)
+	}
+
+	return c, nil
+}
+
+// RemoveEmailChange implements service.EmailChangeStore
+func (r EmailChangeRepository) RemoveEmailChange(ctx context.Context, c user.EmailChange) error {
+	keys := []string{emailChangeKey(c.Token), userEmailChangeKey(c.UserID)}
+	removed, err := removeEmailChangeScript.Run(ctx, r.redis, keys).Int64()
+	if err != nil {
+		return fmt.Errorf("failed to remove email change: %w", err)
+	}
+
+	if removed == 0 {
+		return service.ErrEmailChangeNotFound
+	}
+
+	return nil
+}
+
+func emailChangeKey(token string) string {
+	return keyPrefixEmailChange + token
+}
+
+func userEmailChangeKey(uid user.ID) string {
+	return keyPrefixUserEmailChange + user.IDToString(uid)
+}
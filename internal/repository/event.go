@@ -0,0 +1,138 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+const (
+	tableGroupEvents       = "group_events"
+	tableEventParticipants = "group_event_participants"
+
+	colEventID   = "event_id"
+	colCreatedBy = "created_by"
+)
+
+var eventCols = []string{colID, colGroupID, colName, colDescription, colCreatedBy, colCreatedAt}
+
+// EventRepository stores group events in database
+type EventRepository struct {
+	db *sqlx.DB
+}
+
+// NewEventRepository is EventRepository constructor
+func NewEventRepository(db *sqlx.DB) *EventRepository {
+	return &EventRepository{db: db}
+}
+
+// CreateEvent implements service.EventStore
+func (r EventRepository) CreateEvent(ctx context.Context, e user.Event) (*user.Event, error) {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	q, args, err := psql.Insert(tableGroupEvents).SetMap(map[string]interface{}{
+		colGroupID:     e.GroupID,
+		colName:        e.Name,
+		colDescription: e.Description,
+		colCreatedBy:   e.CreatedBy,
+	}).Suffix("RETURNING " + colID + ", " + colCreatedAt).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.QueryRowxContext(ctx, q, args...).Scan(&e.ID, &e.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	if len(e.Participants) > 0 {
+		qb := psql.Insert(tableEventParticipants).Columns(colEventID, colMemberID)
+		for _, uid := range e.Participants {
+			qb = qb.Values(e.ID, uid)
+		}
+
+		if _, err = qb.RunWith(tx).ExecContext(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	return &e, tx.Commit()
+}
+
+// EventByID implements service.EventStore
+func (r EventRepository) EventByID(ctx context.Context, gid user.GroupID, eid user.EventID) (*user.Event, error) {
+	events, err := r.selectEvents(ctx, squirrel.Eq{colID: eid, colGroupID: gid})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(events) == 0 {
+		return nil, service.ErrEventNotFound
+	}
+
+	return &events[0], nil
+}
+
+// EventsByGroup implements service.EventStore
+func (r EventRepository) EventsByGroup(ctx context.Context, gid user.GroupID) (user.Events, error) {
+	return r.selectEvents(ctx, squirrel.Eq{colGroupID: gid})
+}
+
+// selectEvents returns events matching condition with participants list.
+func (r EventRepository) selectEvents(ctx context.Context, cond squirrel.Eq) (user.Events, error) {
+	q, args, err := psql.Select(eventCols...).From(tableGroupEvents).
+		Where(cond).OrderBy(colCreatedAt + " DESC").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var events user.Events
+	err = conn(ctx, r.db).SelectContext(ctx, &events, q, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil || len(events) == 0 {
+		return events, err
+	}
+
+	ids := make([]user.EventID, 0, len(events))
+	for _, e := range events {
+		ids = append(ids, e.ID)
+	}
+
+	q, args, err = psql.Select(colEventID, colMemberID).From(tableEventParticipants).
+		Where(squirrel.Eq{colEventID: ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var participants []struct {
+		EventID  user.EventID `db:"event_id"`
+		MemberID user.ID      `db:"member_id"`
+	}
+	err = conn(ctx, r.db).SelectContext(ctx, &participants, q, args...)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	byEvent := make(map[[16]byte][]user.ID, len(events))
+	for _, p := range participants {
+		byEvent[p.EventID.Bytes] = append(byEvent[p.EventID.Bytes], p.MemberID)
+	}
+
+	for i := range events {
+		events[i].Participants = byEvent[events[i].ID.Bytes]
+	}
+
+	return events, nil
+}
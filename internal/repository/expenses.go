@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+const (
+	tableExpenses = "expenses"
+
+	colPayerID          = "payer_id"
+	colOriginalAmount   = "original_amount"
+	colOriginalCurrency = "original_currency"
+	colRate             = "rate"
+	colSpentAt          = "spent_at"
+)
+
+// expenseTotalsQuery returns total amount of group expenses per currency.
+//
+// If event ID is not null, only event expenses are included.
+const expenseTotalsQuery = "SELECT currency, SUM(amount)::bigint AS amount FROM expenses " +
+	"WHERE group_id = $1 AND ($2::uuid IS NULL OR event_id = $2) GROUP BY currency ORDER BY currency"
+
+// ExpenseRepository stores group expenses in database
+type ExpenseRepository struct {
+	db *sqlx.DB
+}
+
+// NewExpenseRepository is ExpenseRepository constructor
+func NewExpenseRepository(db *sqlx.DB) *ExpenseRepository {
+	return &ExpenseRepository{db: db}
+}
+
+// AddExpense implements service.ExpenseStorage
+func (r ExpenseRepository) AddExpense(ctx context.Context, exp loan.Expense) (*loan.ExpenseID, error) {
+	q, args, err := psql.Insert(tableExpenses).SetMap(map[string]interface{}{
+		colGroupID:          exp.GroupID,
+		colEventID:          exp.EventID,
+		colPayerID:          exp.PayerID,
+		colAmount:           exp.Amount,
+		colCurrency:         exp.Currency,
+		colOriginalAmount:   exp.OriginalAmount,
+		colOriginalCurrency: exp.OriginalCurrency,
+		colRate:             exp.Rate,
+		colSpentAt:          exp.SpentAt,
+	}).Suffix(returnIDSuffix).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	id := new(loan.ExpenseID)
+	err = conn(ctx, r.db).GetContext(ctx, id, q, args...)
+	return id, err
+}
+
+// ExpenseTotals implements service.ExpenseStorage
+func (r ExpenseRepository) ExpenseTotals(ctx context.Context, gid user.GroupID, eid *user.EventID) ([]loan.Total, error) {
+	var out []loan.Total
+	err := conn(ctx, r.db).SelectContext(ctx, &out, expenseTotalsQuery, gid, eid)
+	return out, err
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
+	"strings"
+	"time"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
@@ -15,15 +17,59 @@ const (
 	tableGroups       = "groups"
 	tableGroupMembers = "group_membership"
 
-	colGroupID  = "group_id"
-	colMemberID = "member_id"
-	colOwnerID  = "owner_id"
+	colGroupID    = "group_id"
+	colMemberID   = "member_id"
+	colOwnerID    = "owner_id"
+	colOwnerSince = "owner_since"
+	colVersion    = "version"
+	colRole       = "role"
+
+	colDescription = "description"
+	colSplitMode   = "split_mode"
+	colRounding    = "rounding"
+	colJoinPolicy  = "join_policy"
+	colArchivedAt  = "archived_at"
+	colDeletedAt   = "deleted_at"
+
+	colJoinedAt = "joined_at"
+	colLeftAt   = "left_at"
 )
 
 var (
-	groupCols = []string{colID, colName, colOwnerID}
+	groupCols = []string{
+		colID, colName, colOwnerID, colCurrency, colVersion, colDescription, colSplitMode, colRounding,
+		colJoinPolicy, colArchivedAt,
+	}
 )
 
+// groupsByUserQuery returns query which selects groups where user is owner or member.
+//
+// Soft-deleted groups are always excluded, archived groups are excluded unless requested.
+func groupsByUserQuery(includeArchived bool) string {
+	filter := func(alias string) string {
+		cond := alias + colDeletedAt + " IS NULL"
+		if !includeArchived {
+			cond += " AND " + alias + colArchivedAt + " IS NULL"
+		}
+		return cond
+	}
+
+	// squirrel doesn't support union selects still.
+	// "github.com/doug-martin/goqu/v9" supports it, but
+	// I don't want to bring a new lib just for 1 query.
+	return "(SELECT " + strings.Join(groupCols, ", ") + " FROM " + tableGroups +
+		" WHERE owner_id = $1 AND " + filter("") + ")" +
+		" UNION " +
+		"(SELECT " +
+		"g." + strings.Join(groupCols, ", g.") +
+		" FROM " + tableGroupMembers + " m" +
+		" INNER JOIN " + tableGroups + " g on " +
+		"m.group_id = g.id" +
+		" WHERE " +
+		"m.member_id = $1 AND m.left_at IS NULL AND " + filter("g.") +
+		")"
+}
+
 type GroupRepository struct {
 	db *sqlx.DB
 }
@@ -34,31 +80,37 @@ func NewGroupRepository(db *sqlx.DB) *GroupRepository {
 }
 
 // AddGroup implements service.GroupStore
-func (r GroupRepository) AddGroup(ctx context.Context, name string, owner user.ID) (*user.GroupID, error) {
+func (r GroupRepository) AddGroup(ctx context.Context, settings user.GroupSettings, owner user.ID) (*user.GroupID, error) {
 	q, args, err := psql.Insert(tableGroups).SetMap(map[string]interface{}{
-		colName:    name,
-		colOwnerID: owner,
+		colName:        settings.Name,
+		colOwnerID:     owner,
+		colCurrency:    settings.Currency,
+		colDescription: settings.Description,
+		colSplitMode:   settings.SplitMode,
+		colRounding:    settings.Rounding,
+		colJoinPolicy:  settings.JoinPolicy,
 	}).Suffix(returnIDSuffix).ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	retId := new(user.GroupID)
-	err = r.db.GetContext(ctx, retId, q, args...)
+	err = conn(ctx, r.db).GetContext(ctx, retId, q, args...)
 	return retId, err
 }
 
 // GetGroupOwner implements service.GroupStore
 func (r GroupRepository) GetGroupOwner(ctx context.Context, gid user.GroupID) (*user.ID, error) {
 	q, args, err := psql.Select(colOwnerID).From(tableGroups).Where(squirrel.Eq{
-		colID: gid,
+		colID:        gid,
+		colDeletedAt: nil,
 	}).Limit(1).ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	uid := new(user.ID)
-	err = r.db.GetContext(ctx, uid, q, args...)
+	err = conn(ctx, r.db).GetContext(ctx, uid, q, args...)
 	if err == sql.ErrNoRows {
 		return nil, service.ErrGroupNotFound
 	}
@@ -67,22 +119,53 @@ func (r GroupRepository) GetGroupOwner(ctx context.Context, gid user.GroupID) (*
 }
 
 // AddGroupUsers implements service.GroupManager
-func (r GroupRepository) AddGroupUsers(ctx context.Context, gid user.GroupID, uids []user.ID) error {
-	qb := psql.Insert(tableGroupMembers).Columns(colGroupID, colMemberID)
+func (r GroupRepository) AddGroupUsers(ctx context.Context, gid user.GroupID, uids []user.ID, role user.Role) error {
+	qb := psql.Insert(tableGroupMembers).Columns(colGroupID, colMemberID, colRole)
 	for _, uid := range uids {
-		qb = qb.Values(gid, uid)
+		qb = qb.Values(gid, uid, role)
 	}
 
-	_, err := qb.RunWith(r.db).ExecContext(ctx)
+	_, err := qb.RunWith(conn(ctx, r.db)).ExecContext(ctx)
 	return err
 }
 
+// GetMemberRole implements service.GroupManager
+func (r GroupRepository) GetMemberRole(ctx context.Context, gid user.GroupID, uid user.ID) (user.Role, error) {
+	const query = "SELECT CASE WHEN g.owner_id = $2 THEN 'owner' ELSE m.role END FROM " + tableGroups + " g" +
+		" LEFT JOIN " + tableGroupMembers + " m ON m.group_id = g.id AND m.member_id = $2 AND m.left_at IS NULL" +
+		" WHERE g.id = $1 AND g.deleted_at IS NULL"
+
+	var role sql.NullString
+	err := conn(ctx, r.db).GetContext(ctx, &role, query, gid, uid)
+	if err == sql.ErrNoRows {
+		return "", service.ErrGroupNotFound
+	}
+
+	return user.Role(role.String), err
+}
+
+// SetMemberRole implements service.GroupManager
+func (r GroupRepository) SetMemberRole(ctx context.Context, gid user.GroupID, uid user.ID, role user.Role) error {
+	result, err := psql.Update(tableGroupMembers).
+		Set(colRole, role).
+		Where(squirrel.Eq{colGroupID: gid, colMemberID: uid, colLeftAt: nil}).
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkAffectedRows(result)
+}
+
 // DeleteGroupUser implements service.GroupManager
+//
+// Membership is closed instead of removal to keep membership history.
 func (r GroupRepository) DeleteGroupUser(ctx context.Context, gid user.GroupID, uid user.ID) error {
-	rows, err := psql.Delete(tableGroupMembers).Where(squirrel.Eq{
+	rows, err := psql.Update(tableGroupMembers).Set(colLeftAt, squirrel.Expr("NOW()")).Where(squirrel.Eq{
 		colGroupID:  gid,
 		colMemberID: uid,
-	}).RunWith(r.db).ExecContext(ctx)
+		colLeftAt:   nil,
+	}).RunWith(conn(ctx, r.db)).ExecContext(ctx)
 	if err != nil {
 		return err
 	}
@@ -93,7 +176,7 @@ func (r GroupRepository) DeleteGroupUser(ctx context.Context, gid user.GroupID,
 // DeleteGroup implements service.GroupStore
 func (r GroupRepository) DeleteGroup(ctx context.Context, gid user.GroupID) error {
 	result, err := psql.Delete(tableGroups).Where(squirrel.Eq{colID: gid}).
-		RunWith(r.db).ExecContext(ctx)
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
 
 	if err != nil {
 		return err
@@ -102,16 +185,75 @@ func (r GroupRepository) DeleteGroup(ctx context.Context, gid user.GroupID) erro
 	return checkAffectedRows(result)
 }
 
+// TransferOwnership implements service.GroupStore
+func (r GroupRepository) TransferOwnership(ctx context.Context, gid user.GroupID, from, to user.ID, formerOwnerRole user.Role) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return err
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	// Owner is not stored in members table, so membership of new owner should be closed
+	// and former owner should be added instead.
+	result, err := psql.Update(tableGroupMembers).Set(colLeftAt, squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{colGroupID: gid, colMemberID: to, colLeftAt: nil}).
+		RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to remove new owner from members: %w", err)
+	}
+
+	if err = checkAffectedRows(result); err != nil {
+		return err
+	}
+
+	// Former owner membership starts with ownership period,
+	// so backdated expenses are still shared with former owner.
+	result, err = psql.Insert(tableGroupMembers).
+		Columns(colGroupID, colMemberID, colRole, colJoinedAt).
+		Select(psql.Select(colID, colOwnerID).Column("?", formerOwnerRole).Column(colOwnerSince).
+			From(tableGroups).Where(squirrel.Eq{colID: gid, colOwnerID: from})).
+		RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to add former owner to members: %w", err)
+	}
+
+	if err = checkAffectedRows(result); err != nil {
+		return err
+	}
+
+	_, err = psql.Update(tableGroups).SetMap(map[string]interface{}{
+		colOwnerID:    to,
+		colOwnerSince: squirrel.Expr("NOW()"),
+	}).Where(squirrel.Eq{colID: gid}).RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to change group owner: %w", err)
+	}
+
+	return tx.Commit()
+}
+
 // GroupByID implements service.GroupStore
 func (r GroupRepository) GroupByID(ctx context.Context, gid user.ID) (*user.Group, error) {
-	q, args, err := psql.Select(groupCols...).From(tableGroups).
-		Where(squirrel.Eq{colID: gid}).Limit(1).ToSql()
+	return r.groupByID(ctx, psql.Select(groupCols...).From(tableGroups).
+		Where(squirrel.Eq{colID: gid, colDeletedAt: nil}).Limit(1))
+}
+
+// GroupByIDForShare implements service.GroupStore
+func (r GroupRepository) GroupByIDForShare(ctx context.Context, gid user.GroupID) (*user.Group, error) {
+	return r.groupByID(ctx, psql.Select(groupCols...).From(tableGroups).
+		Where(squirrel.Eq{colID: gid, colDeletedAt: nil}).Limit(1).Suffix("FOR SHARE"))
+}
+
+func (r GroupRepository) groupByID(ctx context.Context, qb squirrel.SelectBuilder) (*user.Group, error) {
+	q, args, err := qb.ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	out := new(user.Group)
-	err = r.db.GetContext(ctx, out, q, args...)
+	err = conn(ctx, r.db).GetContext(ctx, out, q, args...)
 	if err == sql.ErrNoRows {
 		return nil, service.ErrGroupNotFound
 	}
@@ -119,26 +261,123 @@ func (r GroupRepository) GroupByID(ctx context.Context, gid user.ID) (*user.Grou
 	return out, err
 }
 
-// GroupsByUser implements service.GroupManager
-func (r GroupRepository) GroupsByUser(ctx context.Context, uid user.ID) (user.Groups, error) {
-	// TODO: replace squirrel with gogu everywhere somewhere in future
+// UpdateGroupSettings implements service.GroupStore
+func (r GroupRepository) UpdateGroupSettings(ctx context.Context, gid user.GroupID, settings user.GroupSettings) error {
+	q, args, err := psql.Update(tableGroups).SetMap(map[string]interface{}{
+		colName:        settings.Name,
+		colCurrency:    settings.Currency,
+		colDescription: settings.Description,
+		colSplitMode:   settings.SplitMode,
+		colRounding:    settings.Rounding,
+		colJoinPolicy:  settings.JoinPolicy,
+	}).Where(squirrel.Eq{colID: gid}).ToSql()
+	if err != nil {
+		return err
+	}
 
-	// squirrel doesn't support union selects still.
-	// "github.com/doug-martin/goqu/v9" supports it, but
-	// I don't want to bring a new lib just for 1 query.
-	const query = "(SELECT id, name, owner_id FROM " + tableGroups + " WHERE owner_id = $1)" +
-		" UNION " +
-		"(SELECT " +
-		"id, name, owner_id" +
-		" FROM " + tableGroupMembers + " m" +
-		" INNER JOIN " + tableGroups + " g on " +
-		"m.group_id = g.id" +
-		" WHERE " +
-		"m.member_id = $1" +
-		")"
+	result, err := conn(ctx, r.db).ExecContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+
+	return checkAffectedRows(result)
+}
+
+// SetGroupArchived implements service.GroupStore
+func (r GroupRepository) SetGroupArchived(ctx context.Context, gid user.GroupID, archived bool) error {
+	var archivedAt interface{}
+	if archived {
+		archivedAt = squirrel.Expr("NOW()")
+	}
+
+	result, err := psql.Update(tableGroups).Set(colArchivedAt, archivedAt).
+		Where(squirrel.Eq{colID: gid, colDeletedAt: nil}).
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkAffectedRows(result)
+}
+
+// SoftDeleteGroup implements service.GroupStore
+func (r GroupRepository) SoftDeleteGroup(ctx context.Context, gid user.GroupID) error {
+	result, err := psql.Update(tableGroups).Set(colDeletedAt, squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{colID: gid, colDeletedAt: nil}).
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	return checkAffectedRows(result)
+}
+
+// RestoreGroup implements service.GroupStore
+func (r GroupRepository) RestoreGroup(ctx context.Context, gid user.GroupID, owner user.ID, deletedAfter time.Time) error {
+	result, err := psql.Update(tableGroups).Set(colDeletedAt, nil).
+		Where(squirrel.Eq{colID: gid, colOwnerID: owner}).
+		Where(squirrel.GtOrEq{colDeletedAt: deletedAfter}).
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	n, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("cannot check affected rows: %w", err)
+	}
+
+	if n == 0 {
+		return service.ErrGroupNotFound
+	}
+
+	return nil
+}
+
+// BumpGroupVersion implements service.GroupStore
+func (r GroupRepository) BumpGroupVersion(ctx context.Context, gid user.GroupID, expect *int64) (int64, error) {
+	cond := squirrel.Eq{colID: gid}
+	if expect != nil {
+		cond[colVersion] = *expect
+	}
+
+	q, args, err := psql.Update(tableGroups).
+		Set(colVersion, squirrel.Expr(colVersion+" + 1")).
+		Where(cond).
+		Suffix(returningSuffix(colVersion)).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var version int64
+	err = conn(ctx, r.db).GetContext(ctx, &version, q, args...)
+	if err != sql.ErrNoRows {
+		return version, err
+	}
+
+	if expect == nil {
+		return 0, service.ErrGroupNotFound
+	}
+
+	// Check if group exists to distinguish version mismatch
+	var exists bool
+	err = conn(ctx, r.db).GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM groups WHERE id = $1)", gid)
+	if err != nil {
+		return 0, err
+	}
+
+	if !exists {
+		return 0, service.ErrGroupNotFound
+	}
 
+	return 0, service.ErrVersionMismatch
+}
+
+// GroupsByUser implements service.GroupManager
+func (r GroupRepository) GroupsByUser(ctx context.Context, uid user.ID, includeArchived bool) (user.Groups, error) {
+	// TODO: replace squirrel with gogu everywhere somewhere in future
 	var out user.Groups
-	err := r.db.SelectContext(ctx, &out, query, uid)
+	err := conn(ctx, r.db).SelectContext(ctx, &out, groupsByUserQuery(includeArchived), uid)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -146,13 +385,29 @@ func (r GroupRepository) GroupsByUser(ctx context.Context, uid user.ID) (user.Gr
 	return out, err
 }
 
-func (r GroupRepository) GroupMemberIDs(ctx context.Context, gid user.GroupID) ([]user.ID, error) {
-	const query = "(SELECT owner_id as uid from " + tableGroups + " WHERE id = $1)" +
+// GroupMemberIDs implements service.GroupManager
+func (r GroupRepository) GroupMemberIDs(ctx context.Context, gid user.GroupID, at *time.Time) ([]user.ID, error) {
+	const query = "(SELECT owner_id as uid from " + tableGroups + " WHERE id = $1 AND owner_since <= COALESCE($2, NOW()))" +
 		" UNION " +
-		"(SELECT member_id as uid FROM " + tableGroupMembers + " WHERE group_id = $1)"
+		"(SELECT member_id as uid FROM " + tableGroupMembers + " WHERE group_id = $1" +
+		" AND joined_at <= COALESCE($2, NOW()) AND (left_at IS NULL OR left_at > COALESCE($2, NOW())))"
 	var out []user.ID
-	err := r.db.SelectContext(ctx, &out, query, gid)
-	if err == sql.ErrNoRows {
+	if err := conn(ctx, r.db).SelectContext(ctx, &out, query, gid, at); err != nil {
+		return nil, err
+	}
+
+	if len(out) > 0 {
+		return out, nil
+	}
+
+	// Group might have no members at specified date, check if group exists
+	var exists bool
+	err := conn(ctx, r.db).GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM groups WHERE id = $1 AND deleted_at IS NULL)", gid)
+	if err != nil {
+		return nil, err
+	}
+
+	if !exists {
 		return nil, service.ErrGroupNotFound
 	}
 
@@ -162,13 +417,13 @@ func (r GroupRepository) GroupMemberIDs(ctx context.Context, gid user.GroupID) (
 // GroupsByAuthor implements service.GroupManager
 func (r GroupRepository) GroupsByAuthor(ctx context.Context, uid user.ID) (user.Groups, error) {
 	q, args, err := psql.Select(groupCols...).From(tableGroups).
-		Where(squirrel.Eq{colOwnerID: uid}).ToSql()
+		Where(squirrel.Eq{colOwnerID: uid, colDeletedAt: nil}).ToSql()
 	if err != nil {
 		return nil, err
 	}
 
 	var out user.Groups
-	err = r.db.SelectContext(ctx, &out, q, args...)
+	err = conn(ctx, r.db).SelectContext(ctx, &out, q, args...)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -177,16 +432,22 @@ func (r GroupRepository) GroupsByAuthor(ctx context.Context, uid user.ID) (user.
 }
 
 // GetGroupMembers implements service.GroupManager
-func (r GroupRepository) GetGroupMembers(ctx context.Context, gid user.GroupID) (user.Users, error) {
-	q, args, err := psql.Select(colID, colName, colEmail).
+func (r GroupRepository) GetGroupMembers(ctx context.Context, gid user.GroupID, history bool) (user.Members, error) {
+	cond := squirrel.Eq{colGroupID: gid}
+	if !history {
+		cond[colLeftAt] = nil
+	}
+
+	q, args, err := psql.Select(colID, colName, "COALESCE(email, '') AS email", colPlaceholder, colRole,
+		colJoinedAt, colLeftAt).
 		From(tableGroupMembers).InnerJoin(fmt.Sprintf("%s ON %s = %s", tableUsers, colID, colMemberID)).
-		Where(squirrel.Eq{colGroupID: gid}).ToSql()
+		Where(cond).OrderBy(colJoinedAt).ToSql()
 	if err != nil {
 		return nil, err
 	}
 
-	var out user.Users
-	err = r.db.SelectContext(ctx, &out, q, args...)
+	var out user.Members
+	err = conn(ctx, r.db).SelectContext(ctx, &out, q, args...)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
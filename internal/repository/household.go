@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+const (
+	tableHouseholds       = "group_households"
+	tableHouseholdMembers = "group_household_members"
+
+	colHouseholdID = "household_id"
+	colHolderID    = "holder_id"
+	colWeight      = "weight"
+)
+
+var householdCols = []string{colID, colGroupID, colName, colHolderID, colWeight, colCreatedBy, colCreatedAt}
+
+// HouseholdRepository stores group households in database
+type HouseholdRepository struct {
+	db *sqlx.DB
+}
+
+// NewHouseholdRepository is HouseholdRepository constructor
+func NewHouseholdRepository(db *sqlx.DB) *HouseholdRepository {
+	return &HouseholdRepository{db: db}
+}
+
+// CreateHousehold implements service.HouseholdStore
+func (r HouseholdRepository) CreateHousehold(ctx context.Context, h user.Household) (*user.Household, error) {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	q, args, err := psql.Insert(tableHouseholds).SetMap(map[string]interface{}{
+		colGroupID:   h.GroupID,
+		colName:      h.Name,
+		colHolderID:  h.HolderID,
+		colWeight:    h.Weight,
+		colCreatedBy: h.CreatedBy,
+	}).Suffix("RETURNING " + colID + ", " + colCreatedAt).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	if err = tx.QueryRowxContext(ctx, q, args...).Scan(&h.ID, &h.CreatedAt); err != nil {
+		return nil, err
+	}
+
+	qb := psql.Insert(tableHouseholdMembers).Columns(colHouseholdID, colGroupID, colMemberID)
+	for _, uid := range h.Members {
+		qb = qb.Values(h.ID, h.GroupID, uid)
+	}
+
+	_, err = qb.RunWith(tx).ExecContext(ctx)
+	if isUniqueViolation(err) {
+		return nil, service.ErrMemberInHousehold
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return &h, tx.Commit()
+}
+
+// DeleteHousehold implements service.HouseholdStore
+func (r HouseholdRepository) DeleteHousehold(ctx context.Context, gid user.GroupID, hid user.HouseholdID) error {
+	result, err := psql.Delete(tableHouseholds).Where(squirrel.Eq{colID: hid, colGroupID: gid}).
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if affected == 0 {
+		return service.ErrHouseholdNotFound
+	}
+
+	return nil
+}
+
+// HouseholdByID implements service.HouseholdStore
+func (r HouseholdRepository) HouseholdByID(ctx context.Context, gid user.GroupID, hid user.HouseholdID) (*user.Household, error) {
+	households, err := r.selectHouseholds(ctx, squirrel.Eq{colID: hid, colGroupID: gid})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(households) == 0 {
+		return nil, service.ErrHouseholdNotFound
+	}
+
+	return &households[0], nil
+}
+
+// HouseholdsByGroup implements service.HouseholdStore
+func (r HouseholdRepository) HouseholdsByGroup(ctx context.Context, gid user.GroupID) (user.Households, error) {
+	return r.selectHouseholds(ctx, squirrel.Eq{colGroupID: gid})
+}
+
+// selectHouseholds returns households matching condition with members list.
+func (r HouseholdRepository) selectHouseholds(ctx context.Context, cond squirrel.Eq) (user.Households, error) {
+	q, args, err := psql.Select(householdCols...).From(tableHouseholds).
+		Where(cond).OrderBy(colCreatedAt).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var households user.Households
+	err = conn(ctx, r.db).SelectContext(ctx, &households, q, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	if err != nil || len(households) == 0 {
+		return households, err
+	}
+
+	ids := make([]user.HouseholdID, 0, len(households))
+	for _, h := range households {
+		ids = append(ids, h.ID)
+	}
+
+	q, args, err = psql.Select(colHouseholdID, colMemberID).From(tableHouseholdMembers).
+		Where(squirrel.Eq{colHouseholdID: ids}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var members []struct {
+		HouseholdID user.HouseholdID `db:"household_id"`
+		MemberID    user.ID          `db:"member_id"`
+	}
+	err = conn(ctx, r.db).SelectContext(ctx, &members, q, args...)
+	if err != nil && err != sql.ErrNoRows {
+		return nil, err
+	}
+
+	byHousehold := make(map[[16]byte][]user.ID, len(households))
+	for _, m := range members {
+		byHousehold[m.HouseholdID.Bytes] = append(byHousehold[m.HouseholdID.Bytes], m.MemberID)
+	}
+
+	for i := range households {
+		households[i].Members = byHousehold[households[i].ID.Bytes]
+	}
+
+	return households, nil
+}
@@ -0,0 +1,228 @@
+package repository
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+const (
+	keyPrefixInvite       = "invite:"
+	keyPrefixGroupInvites = "invites:"
+
+	inviteFieldData = "data"
+	inviteFieldUses = "uses"
+)
+
+// createInviteScript saves invite and adds it to group invites list.
+//
+// Group invites list lives until the last group invite expires.
+//
+// KEYS: invite hash, group invites set.
+// ARGV: invite data, max uses, invite TTL in milliseconds, invite token.
+var createInviteScript = redis.NewScript(`
+redis.call('HSET', KEYS[1], 'data', ARGV[1], 'uses', 0, 'max_uses', ARGV[2])
+redis.call('PEXPIRE', KEYS[1], ARGV[3])
+redis.call('SADD', KEYS[2], ARGV[4])
+if redis.call('PTTL', KEYS[2]) < tonumber(ARGV[3]) then
+	redis.call('PEXPIRE', KEYS[2], ARGV[3])
+end
+return 1
+`)
+
+// useInviteScript increments invite uses counter if usage limit is not reached.
+//
+// KEYS: invite hash.
+//
+// Returns invite data on success, 0 if invite doesn't exist or -1 if usage limit is reached.
+var useInviteScript = redis.NewScript(`
+local data = redis.call('HGET', KEYS[1], 'data')
+if not data then
+	return 0
+end
+
+local maxUses = tonumber(redis.call('HGET', KEYS[1], 'max_uses')) or 0
+local uses = tonumber(redis.call('HGET', KEYS[1], 'uses')) or 0
+if maxUses > 0 and uses >= maxUses then
+	return -1
+end
+
+redis.call('HINCRBY', KEYS[1], 'uses', 1)
+return data
+`)
+
+// releaseInviteScript decrements invite uses counter if invite still exists.
+//
+// KEYS: invite hash.
+var releaseInviteScript = redis.NewScript(`
+if redis.call('EXISTS', KEYS[1]) == 1 then
+	redis.call('HINCRBY', KEYS[1], 'uses', -1)
+end
+return 1
+`)
+
+// InviteRepository stores group invites in Redis.
+//
+// Invites are removed by Redis on expiration.
+type InviteRepository struct {
+	redis redis.Cmdable
+}
+
+// NewInviteRepository is InviteRepository constructor
+func NewInviteRepository(r redis.Cmdable) *InviteRepository {
+	return &InviteRepository{redis: r}
+}
+
+// AddInvite implements service.InviteStore
+func (r InviteRepository) AddInvite(ctx context.Context, inv user.Invite) error {
+	data, err := json.Marshal(inv)
+	if err != nil {
+		return fmt.Errorf("failed to marshal invite: %w", err)
+	}
+
+	keys := []string{inviteKey(inv.Token), groupInvitesKey(inv.GroupID)}
+	args := []interface{}{data, inv.MaxUses, inv.TTL().Milliseconds(), inv.Token}
+	if err = createInviteScript.Run(ctx, r.redis, keys, args...).Err(); err != nil {
+		return fmt.Errorf("failed to save invite: %w", err)
+	}
+
+	return nil
+}
+
+// GetInvite implements service.InviteStore
+func (r InviteRepository) GetInvite(ctx context.Context, token string) (*user.Invite, error) {
+	fields, err := r.redis.HGetAll(ctx, inviteKey(token)).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get invite: %w", err)
+	}
+
+	if len(fields) == 0 {
+		return nil, service.ErrInviteNotFound
+	}
+
+	return parseInvite(fields)
+}
+
+// GroupInvites implements service.InviteStore
+func (r InviteRepository) GroupInvites(ctx context.Context, gid user.GroupID) (user.Invites, error) {
+	listKey := groupInvitesKey(gid)
+	tokens, err := r.redis.SMembers(ctx, listKey).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group invites: %w", err)
+	}
+
+	if len(tokens) == 0 {
+		return nil, nil
+	}
+
+	pipe := r.redis.Pipeline()
+	cmds := make([]*redis.StringStringMapCmd, len(tokens))
+	for i, token := range tokens {
+		cmds[i] = pipe.HGetAll(ctx, inviteKey(token))
+	}
+
+	if _, err = pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get group invites: %w", err)
+	}
+
+	out := make(user.Invites, 0, len(tokens))
+	expired := make([]interface{}, 0, len(tokens))
+	for i, cmd := range cmds {
+		fields := cmd.Val()
+		if len(fields) == 0 {
+			expired = append(expired, tokens[i])
+			continue
+		}
+
+		inv, err := parseInvite(fields)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, *inv)
+	}
+
+	if len(expired) > 0 {
+		// Expired invites are removed from list lazily.
+		if err = r.redis.SRem(ctx, listKey, expired...).Err(); err != nil {
+			return nil, fmt.Errorf("failed to remove expired invites: %w", err)
+		}
+	}
+
+	return out, nil
+}
+
+// UseInvite implements service.InviteStore
+func (r InviteRepository) UseInvite(ctx context.Context, token string) (*user.Invite, error) {
+	result, err := useInviteScript.Run(ctx, r.redis, []string{inviteKey(token)}).Result()
+	if err != nil {
+		return nil, fmt.Errorf("failed to use invite: %w", err)
+	}
+
+	switch v := result.(type) {
+	case string:
+		inv := new(user.Invite)
+		if err = json.Unmarshal([]byte(v), inv); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal invite: %w", err)
+		}
+
+		return inv, nil
+	case int64:
+		if v < 0 {
+			return nil, service.ErrInviteExhausted
+		}
+
+		return nil, service.ErrInviteNotFound
+	default:
+		return nil, fmt.Errorf("unexpected invite script result: %v", result)
+	}
+}
+
+// ReleaseInvite implements service.InviteStore
+func (r InviteRepository) ReleaseInvite(ctx context.Context, token string) error {
+	return releaseInviteScript.Run(ctx, r.redis, []string{inviteKey(token)}).Err()
+}
+
+// RemoveInvite implements service.InviteStore
+func (r InviteRepository) RemoveInvite(ctx context.Context, gid user.GroupID, token string) error {
+	pipe := r.redis.TxPipeline()
+	del := pipe.Del(ctx, inviteKey(token))
+	pipe.SRem(ctx, groupInvitesKey(gid), token)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to remove invite: %w", err)
+	}
+
+	if del.Val() == 0 {
+		return service.ErrInviteNotFound
+	}
+
+	return nil
+}
+
+func parseInvite(fields map[string]string) (*user.Invite, error) {
+	inv := new(user.Invite)
+	if err := json.Unmarshal([]byte(fields[inviteFieldData]), inv); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal invite: %w", err)
+	}
+
+	uses, err := strconv.Atoi(fields[inviteFieldUses])
+	if err != nil {
+		return nil, fmt.Errorf("invalid invite uses counter: %w", err)
+	}
+
+	inv.Uses = uses
+	return inv, nil
+}
+
+func inviteKey(token string) string {
+	return keyPrefixInvite + token
+}
+
+func groupInvitesKey(gid user.GroupID) string {
+	return keyPrefixGroupInvites + user.IDToString(gid)
+}
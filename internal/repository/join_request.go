@@ -0,0 +1,111 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+const (
+	tableJoinRequests = "group_join_requests"
+
+	colMessage   = "message"
+	colStatus    = "status"
+	colDecidedBy = "decided_by"
+	colDecidedAt = "decided_at"
+)
+
+var joinRequestCols = []string{
+	colID, colGroupID, colUserID, colMessage, colStatus, colCreatedAt, colDecidedBy, colDecidedAt,
+}
+
+// JoinRequestRepository stores group join requests in database
+type JoinRequestRepository struct {
+	db *sqlx.DB
+}
+
+// NewJoinRequestRepository is JoinRequestRepository constructor
+func NewJoinRequestRepository(db *sqlx.DB) *JoinRequestRepository {
+	return &JoinRequestRepository{db: db}
+}
+
+// AddJoinRequest implements service.JoinRequestStore
+func (r JoinRequestRepository) AddJoinRequest(ctx context.Context, gid user.GroupID, uid user.ID, msg string) (*user.JoinRequest, error) {
+	q, args, err := psql.Insert(tableJoinRequests).SetMap(map[string]interface{}{
+		colGroupID: gid,
+		colUserID:  uid,
+		colMessage: msg,
+	}).Suffix(returningSuffix(joinRequestColumns())).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	out := new(user.JoinRequest)
+	err = conn(ctx, r.db).GetContext(ctx, out, q, args...)
+	if isUniqueViolation(err) {
+		return nil, service.ErrJoinRequestExists
+	}
+
+	return out, err
+}
+
+// GroupJoinRequests implements service.JoinRequestStore
+func (r JoinRequestRepository) GroupJoinRequests(ctx context.Context, gid user.GroupID, status user.JoinRequestStatus) (user.JoinRequests, error) {
+	cond := squirrel.Eq{colGroupID: gid}
+	if status != "" {
+		cond[colStatus] = status
+	}
+
+	return r.selectJoinRequests(ctx, cond)
+}
+
+// UserJoinRequests implements service.JoinRequestStore
+func (r JoinRequestRepository) UserJoinRequests(ctx context.Context, uid user.ID) (user.JoinRequests, error) {
+	return r.selectJoinRequests(ctx, squirrel.Eq{colUserID: uid})
+}
+
+// DecideJoinRequest implements service.JoinRequestStore
+func (r JoinRequestRepository) DecideJoinRequest(ctx context.Context, gid user.GroupID, rid user.JoinRequestID, actor user.ID, status user.JoinRequestStatus) (*user.JoinRequest, error) {
+	q, args, err := psql.Update(tableJoinRequests).
+		Set(colStatus, status).
+		Set(colDecidedBy, actor).
+		Set(colDecidedAt, squirrel.Expr("NOW()")).
+		Where(squirrel.Eq{colID: rid, colGroupID: gid, colStatus: user.JoinRequestPending}).
+		Suffix(returningSuffix(joinRequestColumns())).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	out := new(user.JoinRequest)
+	err = conn(ctx, r.db).GetContext(ctx, out, q, args...)
+	if err == sql.ErrNoRows {
+		return nil, service.ErrJoinRequestNotFound
+	}
+
+	return out, err
+}
+
+func (r JoinRequestRepository) selectJoinRequests(ctx context.Context, cond squirrel.Sqlizer) (user.JoinRequests, error) {
+	q, args, err := psql.Select(joinRequestCols...).From(tableJoinRequests).
+		Where(cond).OrderBy(colCreatedAt + " DESC").ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var out user.JoinRequests
+	err = conn(ctx, r.db).SelectContext(ctx, &out, q, args...)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+
+	return out, err
+}
+
+func joinRequestColumns() string {
+	return strings.Join(joinRequestCols, ", ")
+}
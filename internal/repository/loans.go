@@ -2,23 +2,66 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"fmt"
 
+	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model/balance"
 	"github.com/x1unix/sbda-ledger/internal/model/loan"
 	"github.com/x1unix/sbda-ledger/internal/model/user"
 )
 
 const (
-	tableLoans = "loans"
+	tableTransactions = "journal_transactions"
+	tablePostings     = "journal_postings"
 
-	colLenderID = "lender_id"
-	colDebtorID = "debtor_id"
-	colAmount   = "amount"
+	colKind           = "kind"
+	colTransactionID  = "transaction_id"
+	colUserID         = "user_id"
+	colCounterpartyID = "counterparty_id"
+	colAmount         = "amount"
+	colCurrency       = "currency"
+	colExpense        = "expense_id"
+	colCreatedAt      = "created_at"
+	colSeq            = "seq"
+	colPrevHash       = "prev_hash"
+	colHash           = "hash"
+
+	// journalLockKey is advisory lock key which serializes appends to transactions chain.
+	journalLockKey = 0x6a6f75726e616c
 )
 
-// LoansRepository stores loan log in database
+const userBalanceQuery = "SELECT counterparty_id AS user_id, currency, SUM(amount)::bigint AS balance " +
+	"FROM journal_postings WHERE user_id = $1 GROUP BY counterparty_id, currency"
+
+// groupUserBalanceQuery returns non-zero user balance built from group transactions.
+const groupUserBalanceQuery = "SELECT p.counterparty_id AS user_id, p.currency, SUM(p.amount)::bigint AS balance " +
+	"FROM journal_postings p JOIN journal_transactions t ON t.id = p.transaction_id " +
+	"WHERE t.group_id = $1 AND p.user_id = $2 GROUP BY p.counterparty_id, p.currency " +
+	"HAVING SUM(p.amount) <> 0 ORDER BY p.currency, p.counterparty_id"
+
+// userGroupBalancesQuery returns non-zero user balance for each group, including balance outside groups.
+const userGroupBalancesQuery = "SELECT t.group_id, p.counterparty_id AS user_id, p.currency, SUM(p.amount)::bigint AS balance " +
+	"FROM journal_postings p JOIN journal_transactions t ON t.id = p.transaction_id " +
+	"WHERE p.user_id = $1 GROUP BY t.group_id, p.counterparty_id, p.currency " +
+	"HAVING SUM(p.amount) <> 0 ORDER BY t.group_id NULLS FIRST, p.currency, p.counterparty_id"
+
+// groupDebtsQuery returns outstanding debts between users built from group transactions.
+//
+// If event ID is not null, only event transactions are included.
+const groupDebtsQuery = "SELECT p.user_id AS lender_id, p.counterparty_id AS debtor_id, p.currency, SUM(p.amount)::bigint AS amount " +
+	"FROM journal_postings p JOIN journal_transactions t ON t.id = p.transaction_id " +
+	"WHERE t.group_id = $1 AND ($2::uuid IS NULL OR t.event_id = $2) GROUP BY p.user_id, p.counterparty_id, p.currency " +
+	"HAVING SUM(p.amount) > 0 ORDER BY p.currency, p.user_id, p.counterparty_id"
+
+// LoansRepository stores loans in double-entry journal in database
 type LoansRepository struct {
 	db *sqlx.DB
+
+	// materialized enables update of materialized balance (see PairBalanceRepository)
+	// instead of sending balance cache update events to outbox.
+	materialized bool
 }
 
 // NewLoansRepository is LoansRepository constructor
@@ -26,25 +69,211 @@ func NewLoansRepository(db *sqlx.DB) *LoansRepository {
 	return &LoansRepository{db: db}
 }
 
-// AddLoans implements service.LoansStorage
-func (r LoansRepository) AddLoans(ctx context.Context, records []loan.Loan) error {
-	q := psql.Insert(tableLoans).Columns(colLenderID, colDebtorID, colAmount)
-	for _, record := range records {
-		q = q.Values(record.LenderID, record.DebtorID, record.Amount)
+// NewMaterializedLoansRepository returns LoansRepository which updates
+// users balance in PairBalanceRepository in the same transaction as journal postings.
+func NewMaterializedLoansRepository(db *sqlx.DB) *LoansRepository {
+	return &LoansRepository{db: db, materialized: true}
+}
+
+// AddTransaction implements service.LoansStorage
+//
+// Transaction is appended to the end of transactions chain.
+// Transaction ID, creation date and chain fields are populated on success.
+// Balance cache update events are saved to outbox in the same database transaction,
+// or materialized balance is updated if repository is materialized.
+func (r LoansRepository) AddTransaction(ctx context.Context, t *loan.Transaction) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return err
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	// Lock is held until end of transaction, so chain head won't change until commit.
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", journalLockKey); err != nil {
+		return fmt.Errorf("failed to acquire journal lock: %w", err)
+	}
+
+	if err = r.linkTransaction(ctx, tx, t); err != nil {
+		return err
+	}
+
+	q, args, err := psql.Insert(tableTransactions).SetMap(map[string]interface{}{
+		colID:        t.ID,
+		colKind:      t.Kind,
+		colExpense:   t.ExpenseID,
+		colGroupID:   t.GroupID,
+		colEventID:   t.EventID,
+		colCreatedAt: t.CreatedAt,
+		colSeq:       t.Seq,
+		colPrevHash:  t.PrevHash,
+		colHash:      t.Hash,
+	}).ToSql()
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, q, args...); err != nil {
+		return fmt.Errorf("failed to create journal transaction: %w", err)
+	}
+
+	iq := psql.Insert(tablePostings).Columns(colTransactionID, colUserID, colCounterpartyID, colCurrency, colAmount)
+	for _, p := range t.Postings {
+		iq = iq.Values(t.ID, p.UserID, p.CounterpartyID, p.Currency, p.Amount)
+	}
+
+	if _, err = iq.RunWith(tx).ExecContext(ctx); err != nil {
+		return fmt.Errorf("failed to save journal postings: %w", err)
+	}
+
+	if r.materialized {
+		if err = applyPairBalances(ctx, tx, t); err != nil {
+			return fmt.Errorf("failed to update users balance: %w", err)
+		}
+
+		// Postings balance is checked by deferred constraint on commit.
+		return tx.Commit()
+	}
+
+	// Cache update events are committed together with postings.
+	if err = addEvents(ctx, tx, balance.EventsFromTransaction(*t)); err != nil {
+		return fmt.Errorf("failed to save balance update events: %w", err)
+	}
+
+	// Postings balance is checked by deferred constraint on commit.
+	return tx.Commit()
+}
+
+// linkTransaction assigns ID, creation date and chain position to a transaction
+// and calculates transaction hash.
+func (r LoansRepository) linkTransaction(ctx context.Context, tx executor, t *loan.Transaction) error {
+	row := tx.QueryRowxContext(ctx, "SELECT uuid_generate_v4(), NOW()")
+	if err := row.Scan(&t.ID, &t.CreatedAt); err != nil {
+		return fmt.Errorf("failed to generate transaction ID: %w", err)
+	}
+
+	var head struct {
+		Seq  int64     `db:"seq"`
+		Hash loan.Hash `db:"hash"`
 	}
 
-	_, err := q.RunWith(r.db).ExecContext(ctx)
-	return err
+	err := tx.GetContext(ctx, &head, "SELECT seq, hash FROM journal_transactions ORDER BY seq DESC LIMIT 1")
+	switch err {
+	case nil:
+		t.Seq, t.PrevHash = head.Seq+1, head.Hash
+	case sql.ErrNoRows:
+		t.Seq, t.PrevHash = 1, loan.GenesisHash
+	default:
+		return fmt.Errorf("failed to get journal chain head: %w", err)
+	}
+
+	t.Hash = t.ComputeHash()
+	return nil
+}
+
+// TransactionsAfter implements service.ChainStorage
+func (r LoansRepository) TransactionsAfter(ctx context.Context, seq int64, limit int) ([]loan.Transaction, error) {
+	q, args, err := psql.Select(colID, colKind, colExpense, colGroupID, colEventID, colCreatedAt, colSeq, colPrevHash, colHash).
+		From(tableTransactions).
+		Where(squirrel.Gt{colSeq: seq}).
+		OrderBy(colSeq).
+		Limit(uint64(limit)).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var txs []loan.Transaction
+	if err = conn(ctx, r.db).SelectContext(ctx, &txs, q, args...); err != nil {
+		return nil, err
+	}
+
+	if len(txs) == 0 {
+		return nil, nil
+	}
+
+	ids := make([]loan.TransactionID, len(txs))
+	index := make(map[loan.TransactionID]*loan.Transaction, len(txs))
+	for i := range txs {
+		ids[i] = txs[i].ID
+		index[txs[i].ID] = &txs[i]
+	}
+
+	q, args, err = psql.Select(colTransactionID, colUserID, colCounterpartyID, colCurrency, colAmount).
+		From(tablePostings).
+		Where(squirrel.Eq{colTransactionID: ids}).
+		OrderBy(colID).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var postings []struct {
+		loan.Posting
+		TransactionID loan.TransactionID `db:"transaction_id"`
+	}
+	if err = conn(ctx, r.db).SelectContext(ctx, &postings, q, args...); err != nil {
+		return nil, fmt.Errorf("failed to get journal postings: %w", err)
+	}
+
+	for _, p := range postings {
+		if t, ok := index[p.TransactionID]; ok {
+			t.Postings = append(t.Postings, p.Posting)
+		}
+	}
+
+	return txs, nil
 }
 
 // GetUserBalance implements service.LoansStorage
 func (r LoansRepository) GetUserBalance(ctx context.Context, uid user.ID) ([]loan.Balance, error) {
 	var out []loan.Balance
-	const query = "SELECT user_id, SUM(amount) as balance FROM (" +
-		"SELECT debtor_id AS user_id, amount FROM loans WHERE lender_id = $1" +
-		" UNION ALL " +
-		"SELECT lender_id AS user_id, amount * -1 FROM loans WHERE debtor_id = $1" +
-		") balance GROUP BY user_id"
-	err := r.db.SelectContext(ctx, &out, query, uid)
+	err := conn(ctx, r.db).SelectContext(ctx, &out, userBalanceQuery, uid)
 	return out, err
 }
+
+// GroupUserBalance implements service.GroupBalanceStorage
+func (r LoansRepository) GroupUserBalance(ctx context.Context, gid user.GroupID, uid user.ID) ([]loan.Balance, error) {
+	var out []loan.Balance
+	err := conn(ctx, r.db).SelectContext(ctx, &out, groupUserBalanceQuery, gid, uid)
+	return out, err
+}
+
+// GroupDebts implements service.GroupBalanceStorage
+func (r LoansRepository) GroupDebts(ctx context.Context, gid user.GroupID, eid *user.EventID) ([]loan.Debt, error) {
+	var out []loan.Debt
+	err := conn(ctx, r.db).SelectContext(ctx, &out, groupDebtsQuery, gid, eid)
+	return out, err
+}
+
+// UserGroupBalances implements service.PlaceholderBalanceStorage
+func (r LoansRepository) UserGroupBalances(ctx context.Context, uid user.ID) ([]loan.GroupBalance, error) {
+	var out []loan.GroupBalance
+	err := conn(ctx, r.db).SelectContext(ctx, &out, userGroupBalancesQuery, uid)
+	return out, err
+}
+
+// UserBalanceSnapshot implements service.LoansStorage
+func (r LoansRepository) UserBalanceSnapshot(ctx context.Context, uid user.ID) ([]loan.Balance, int64, error) {
+	// Both queries should see the same journal state.
+	tx, err := beginTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, 0, err
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	// Transactions are appended under lock, so all transactions
+	// up to the last visible position are visible in snapshot.
+	var seq int64
+	if err = tx.GetContext(ctx, &seq, "SELECT COALESCE(MAX(seq), 0) FROM journal_transactions"); err != nil {
+		return nil, 0, fmt.Errorf("failed to get journal position: %w", err)
+	}
+
+	var out []loan.Balance
+	if err = tx.SelectContext(ctx, &out, userBalanceQuery, uid); err != nil {
+		return nil, 0, err
+	}
+
+	return out, seq, tx.Commit()
+}
@@ -0,0 +1,77 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model/balance"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+)
+
+const (
+	tableOutbox = "balance_outbox"
+
+	colDeltas   = "deltas"
+	colAttempts = "attempts"
+)
+
+// OutboxRepository stores balance cache update events
+type OutboxRepository struct {
+	db *sqlx.DB
+}
+
+// NewOutboxRepository is OutboxRepository constructor
+func NewOutboxRepository(db *sqlx.DB) *OutboxRepository {
+	return &OutboxRepository{db: db}
+}
+
+// PendingEvents implements service.OutboxStorage
+func (r OutboxRepository) PendingEvents(ctx context.Context, before time.Time, limit int) ([]balance.Event, error) {
+	q, args, err := psql.Select(colTransactionID, colSeq, colUserID, colDeltas, colAttempts, colCreatedAt).
+		From(tableOutbox).
+		Where(squirrel.Lt{colCreatedAt: before}).
+		OrderBy(colCreatedAt).
+		Limit(uint64(limit)).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []balance.Event
+	err = conn(ctx, r.db).SelectContext(ctx, &out, q, args...)
+	return out, err
+}
+
+// DeleteEvent implements service.OutboxStorage
+func (r OutboxRepository) DeleteEvent(ctx context.Context, tid loan.TransactionID, uid user.ID) error {
+	_, err := psql.Delete(tableOutbox).
+		Where(squirrel.Eq{colTransactionID: tid, colUserID: uid}).
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	return err
+}
+
+// MarkEventFailed implements service.OutboxStorage
+func (r OutboxRepository) MarkEventFailed(ctx context.Context, tid loan.TransactionID, uid user.ID) error {
+	_, err := psql.Update(tableOutbox).
+		Set(colAttempts, squirrel.Expr(colAttempts+" + 1")).
+		Where(squirrel.Eq{colTransactionID: tid, colUserID: uid}).
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	return err
+}
+
+// addEvents saves balance cache update events as part of database transaction.
+func addEvents(ctx context.Context, tx executor, events []balance.Event) error {
+	if len(events) == 0 {
+		return nil
+	}
+
+	q := psql.Insert(tableOutbox).Columns(colTransactionID, colSeq, colUserID, colDeltas)
+	for _, e := range events {
+		q = q.Values(e.TransactionID, e.Seq, e.UserID, e.Deltas)
+	}
+
+	_, err := q.RunWith(tx).ExecContext(ctx)
+	return err
+}
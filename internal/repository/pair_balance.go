@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model/balance"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+const (
+	tablePairBalances     = "pair_balances"
+	tablePairBalanceUsers = "pair_balance_users"
+
+	colBalance = "balance"
+)
+
+// applyPairBalancesQuery adds postings of journal transaction to balance of materialized users.
+const applyPairBalancesQuery = "INSERT INTO pair_balances (user_id, currency, counterparty_id, balance) " +
+	"SELECT p.user_id, p.currency, p.counterparty_id, SUM(p.amount) FROM journal_postings p " +
+	"JOIN pair_balance_users u ON u.user_id = p.user_id WHERE p.transaction_id = $1 " +
+	"GROUP BY p.user_id, p.currency, p.counterparty_id " +
+	"ON CONFLICT (user_id, currency, counterparty_id) DO UPDATE SET balance = pair_balances.balance + EXCLUDED.balance"
+
+// PairBalanceRepository keeps materialized users balance in database.
+//
+// Unlike BalanceRepository, balance is updated in the same transaction
+// as journal postings by LoansRepository (see NewMaterializedLoansRepository),
+// so balance is always consistent with journal.
+type PairBalanceRepository struct {
+	db *sqlx.DB
+}
+
+// NewPairBalanceRepository is PairBalanceRepository constructor
+func NewPairBalanceRepository(db *sqlx.DB) *PairBalanceRepository {
+	return &PairBalanceRepository{db: db}
+}
+
+// GetBalance implements service.BalanceStore
+func (r PairBalanceRepository) GetBalance(ctx context.Context, uid user.ID) ([]loan.Balance, error) {
+	// Both queries should see the same state.
+	tx, err := beginTx(ctx, r.db, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	var exists bool
+	err = tx.GetContext(ctx, &exists, "SELECT EXISTS(SELECT 1 FROM pair_balance_users WHERE user_id = $1)", uid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check if user balance is materialized: %w", err)
+	}
+
+	if !exists {
+		return nil, service.ErrNoBalance
+	}
+
+	q, args, err := psql.Select(colCounterpartyID+" AS "+colUserID, colCurrency, colBalance).
+		From(tablePairBalances).
+		Where(squirrel.Eq{colUserID: uid}).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	var out []loan.Balance
+	if err = tx.SelectContext(ctx, &out, q, args...); err != nil {
+		return nil, err
+	}
+
+	return out, tx.Commit()
+}
+
+// BeginPopulation implements service.BalanceStore
+//
+// Balance is populated under journal lock, so population mark is not required.
+func (r PairBalanceRepository) BeginPopulation(_ context.Context, _ user.ID) error {
+	return nil
+}
+
+// CompletePopulation implements service.BalanceStore
+//
+// Passed balance snapshot is ignored, balance is calculated from journal under journal lock,
+// since transactions committed after snapshot are not applied to not materialized balance.
+func (r PairBalanceRepository) CompletePopulation(ctx context.Context, uid user.ID, _ int64, _ ...loan.Balance) (bool, error) {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return false, err
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	// Journal can't be changed until balance is populated.
+	if _, err = tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)", journalLockKey); err != nil {
+		return false, fmt.Errorf("failed to acquire journal lock: %w", err)
+	}
+
+	res, err := tx.ExecContext(ctx, "INSERT INTO pair_balance_users (user_id, seq) "+
+		"SELECT $1, COALESCE(MAX(seq), 0) FROM journal_transactions ON CONFLICT DO NOTHING", uid)
+	if err != nil {
+		return false, fmt.Errorf("failed to mark user balance as materialized: %w", err)
+	}
+
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		// Balance is already materialized
+		return false, err
+	}
+
+	_, err = tx.ExecContext(ctx, "INSERT INTO pair_balances (user_id, currency, counterparty_id, balance) "+
+		"SELECT user_id, currency, counterparty_id, SUM(amount) FROM journal_postings "+
+		"WHERE user_id = $1 GROUP BY user_id, currency, counterparty_id", uid)
+	if err != nil {
+		return false, fmt.Errorf("failed to calculate user balance: %w", err)
+	}
+
+	return true, tx.Commit()
+}
+
+// ApplyEvent implements service.BalanceStore
+//
+// Balance is updated in journal transaction and no balance update events are produced,
+// so method does nothing. Outbox step is skipped for materialized balance, see IsMaterialized.
+func (r PairBalanceRepository) ApplyEvent(_ context.Context, _ balance.Event) (bool, error) {
+	return false, nil
+}
+
+// IsMaterialized implements service.MaterializedBalanceStorage
+func (r PairBalanceRepository) IsMaterialized() bool {
+	return true
+}
+
+// ClearBalance implements service.BalanceStore
+func (r PairBalanceRepository) ClearBalance(ctx context.Context, uid user.ID) error {
+	_, err := psql.Delete(tablePairBalanceUsers).
+		Where(squirrel.Eq{colUserID: uid}).
+		RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to clear user balance: %w", err)
+	}
+
+	return nil
+}
+
+// applyPairBalances updates materialized balance of users affected by transaction
+// as part of database transaction.
+//
+// Transaction postings should be already saved.
+func applyPairBalances(ctx context.Context, tx executor, t *loan.Transaction) error {
+	if _, err := tx.ExecContext(ctx, applyPairBalancesQuery, t.ID); err != nil {
+		return err
+	}
+
+	_, err := tx.ExecContext(ctx, "UPDATE pair_balance_users SET seq = $2 WHERE user_id IN "+
+		"(SELECT user_id FROM journal_postings WHERE transaction_id = $1)", t.ID, t.Seq)
+	return err
+}
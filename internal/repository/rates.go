@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/service"
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	tableExchangeRates = "exchange_rates"
+
+	colBase  = "base"
+	colQuote = "quote"
+)
+
+// RatesRepository provides exchange rates from manually maintained database table.
+type RatesRepository struct {
+	db *sqlx.DB
+}
+
+// NewRatesRepository is RatesRepository constructor
+func NewRatesRepository(db *sqlx.DB) *RatesRepository {
+	return &RatesRepository{db: db}
+}
+
+// ExchangeRate implements service.RateProvider
+func (r RatesRepository) ExchangeRate(ctx context.Context, base, quote model.Currency) (float64, error) {
+	q, args, err := psql.Select(colRate).From(tableExchangeRates).Where(squirrel.Eq{
+		colBase:  base,
+		colQuote: quote,
+	}).Limit(1).ToSql()
+	if err != nil {
+		return 0, err
+	}
+
+	var rate float64
+	err = conn(ctx, r.db).GetContext(ctx, &rate, q, args...)
+	if err == sql.ErrNoRows {
+		return 0, service.ErrNoRate
+	}
+
+	return rate, err
+}
+
+// FileRates provides exchange rates from a YAML file for offline use.
+//
+// File contains rates for each base currency:
+//
+//	EUR:
+//	  USD: 1.08
+//	  CHF: 0.95
+type FileRates struct {
+	rates map[model.Currency]map[model.Currency]float64
+}
+
+// NewFileRates reads exchange rates from a file
+func NewFileRates(fileName string) (*FileRates, error) {
+	f, err := os.Open(fileName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open rates file: %w", err)
+	}
+
+	defer f.Close()
+	rates := make(map[model.Currency]map[model.Currency]float64)
+	if err = yaml.NewDecoder(f).Decode(&rates); err != nil {
+		return nil, fmt.Errorf("failed to parse rates file %q: %w", fileName, err)
+	}
+
+	for base, quotes := range rates {
+		if !base.IsValid() {
+			return nil, fmt.Errorf("rates file %q contains invalid currency %q", fileName, base)
+		}
+
+		for quote, rate := range quotes {
+			if !quote.IsValid() {
+				return nil, fmt.Errorf("rates file %q contains invalid currency %q", fileName, quote)
+			}
+
+			if rate <= 0 {
+				return nil, fmt.Errorf("rates file %q contains invalid %s/%s rate", fileName, base, quote)
+			}
+		}
+	}
+
+	return &FileRates{rates: rates}, nil
+}
+
+// ExchangeRate implements service.RateProvider
+//
+// If file has no rate for currency pair, rate for reverse currency pair is used.
+func (r FileRates) ExchangeRate(_ context.Context, base, quote model.Currency) (float64, error) {
+	if rate, ok := r.rates[base][quote]; ok {
+		return rate, nil
+	}
+
+	if rate, ok := r.rates[quote][base]; ok {
+		return 1 / rate, nil
+	}
+
+	return 0, service.ErrNoRate
+}
@@ -0,0 +1,109 @@
+package repository
+
+import (
+	"context"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+func TestNewFileRates(t *testing.T) {
+	cases := map[string]struct {
+		data    string
+		wantErr string
+	}{
+		"valid file": {
+			data: "EUR:\n  USD: 1.25\n",
+		},
+		"malformed file": {
+			data:    "EUR: [USD",
+			wantErr: "failed to parse rates file",
+		},
+		"invalid base currency": {
+			data:    "XXX:\n  USD: 1.25\n",
+			wantErr: `contains invalid currency "XXX"`,
+		},
+		"invalid quote currency": {
+			data:    "EUR:\n  XXX: 1.25\n",
+			wantErr: `contains invalid currency "XXX"`,
+		},
+		"invalid rate": {
+			data:    "EUR:\n  USD: 0\n",
+			wantErr: "contains invalid EUR/USD rate",
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			_, err := NewFileRates(writeRatesFile(t, c.data))
+			if c.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+
+			require.Error(t, err)
+			require.Contains(t, err.Error(), c.wantErr)
+		})
+	}
+
+	_, err := NewFileRates(filepath.Join(t.TempDir(), "missing.yaml"))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to open rates file")
+}
+
+func TestFileRates_ExchangeRate(t *testing.T) {
+	rates, err := NewFileRates(writeRatesFile(t, "EUR:\n  USD: 1.25\n  CHF: 0.5\n"))
+	require.NoError(t, err)
+
+	cases := map[string]struct {
+		base    model.Currency
+		quote   model.Currency
+		want    float64
+		wantErr error
+	}{
+		"direct pair": {
+			base:  "EUR",
+			quote: "USD",
+			want:  1.25,
+		},
+		"reverse pair": {
+			base:  "USD",
+			quote: "EUR",
+			want:  0.8,
+		},
+		"reverse pair of another quote": {
+			base:  "CHF",
+			quote: "EUR",
+			want:  2,
+		},
+		"missing pair": {
+			base:    "USD",
+			quote:   "CHF",
+			wantErr: service.ErrNoRate,
+		},
+	}
+
+	for n, c := range cases {
+		t.Run(n, func(t *testing.T) {
+			got, err := rates.ExchangeRate(context.Background(), c.base, c.quote)
+			if c.wantErr != nil {
+				require.Equal(t, c.wantErr, err)
+				return
+			}
+
+			require.NoError(t, err)
+			require.InDelta(t, c.want, got, 1e-9)
+		})
+	}
+}
+
+func writeRatesFile(t *testing.T, data string) string {
+	t.Helper()
+	fileName := filepath.Join(t.TempDir(), "rates.yaml")
+	require.NoError(t, ioutil.WriteFile(fileName, []byte(data), 0600))
+	return fileName
+}
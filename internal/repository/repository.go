@@ -2,9 +2,11 @@ package repository
 
 import (
 	"database/sql"
+	"errors"
 	"fmt"
 
 	"github.com/Masterminds/squirrel"
+	"github.com/jackc/pgconn"
 	"github.com/x1unix/sbda-ledger/internal/web"
 )
 
@@ -13,6 +15,9 @@ var (
 	psql = squirrel.StatementBuilder.PlaceholderFormat(squirrel.Dollar)
 )
 
+// pgUniqueViolation is PostgreSQL unique constraint violation error code
+const pgUniqueViolation = "23505"
+
 var returnIDSuffix = returningSuffix(colID)
 
 func returningSuffix(colName string) string {
@@ -31,3 +36,8 @@ func checkAffectedRows(r sql.Result) error {
 
 	return nil
 }
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == pgUniqueViolation
+}
@@ -0,0 +1,141 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Masterminds/squirrel"
+	"github.com/jmoiron/sqlx"
+)
+
+// executor is database query executor.
+//
+// Both database connection and transaction implement executor,
+// so repositories can run queries in transaction from context (see TxManager).
+type executor interface {
+	sqlx.ExtContext
+	squirrel.StdSqlCtx
+
+	GetContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+	SelectContext(ctx context.Context, dest interface{}, query string, args ...interface{}) error
+}
+
+type txContextKey struct{}
+
+// txState is transaction started by TxManager.
+type txState struct {
+	tx *sqlx.Tx
+
+	// afterCommit is list of functions called after transaction commit.
+	afterCommit []func()
+}
+
+func txFromContext(ctx context.Context) *txState {
+	state, _ := ctx.Value(txContextKey{}).(*txState)
+	return state
+}
+
+// conn returns transaction from context if present, otherwise database connection is returned.
+func conn(ctx context.Context, db *sqlx.DB) executor {
+	if state := txFromContext(ctx); state != nil {
+		return state.tx
+	}
+
+	return db
+}
+
+// dbTx is transaction used by repository method.
+//
+// Transaction can be started by repository method itself, or joined from context.
+// Joined transaction is committed or rolled back by its owner, so Commit and Rollback are no-op.
+type dbTx struct {
+	*sqlx.Tx
+	joined bool
+}
+
+// beginTx starts a new transaction or joins a transaction from context.
+//
+// Transaction options are ignored for joined transaction.
+func beginTx(ctx context.Context, db *sqlx.DB, opts *sql.TxOptions) (*dbTx, error) {
+	if state := txFromContext(ctx); state != nil {
+		return &dbTx{Tx: state.tx, joined: true}, nil
+	}
+
+	tx, err := db.BeginTxx(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dbTx{Tx: tx}, nil
+}
+
+// Commit commits transaction if it's not joined
+func (t dbTx) Commit() error {
+	if t.joined {
+		return nil
+	}
+
+	return t.Tx.Commit()
+}
+
+// Rollback aborts transaction if it's not joined
+func (t dbTx) Rollback() error {
+	if t.joined {
+		return nil
+	}
+
+	return t.Tx.Rollback()
+}
+
+// TxManager runs repository calls in a single database transaction.
+type TxManager struct {
+	db *sqlx.DB
+}
+
+// NewTxManager is TxManager constructor
+func NewTxManager(db *sqlx.DB) *TxManager {
+	return &TxManager{db: db}
+}
+
+// WithinTransaction implements service.Transactor
+func (m TxManager) WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if txFromContext(ctx) != nil {
+		// Nested calls are part of outer transaction.
+		return fn(ctx)
+	}
+
+	tx, err := m.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	state := &txState{tx: tx}
+	if err = fn(context.WithValue(ctx, txContextKey{}, state)); err != nil {
+		return err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	for _, fn := range state.afterCommit {
+		fn()
+	}
+
+	return nil
+}
+
+// AfterCommit implements service.Transactor
+func (m TxManager) AfterCommit(ctx context.Context, fn func()) {
+	state := txFromContext(ctx)
+	if state == nil {
+		fn()
+		return
+	}
+
+	state.afterCommit = append(state.afterCommit, fn)
+}
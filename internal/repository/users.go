@@ -3,6 +3,7 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"fmt"
 
 	"github.com/Masterminds/squirrel"
 	"github.com/jmoiron/sqlx"
@@ -17,10 +18,47 @@ const (
 	colName     = "name"
 	colPassword = "password"
 
+	colPlaceholder = "placeholder"
+	colClaimedBy   = "claimed_by"
+
 	tableUsers = "users"
 )
 
-var userCols = []string{colID, colEmail, colName, colPassword}
+// placeholders have no email and password
+var userCols = []string{
+	colID, "COALESCE(email, '') AS email", colName, "COALESCE(password, '') AS password",
+	colPlaceholder, colClaimedBy,
+}
+
+// reassignMembershipQuery replaces placeholder with user in membership history
+// and in active memberships of groups where user is not a member or owner yet.
+const reassignMembershipQuery = "UPDATE " + tableGroupMembers + " m SET member_id = $2 " +
+	"WHERE m.member_id = $1 AND (m.left_at IS NOT NULL OR (" +
+	"NOT EXISTS (SELECT 1 FROM " + tableGroupMembers + " o WHERE o.group_id = m.group_id AND o.member_id = $2 AND o.left_at IS NULL) " +
+	"AND NOT EXISTS (SELECT 1 FROM " + tableGroups + " g WHERE g.id = m.group_id AND g.owner_id = $2)))"
+
+// reassignEventParticipantsQuery replaces placeholder with user in group events
+// where user is not a participant yet.
+const reassignEventParticipantsQuery = "UPDATE " + tableEventParticipants + " p SET member_id = $2 " +
+	"WHERE p.member_id = $1 AND NOT EXISTS (SELECT 1 FROM " + tableEventParticipants + " o " +
+	"WHERE o.event_id = p.event_id AND o.member_id = $2)"
+
+// reassignHouseholdQuery replaces placeholder with user in households
+// of groups where user doesn't belong to a household yet.
+const reassignHouseholdQuery = "UPDATE " + tableHouseholdMembers + " m SET member_id = $2 " +
+	"WHERE m.member_id = $1 AND NOT EXISTS (SELECT 1 FROM " + tableHouseholdMembers + " o " +
+	"WHERE o.group_id = m.group_id AND o.member_id = $2)"
+
+// reassignHouseholdHolderQuery makes user a holder of households where placeholder was replaced by user.
+const reassignHouseholdHolderQuery = "UPDATE " + tableHouseholds + " h SET holder_id = $2 " +
+	"WHERE h.holder_id = $1 AND EXISTS (SELECT 1 FROM " + tableHouseholdMembers + " m " +
+	"WHERE m.household_id = h.id AND m.member_id = $2)"
+
+// closeOwnedMembershipQuery closes user membership in groups owned by placeholder,
+// as group owner is not stored in members table.
+const closeOwnedMembershipQuery = "UPDATE " + tableGroupMembers + " m SET left_at = NOW() " +
+	"WHERE m.member_id = $2 AND m.left_at IS NULL AND EXISTS (SELECT 1 FROM " + tableGroups + " g " +
+	"WHERE g.id = m.group_id AND g.owner_id = $1)"
 
 type UserRepository struct {
 	db *sqlx.DB
@@ -38,7 +76,7 @@ func (r UserRepository) AllUsers(ctx context.Context) (user.Users, error) {
 	}
 
 	var out user.Users
-	err = r.db.SelectContext(ctx, &out, q, args...)
+	err = conn(ctx, r.db).SelectContext(ctx, &out, q, args...)
 	if err == sql.ErrNoRows {
 		return nil, nil
 	}
@@ -56,7 +94,111 @@ func (r UserRepository) AddUser(ctx context.Context, u user.User) (*user.ID, err
 	}
 
 	newID := new(user.ID)
-	return newID, r.db.GetContext(ctx, newID, q, args...)
+	err = conn(ctx, r.db).GetContext(ctx, newID, q, args...)
+	if isUniqueViolation(err) {
+		return nil, service.ErrExists
+	}
+
+	return newID, err
+}
+
+// AddPlaceholder implements service.PlaceholderStore
+func (r UserRepository) AddPlaceholder(ctx context.Context, name string) (*user.ID, error) {
+	q, args, err := psql.Insert(tableUsers).SetMap(map[string]interface{}{
+		colName:        name,
+		colPlaceholder: true,
+	}).Suffix(returnIDSuffix).ToSql()
+	if err != nil {
+		return nil, err
+	}
+
+	newID := new(user.ID)
+	err = conn(ctx, r.db).GetContext(ctx, newID, q, args...)
+	return newID, err
+}
+
+// ClaimPlaceholder implements service.PlaceholderStore
+func (r UserRepository) ClaimPlaceholder(ctx context.Context, pid, uid user.ID) error {
+	tx, err := beginTx(ctx, r.db, nil)
+	if err != nil {
+		return err
+	}
+
+	// Rollback is no-op after commit
+	defer tx.Rollback()
+
+	result, err := psql.Update(tableUsers).Set(colClaimedBy, uid).Where(squirrel.Eq{
+		colID:          pid,
+		colPlaceholder: true,
+		colClaimedBy:   nil,
+	}).RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("cannot check affected rows: %w", err)
+	}
+
+	if affected == 0 {
+		return service.ErrPlaceholderNotFound
+	}
+
+	if _, err = tx.ExecContext(ctx, reassignMembershipQuery, pid, uid); err != nil {
+		return fmt.Errorf("failed to reassign placeholder membership: %w", err)
+	}
+
+	// User keeps own role in groups where user is already a member,
+	// placeholder membership is closed and kept in user membership history.
+	_, err = psql.Update(tableGroupMembers).SetMap(map[string]interface{}{
+		colMemberID: uid,
+		colLeftAt:   squirrel.Expr("NOW()"),
+	}).Where(squirrel.Eq{colMemberID: pid}).RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to close placeholder membership: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, reassignEventParticipantsQuery, pid, uid); err != nil {
+		return fmt.Errorf("failed to reassign placeholder event participation: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, reassignHouseholdQuery, pid, uid); err != nil {
+		return fmt.Errorf("failed to reassign placeholder household: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, reassignHouseholdHolderQuery, pid, uid); err != nil {
+		return fmt.Errorf("failed to reassign placeholder household balance: %w", err)
+	}
+
+	if _, err = tx.ExecContext(ctx, closeOwnedMembershipQuery, pid, uid); err != nil {
+		return fmt.Errorf("failed to close membership in placeholder groups: %w", err)
+	}
+
+	_, err = psql.Update(tableGroups).Set(colOwnerID, uid).
+		Where(squirrel.Eq{colOwnerID: pid}).RunWith(tx).ExecContext(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to reassign placeholder groups: %w", err)
+	}
+
+	return tx.Commit()
+}
+
+// UpdateUser implements service.UserStorage
+func (r UserRepository) UpdateUser(ctx context.Context, uid user.ID, props user.Props) error {
+	result, err := psql.Update(tableUsers).SetMap(map[string]interface{}{
+		colEmail: props.Email,
+		colName:  props.Name,
+	}).Where(squirrel.Eq{colID: uid}).RunWith(conn(ctx, r.db)).ExecContext(ctx)
+	if isUniqueViolation(err) {
+		return service.ErrExists
+	}
+
+	if err != nil {
+		return err
+	}
+
+	return checkAffectedRows(result)
 }
 
 func (r UserRepository) UserByEmail(ctx context.Context, email string) (*user.User, error) {
@@ -67,7 +209,7 @@ func (r UserRepository) UserByEmail(ctx context.Context, email string) (*user.Us
 		return nil, err
 	}
 	u := new(user.User)
-	err = r.db.GetContext(ctx, u, q, args...)
+	err = conn(ctx, r.db).GetContext(ctx, u, q, args...)
 	// UserByEmail is handler differently
 	return u, wrapRecordError(err)
 }
@@ -80,14 +222,14 @@ func (r UserRepository) UserByID(ctx context.Context, uid user.ID) (*user.User,
 		return nil, err
 	}
 	u := new(user.User)
-	err = r.db.GetContext(ctx, u, q, args...)
+	err = conn(ctx, r.db).GetContext(ctx, u, q, args...)
 	if err == sql.ErrNoRows {
 		return nil, web.NewErrNotFound("user not found")
 	}
 	return u, err
 }
 
-func (r UserRepository) Exists(email string) (bool, error) {
+func (r UserRepository) Exists(ctx context.Context, email string) (bool, error) {
 	q, args, err := psql.Select("COUNT(*)").From(tableUsers).Where(squirrel.Eq{
 		colEmail: email,
 	}).ToSql()
@@ -95,7 +237,7 @@ func (r UserRepository) Exists(email string) (bool, error) {
 		return false, err
 	}
 	var count uint
-	err = r.db.Get(&count, q, args...)
+	err = conn(ctx, r.db).GetContext(ctx, &count, q, args...)
 	return count > 0, err
 }
 
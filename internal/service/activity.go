@@ -0,0 +1,58 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x1unix/sbda-ledger/internal/model/activity"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/web"
+)
+
+// ActivityRecorder records group activity events
+type ActivityRecorder interface {
+	// AddEvent saves a new group activity event.
+	AddEvent(ctx context.Context, e activity.Event) error
+}
+
+// ActivityStore stores group activity feed
+type ActivityStore interface {
+	ActivityRecorder
+
+	// GroupEvents returns group events which match filter, newest first.
+	GroupEvents(ctx context.Context, gid user.GroupID, f activity.Filter) ([]activity.Event, error)
+}
+
+// recordEvent saves a new group activity event with specified payload.
+//
+// Event is saved in the same transaction as the change which caused the event.
+func recordEvent(ctx context.Context, rec ActivityRecorder, gid user.GroupID, actor *user.ID, t activity.EventType, payload interface{}) error {
+	e, err := activity.NewEvent(gid, actor, t, payload)
+	if err != nil {
+		return err
+	}
+
+	if err = rec.AddEvent(ctx, *e); err != nil {
+		return fmt.Errorf("failed to record %s event: %w", t, err)
+	}
+
+	return nil
+}
+
+// checkActivityFilter validates activity feed filter and fills default values.
+func checkActivityFilter(f activity.Filter) (activity.Filter, error) {
+	for _, t := range f.Types {
+		if !t.IsValid() {
+			return f, web.NewErrBadRequest("unknown event type %q", t)
+		}
+	}
+
+	switch {
+	case f.Limit == 0:
+		f.Limit = activity.DefaultLimit
+	case f.Limit < 0 || f.Limit > activity.MaxLimit:
+		return f, web.NewErrBadRequest("limit should be between 1 and %d", activity.MaxLimit)
+	}
+
+	return f, nil
+}
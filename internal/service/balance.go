@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 
+	"github.com/x1unix/sbda-ledger/internal/model/activity"
+	"github.com/x1unix/sbda-ledger/internal/model/auth"
+	"github.com/x1unix/sbda-ledger/internal/model/balance"
 	"github.com/x1unix/sbda-ledger/internal/model/loan"
 	"github.com/x1unix/sbda-ledger/internal/model/user"
 	"go.uber.org/zap"
@@ -16,47 +19,82 @@ var (
 
 // BalanceStorage is user balance storage that acts as cache.
 type BalanceStorage interface {
-	// HasBalance checks if user balance present in cache.
-	HasBalance(ctx context.Context, uid user.ID) (bool, error)
-
 	// GetBalance returns user balance from cache storage.
 	//
 	// If balance was not cached, ErrNoBalance error will be returned.
 	GetBalance(ctx context.Context, uid user.ID) ([]loan.Balance, error)
 
-	// SetBalance implicitly sets user balance in cache storage.
-	SetBalance(ctx context.Context, uid user.ID, balance ...loan.Balance) error
+	// BeginPopulation marks that user balance is going to be populated.
+	//
+	// Balance update events received until population is completed are recorded
+	// and replayed on top of populated balance snapshot.
+	BeginPopulation(ctx context.Context, uid user.ID) error
 
-	// UpdateBalance updates user balance with specified delta.
+	// CompletePopulation stores user balance snapshot taken at specified journal position.
 	//
-	// Method doesn't check if balance value exists, so HasBalance call is required.
-	UpdateBalance(ctx context.Context, uid user.ID, deltas ...loan.Balance) error
+	// Returns false if balance is already cached or population took too long.
+	CompletePopulation(ctx context.Context, uid user.ID, seq int64, balance ...loan.Balance) (bool, error)
+
+	// ApplyEvent atomically updates user balance with event deltas
+	// if event wasn't applied before.
+	//
+	// Balance is updated only if user balance is cached and event is not part of cached snapshot.
+	// Returns false if event was already applied.
+	ApplyEvent(ctx context.Context, e balance.Event) (bool, error)
 
 	// ClearBalance removes balance record from storage.
 	ClearBalance(ctx context.Context, uid user.ID) error
 }
 
-// LoansStorage is loans log storage.
+// MaterializedBalanceStorage is optional BalanceStorage capability.
+//
+// Materialized storage updates user balance in the same database transaction
+// as journal postings, so balance update events are not produced and
+// outbox step after transaction commit is skipped.
+type MaterializedBalanceStorage interface {
+	// IsMaterialized reports whether balance is updated together with journal postings.
+	IsMaterialized() bool
+}
+
+// LoansStorage is double-entry journal storage.
 type LoansStorage interface {
-	// AddLoans adds loan records
-	AddLoans(ctx context.Context, records []loan.Loan) error
+	// AddTransaction saves journal transaction with postings.
+	//
+	// Transaction ID, position and hash are filled by storage.
+	// Storage should reject transaction if postings don't sum to zero.
+	AddTransaction(ctx context.Context, t *loan.Transaction) error
 
 	// GetUserBalance returns balance (saldo) for each user
 	// that gave loan to a user or have dept.
 	GetUserBalance(ctx context.Context, uid user.ID) ([]loan.Balance, error)
+
+	// UserBalanceSnapshot returns user balance with journal position of the last
+	// transaction included into balance.
+	UserBalanceSnapshot(ctx context.Context, uid user.ID) ([]loan.Balance, int64, error)
 }
 
 // LoanService manages user dept balance and transactions history
 type LoanService struct {
-	log     *zap.Logger
-	rootCtx context.Context
-	cache   BalanceStorage
-	loans   LoansStorage
+	log      *zap.Logger
+	rootCtx  context.Context
+	cache    BalanceStorage
+	loans    LoansStorage
+	outbox   *BalanceOutbox
+	activity ActivityRecorder
+	tx       Transactor
 }
 
 // NewLoanService is LoanService constructor.
-func NewLoanService(ctx context.Context, log *zap.Logger, cache BalanceStorage, loans LoansStorage) *LoanService {
-	return &LoanService{rootCtx: ctx, log: log.Named("service.loans"), cache: cache, loans: loans}
+func NewLoanService(ctx context.Context, log *zap.Logger, cache BalanceStorage, loans LoansStorage, outbox *BalanceOutbox, activity ActivityRecorder, tx Transactor) *LoanService {
+	return &LoanService{
+		rootCtx:  ctx,
+		log:      log.Named("service.loans"),
+		cache:    cache,
+		loans:    loans,
+		outbox:   outbox,
+		activity: activity,
+		tx:       tx,
+	}
 }
 
 // GetUserBalance provides user balance status.
@@ -81,123 +119,134 @@ func (svc LoanService) GetUserBalance(ctx context.Context, uid user.ID) ([]loan.
 	}
 
 	// User balance is populated to a cache only after first balance status was queried.
-	balance, err = svc.loans.GetUserBalance(ctx, uid)
+	balance, err = populateBalance(ctx, svc.cache, svc.loans, uid)
 	if err != nil {
 		svc.log.Error("failed to calculate user balance", zap.Error(err), zap.Any("uid", uid))
 		return nil, fmt.Errorf("failed to get user balance: %w", err)
 	}
 
-	if err = svc.cache.SetBalance(ctx, uid, balance...); err != nil {
-		svc.log.Error("failed to cache user balance", zap.Error(err), zap.Any("uid", uid))
-	}
 	return balance, nil
 }
 
-// AddLoan adds a loan for each debtor from lender by specified amount.
+// populateBalance calculates user balance and stores it in cache.
 //
-// Amount is not divided between debtors, but assigned to each debtor individually.
+// Population mark is set before balance snapshot is taken, so balance updates
+// committed during population are not lost and applied on top of snapshot.
 //
-// Implements service.LoanAdder interface.
-func (svc LoanService) AddLoan(ctx context.Context, lender user.ID, amount loan.Amount, debtors []user.ID) error {
-	// append() to pre-allocated slice is still heavier
-	// than index assign, but I want to make things a bit faster.
-	// Someone might say that this is a premature optimisation, but anyway
-	// I don't see a purpose for append() here.
-	transactions := make([]loan.Loan, len(debtors))
-	for i, debtor := range debtors {
-		transactions[i] = loan.Loan{
-			LenderID: lender,
-			DebtorID: debtor,
-			Amount:   amount,
-		}
+// Cache errors are not fatal, balance is returned anyway.
+func populateBalance(ctx context.Context, cache BalanceStorage, loans LoansStorage, uid user.ID) ([]loan.Balance, error) {
+	if err := cache.BeginPopulation(ctx, uid); err != nil {
+		return loans.GetUserBalance(ctx, uid)
 	}
 
-	// log all transactions
-	if err := svc.loans.AddLoans(ctx, transactions); err != nil {
-		return fmt.Errorf("failed to save loan transactions: %w", err)
+	balance, seq, err := loans.UserBalanceSnapshot(ctx, uid)
+	if err != nil {
+		return nil, err
 	}
 
-	// update balance cache for affected users
-	svc.commitBalanceChanges(amount, lender, debtors)
-	return nil
+	// Balance might be already populated by concurrent request,
+	// in this case cached value is left as is.
+	_, _ = cache.CompletePopulation(ctx, uid, seq, balance...)
+	return balance, nil
 }
 
-// commitBalanceChanges updates user balance with specified delta in cache
-func (svc LoanService) commitBalanceChanges(delta loan.Amount, lenderID user.ID, users []user.ID) {
-	if err := svc.updateUserDebtorsBalance(lenderID, delta, users); err != nil {
-		// we got an error for lender only, try luck with debtors...
-		svc.log.Error("failed to update user balance in cache",
-			zap.Error(err), zap.Any("uid", lenderID), zap.Int64("delta", delta))
-	}
-
-	// we're going to reuse this struct for all debtors.
-	// For all debtors, balance is going to decrease by n(loan).
-	balance := loan.Balance{
-		UserID:  lenderID,
-		Balance: -delta,
+// AddExpenseLoans adds a loan from lender to each debtor by share amount
+// in expense currency.
+//
+// Lender is expense payer or holder of payer's household.
+//
+// Implements service.LoanAdder interface.
+func (svc LoanService) AddExpenseLoans(ctx context.Context, exp *loan.Expense, lender user.ID, shares []loan.ExpenseShare) error {
+	t := loan.Transaction{
+		Kind:      loan.KindExpense,
+		ExpenseID: &exp.ID,
+		GroupID:   &exp.GroupID,
+		EventID:   exp.EventID,
+		Postings:  make([]loan.Posting, 0, len(shares)*2),
 	}
 
-	// update debtors balance status in cache
-	for _, uid := range users {
-		if err := svc.updateUserBalance(uid, balance); err != nil {
-			svc.log.Error("failed to update debtor's balance in cache",
-				zap.Error(err), zap.Any("lender_id", lenderID),
-				zap.Any("debtor_id", uid), zap.Int64("delta", delta))
-			continue
-		}
+	for _, share := range shares {
+		t.AddLoan(lender, share.DebtorID, share.Amount, exp.Currency)
 	}
 
-	svc.log.Debug("updated debtors balance in cache", zap.Any("lender_id", lenderID),
-		zap.Any("debtors", users), zap.Int64("delta", delta))
+	_, err := svc.AddTransaction(ctx, t)
+	return err
 }
 
-// updateUserDebtorsBalance updates lender debtors balance registry.
+// AddTransaction saves a journal transaction and updates balance of affected users.
 //
-// This is how user balance relation is kept in cache:
+// Transaction postings in each currency must sum to zero.
 //
-//	var UserBalance = map[user.ID]map[user.ID]loan.Amount
-func (svc LoanService) updateUserDebtorsBalance(uid user.ID, delta loan.Amount, debtors []user.ID) error {
-	exists, err := svc.cache.HasBalance(svc.rootCtx, uid)
-	if err != nil {
-		return fmt.Errorf("failed to check lender balance cache status: %w", err)
+// Group transactions, except expenses, are recorded to group activity feed.
+// User of current session is recorded as event actor.
+func (svc LoanService) AddTransaction(ctx context.Context, t loan.Transaction) (*loan.TransactionID, error) {
+	if err := t.Validate(); err != nil {
+		return nil, err
 	}
 
-	if !exists {
-		svc.log.Info("lender balance cache not populated, skip update",
-			zap.Any("uid", uid), zap.Int64("delta", delta), zap.Any("debtors", debtors))
-		return nil
+	// log all postings
+	if err := svc.loans.AddTransaction(ctx, &t); err != nil {
+		return nil, fmt.Errorf("failed to save journal transaction: %w", err)
 	}
 
-	deltas := make([]loan.Balance, len(debtors))
-	for i, debtorID := range debtors {
-		deltas[i] = loan.Balance{
-			UserID:  debtorID,
-			Balance: delta,
-		}
+	if err := svc.recordTransactionEvent(ctx, t); err != nil {
+		return nil, err
 	}
 
-	if err := svc.cache.UpdateBalance(svc.rootCtx, uid, deltas...); err != nil {
-		// Loaner cache possibly borked, try to truncate it
-		_ = svc.cache.ClearBalance(svc.rootCtx, uid)
-		return fmt.Errorf("failed to commit updates loaner balance cache: %w", err)
+	if isMaterialized(svc.cache) {
+		return &t.ID, nil
 	}
-	return nil
+
+	// Update balance cache for affected users.
+	// If called in a transaction, cache is updated only after commit.
+	svc.tx.AfterCommit(ctx, func() {
+		svc.commitBalanceChanges(t)
+	})
+	return &t.ID, nil
 }
 
-// updateUserBalance commits user balance change when balance related to one of users is changed.
-// For example when user loaned or took dept from other user.
-func (svc LoanService) updateUserBalance(uid user.ID, balance loan.Balance) error {
-	exists, err := svc.cache.HasBalance(svc.rootCtx, uid)
-	if err != nil {
-		return fmt.Errorf("failed to check user balance cache status: %w", err)
-	}
+// isMaterialized checks if balance storage is updated together with journal postings.
+func isMaterialized(store BalanceStorage) bool {
+	m, ok := store.(MaterializedBalanceStorage)
+	return ok && m.IsMaterialized()
+}
 
-	if !exists {
-		svc.log.Info("user balance cache not populated, skip update",
-			zap.Any("uid", uid), zap.Any("balance_uid", balance.UserID),
-			zap.Int64("delta", balance.Balance))
+// recordTransactionEvent records group transaction to group activity feed.
+func (svc LoanService) recordTransactionEvent(ctx context.Context, t loan.Transaction) error {
+	eventType, ok := activity.TransactionEvents[t.Kind]
+	if !ok || t.GroupID == nil {
 		return nil
 	}
 
-	return svc.cache.UpdateBalance(svc.rootCtx, uid, balance)
+	var actor *user.ID
+	if sess := auth.SessionFromContext(ctx); sess != nil {
+		actor = &sess.UserID
+	}
+
+	return recordEvent(ctx, svc.activity, *t.GroupID, actor, eventType, activity.TransactionPayload{
+		TransactionID: t.ID,
+		Kind:          t.Kind,
+		ExpenseID:     t.ExpenseID,
+		Postings:      t.Postings,
+	})
+}
+
+// commitBalanceChanges applies transaction postings to users balance in cache.
+//
+// Update events are already stored in outbox, so failed updates will be retried by outbox worker.
+//
+// This is how user balance relation is kept in cache:
+//
+//	var UserBalance = map[user.ID]map[model.Currency]map[user.ID]loan.Amount
+func (svc LoanService) commitBalanceChanges(t loan.Transaction) {
+	for _, e := range balance.EventsFromTransaction(t) {
+		if err := svc.outbox.Apply(svc.rootCtx, e); err != nil {
+			svc.log.Error("failed to update user balance in cache, update postponed",
+				zap.Error(err), zap.Any("uid", e.UserID), zap.Any("deltas", e.Deltas),
+				zap.String("kind", string(t.Kind)))
+		}
+	}
+
+	svc.log.Debug("updated users balance in cache",
+		zap.String("kind", string(t.Kind)), zap.Any("postings", t.Postings))
 }
@@ -0,0 +1,179 @@
+package service
+
+import (
+	"context"
+	"expvar"
+	"fmt"
+	"time"
+
+	"github.com/x1unix/sbda-ledger/internal/model/balance"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"go.uber.org/zap"
+)
+
+// balanceCheckMetrics contains balance cache verification metrics.
+//
+// Metrics are published with expvar under "balance_check" key.
+var balanceCheckMetrics = expvar.NewMap("balance_check")
+
+const (
+	metricRuns          = "runs"
+	metricFailures      = "failures"
+	metricChecked       = "users_checked"
+	metricInconsistent  = "users_inconsistent"
+	metricDiscrepancies = "discrepancies"
+	metricRebuilt       = "users_rebuilt"
+	metricLastRun       = "last_run_timestamp"
+)
+
+// BalanceChecker checks consistency of balance cache against loans journal.
+type BalanceChecker struct {
+	log   *zap.Logger
+	cache BalanceStorage
+	loans LoansStorage
+	users UserStorage
+}
+
+// NewBalanceChecker is BalanceChecker constructor
+func NewBalanceChecker(log *zap.Logger, cache BalanceStorage, loans LoansStorage, users UserStorage) *BalanceChecker {
+	return &BalanceChecker{
+		log:   log.Named("service.balance_check"),
+		cache: cache,
+		loans: loans,
+		users: users,
+	}
+}
+
+// Check compares cached balance of specified users with balance calculated from loans journal.
+//
+// All users are checked if users list is empty.
+// If rebuild is true, inconsistent user balance cache is rebuilt.
+//
+// Balance may change between cache and journal reads, so check
+// may report false discrepancies under write load.
+func (c BalanceChecker) Check(ctx context.Context, uids []user.ID, rebuild bool) (*balance.CheckReport, error) {
+	balanceCheckMetrics.Add(metricRuns, 1)
+	report, err := c.check(ctx, uids, rebuild)
+	if err != nil {
+		balanceCheckMetrics.Add(metricFailures, 1)
+		return nil, err
+	}
+
+	lastRun := new(expvar.Int)
+	lastRun.Set(time.Now().Unix())
+	balanceCheckMetrics.Set(metricLastRun, lastRun)
+	balanceCheckMetrics.Add(metricChecked, int64(report.Checked))
+	balanceCheckMetrics.Add(metricInconsistent, int64(report.Inconsistent))
+	balanceCheckMetrics.Add(metricDiscrepancies, int64(len(report.Discrepancies)))
+	balanceCheckMetrics.Add(metricRebuilt, int64(report.Rebuilt))
+	return report, nil
+}
+
+func (c BalanceChecker) check(ctx context.Context, uids []user.ID, rebuild bool) (*balance.CheckReport, error) {
+	if len(uids) == 0 {
+		users, err := c.users.AllUsers(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get users list: %w", err)
+		}
+
+		uids = make([]user.ID, len(users))
+		for i, u := range users {
+			uids[i] = u.ID
+		}
+	}
+
+	report := &balance.CheckReport{Discrepancies: []balance.Discrepancy{}}
+	for _, uid := range uids {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+
+		if err := c.checkUser(ctx, uid, rebuild, report); err != nil {
+			return nil, err
+		}
+	}
+
+	c.log.Info("balance cache check finished",
+		zap.Int("checked", report.Checked),
+		zap.Int("not_cached", report.NotCached),
+		zap.Int("inconsistent", report.Inconsistent),
+		zap.Int("rebuilt", report.Rebuilt))
+	return report, nil
+}
+
+func (c BalanceChecker) checkUser(ctx context.Context, uid user.ID, rebuild bool, report *balance.CheckReport) error {
+	report.Checked++
+	cached, err := c.cache.GetBalance(ctx, uid)
+	if err == ErrNoBalance {
+		// Balance is populated on first read, nothing to check.
+		report.NotCached++
+		return nil
+	}
+
+	if err != nil {
+		return fmt.Errorf("failed to get cached balance of user %s: %w", user.IDToString(uid), err)
+	}
+
+	actual, err := c.loans.GetUserBalance(ctx, uid)
+	if err != nil {
+		return fmt.Errorf("failed to calculate balance of user %s: %w", user.IDToString(uid), err)
+	}
+
+	diff := balance.Compare(uid, cached, actual)
+	if len(diff) == 0 {
+		return nil
+	}
+
+	report.Inconsistent++
+	report.Discrepancies = append(report.Discrepancies, diff...)
+	c.log.Warn("user balance cache is inconsistent",
+		zap.Any("uid", uid), zap.Any("discrepancies", diff))
+
+	if !rebuild {
+		return nil
+	}
+
+	if err = c.rebuild(ctx, uid); err != nil {
+		return err
+	}
+
+	report.Rebuilt++
+	return nil
+}
+
+// rebuild drops user balance cache and populates it again from journal.
+//
+// Balance is populated from a fresh snapshot, since transactions might be committed after check.
+func (c BalanceChecker) rebuild(ctx context.Context, uid user.ID) error {
+	if err := c.cache.ClearBalance(ctx, uid); err != nil {
+		return fmt.Errorf("failed to clear balance cache of user %s: %w", user.IDToString(uid), err)
+	}
+
+	if _, err := populateBalance(ctx, c.cache, c.loans, uid); err != nil {
+		return fmt.Errorf("failed to rebuild balance cache of user %s: %w", user.IDToString(uid), err)
+	}
+
+	c.log.Info("rebuilt user balance cache", zap.Any("uid", uid))
+	return nil
+}
+
+// Start starts periodic verification of all users balance cache.
+//
+// Blocks until context is cancelled.
+func (c BalanceChecker) Start(ctx context.Context, interval time.Duration, rebuild bool) {
+	c.log.Info("started periodic balance cache check",
+		zap.Duration("interval", interval), zap.Bool("rebuild", rebuild))
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if _, err := c.Check(ctx, nil, rebuild); err != nil && ctx.Err() == nil {
+				c.log.Error("periodic balance cache check failed", zap.Error(err))
+			}
+		}
+	}
+}
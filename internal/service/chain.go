@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"go.uber.org/zap"
+)
+
+// chainBatchSize is number of transactions loaded at once during chain verification.
+const chainBatchSize = 500
+
+// ChainStorage provides access to journal transactions chain.
+type ChainStorage interface {
+	// TransactionsAfter returns transactions with postings which follow specified chain position.
+	//
+	// Transactions are ordered by chain position.
+	TransactionsAfter(ctx context.Context, seq int64, limit int) ([]loan.Transaction, error)
+}
+
+// ChainVerifier checks integrity of journal transactions chain.
+type ChainVerifier struct {
+	log   *zap.Logger
+	store ChainStorage
+}
+
+// NewChainVerifier is ChainVerifier constructor
+func NewChainVerifier(log *zap.Logger, store ChainStorage) *ChainVerifier {
+	return &ChainVerifier{log: log.Named("service.chain"), store: store}
+}
+
+// Verify walks through transactions chain and reports first broken link.
+//
+// Head is previously recorded chain head (optional).
+// If head is not nil, chain is considered truncated when it doesn't contain the head.
+func (v ChainVerifier) Verify(ctx context.Context, head loan.Hash) (*loan.ChainReport, error) {
+	report := &loan.ChainReport{Valid: true}
+	prevHash := loan.GenesisHash
+	headFound := head == nil
+	lastID := loan.TransactionID{Status: pgtype.Null}
+	var seq int64
+	for {
+		txs, err := v.store.TransactionsAfter(ctx, seq, chainBatchSize)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read journal transactions after %d: %w", seq, err)
+		}
+
+		if len(txs) == 0 {
+			break
+		}
+
+		for _, t := range txs {
+			if reason := checkChainLink(t, seq+1, prevHash); reason != "" {
+				report.Valid = false
+				report.BrokenLink = &loan.BrokenLink{
+					Seq:           t.Seq,
+					TransactionID: t.ID,
+					Reason:        reason,
+				}
+
+				v.log.Warn("journal chain is broken",
+					zap.Int64("seq", t.Seq), zap.Any("transaction_id", t.ID), zap.String("reason", reason))
+				return report, nil
+			}
+
+			seq, prevHash, lastID = t.Seq, t.Hash, t.ID
+			report.Checked++
+			report.Head = t.Hash
+			if !headFound && t.Hash.Equal(head) {
+				headFound = true
+			}
+		}
+	}
+
+	if !headFound {
+		reason := fmt.Sprintf("recorded head %s not found in chain, chain might be truncated", head)
+		report.Valid = false
+		report.BrokenLink = &loan.BrokenLink{
+			Seq:           seq,
+			TransactionID: lastID,
+			Reason:        reason,
+		}
+
+		v.log.Warn("journal chain is broken", zap.Int64("seq", seq), zap.String("reason", reason))
+		return report, nil
+	}
+
+	v.log.Info("journal chain verified", zap.Int64("checked", report.Checked),
+		zap.Stringer("head", report.Head))
+	return report, nil
+}
+
+// checkChainLink validates transaction against previous transaction in a chain.
+//
+// Returns failure reason or empty string if link is valid.
+func checkChainLink(t loan.Transaction, expectSeq int64, prevHash loan.Hash) string {
+	if t.Seq != expectSeq {
+		return fmt.Sprintf("expected transaction #%d, got #%d", expectSeq, t.Seq)
+	}
+
+	if !t.PrevHash.Equal(prevHash) {
+		return fmt.Sprintf("previous hash mismatch: expected %s, got %s", prevHash, t.PrevHash)
+	}
+
+	if hash := t.ComputeHash(); !t.Hash.Equal(hash) {
+		return fmt.Sprintf("transaction hash mismatch: expected %s, got %s", hash, t.Hash)
+	}
+
+	return ""
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/web"
+	"go.uber.org/zap"
+)
+
+var ErrEventNotFound = web.NewErrNotFound("event not found")
+
+// EventStore stores group events
+type EventStore interface {
+	// CreateEvent saves a new group event with participants list and returns created event.
+	CreateEvent(ctx context.Context, e user.Event) (*user.Event, error)
+
+	// EventByID returns group event with participants list.
+	//
+	// Returns ErrEventNotFound if there is no such event in a group.
+	EventByID(ctx context.Context, gid user.GroupID, eid user.EventID) (*user.Event, error)
+
+	// EventsByGroup returns all group events, newest first.
+	EventsByGroup(ctx context.Context, gid user.GroupID) (user.Events, error)
+}
+
+// EventService manages group events.
+//
+// Event is a sub-ledger inside a group, like a trip or an occasion.
+// Event participants are group members, so event expenses are shared only
+// between members who participate in the event.
+type EventService struct {
+	log      *zap.Logger
+	groups   GroupManager
+	events   EventStore
+	expenses ExpenseStorage
+	balances GroupBalanceStorage
+	admins   AdminChecker
+}
+
+// NewEventService is EventService constructor
+func NewEventService(log *zap.Logger, groups GroupManager, events EventStore, expenses ExpenseStorage, balances GroupBalanceStorage, admins AdminChecker) *EventService {
+	return &EventService{
+		log:      log.Named("service.events"),
+		groups:   groups,
+		events:   events,
+		expenses: expenses,
+		balances: balances,
+		admins:   admins,
+	}
+}
+
+// CreateEvent creates a new group event.
+//
+// Participants should be group members. Event without participants is shared by all group members.
+// Actor should be allowed to post expenses in a group.
+func (svc EventService) CreateEvent(ctx context.Context, actorId user.ID, gid user.GroupID, e user.Event) (*user.Event, error) {
+	if _, err := checkGroupPermission(ctx, svc.groups, actorId, gid, user.PermPostExpense); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[[16]byte]struct{}, len(e.Participants))
+	participants := make([]user.ID, 0, len(e.Participants))
+	for _, uid := range e.Participants {
+		if _, ok := seen[uid.Bytes]; ok {
+			continue
+		}
+
+		role, err := svc.groups.GetMemberRole(ctx, gid, uid)
+		if err != nil {
+			return nil, err
+		}
+
+		if role == "" {
+			return nil, web.NewErrBadRequest("event participants should be group members")
+		}
+
+		seen[uid.Bytes] = struct{}{}
+		participants = append(participants, uid)
+	}
+
+	e.GroupID = gid
+	e.CreatedBy = &actorId
+	e.Participants = participants
+	event, err := svc.events.CreateEvent(ctx, e)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create event: %w", err)
+	}
+
+	svc.log.Info("event created", zap.Any("gid", gid),
+		zap.Any("eid", event.ID), zap.Any("actor", actorId))
+	return event, nil
+}
+
+// GroupEvents returns all group events.
+//
+// Events are available only to group members and administrators.
+func (svc EventService) GroupEvents(ctx context.Context, actorId user.ID, gid user.GroupID) (user.Events, error) {
+	if err := checkGroupReadAccess(ctx, svc.groups, svc.admins, actorId, gid); err != nil {
+		return nil, err
+	}
+
+	return svc.events.EventsByGroup(ctx, gid)
+}
+
+// GetEvent returns group event.
+//
+// Events are available only to group members and administrators.
+func (svc EventService) GetEvent(ctx context.Context, actorId user.ID, gid user.GroupID, eid user.EventID) (*user.Event, error) {
+	if err := checkGroupReadAccess(ctx, svc.groups, svc.admins, actorId, gid); err != nil {
+		return nil, err
+	}
+
+	return svc.events.EventByID(ctx, gid, eid)
+}
+
+// GroupReport returns summary of group expenses and outstanding debts.
+//
+// If event ID is not nil, report is scoped to group event.
+// Otherwise, report is rolled up to the whole group including all group events.
+func (svc EventService) GroupReport(ctx context.Context, actorId user.ID, gid user.GroupID, eid *user.EventID) (*loan.Report, error) {
+	if err := checkGroupReadAccess(ctx, svc.groups, svc.admins, actorId, gid); err != nil {
+		return nil, err
+	}
+
+	if eid != nil {
+		if _, err := svc.events.EventByID(ctx, gid, *eid); err != nil {
+			return nil, err
+		}
+	}
+
+	spent, err := svc.expenses.ExpenseTotals(ctx, gid, eid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get expense totals: %w", err)
+	}
+
+	debts, err := svc.balances.GroupDebts(ctx, gid, eid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group debts: %w", err)
+	}
+
+	report := &loan.Report{
+		GroupID: gid,
+		EventID: eid,
+		Spent:   spent,
+		Debts:   debts,
+	}
+	if report.Spent == nil {
+		report.Spent = []loan.Total{}
+	}
+	if report.Debts == nil {
+		report.Debts = []loan.Debt{}
+	}
+
+	return report, nil
+}
@@ -4,64 +4,213 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"math"
+	"time"
 
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/activity"
 	"github.com/x1unix/sbda-ledger/internal/model/loan"
 	"github.com/x1unix/sbda-ledger/internal/model/user"
 	"github.com/x1unix/sbda-ledger/internal/web"
 	"go.uber.org/zap"
 )
 
+// GroupRestoreWindow is period during which deleted group can be restored.
+const GroupRestoreWindow = 30 * 24 * time.Hour
+
 var (
 	ErrGroupNotFound = errors.New("group not found")
+
+	// ErrGroupArchived is returned on attempt to change archived group.
+	ErrGroupArchived = web.NewErrConflict("group is archived, unarchive it first")
+
+	// ErrVersionMismatch is returned when resource was modified by concurrent request.
+	ErrVersionMismatch = web.NewErrPreconditionFailed("resource was modified by another request, reload it and try again")
 )
 
 // GroupStore stores group
 type GroupStore interface {
-	AddGroup(ctx context.Context, name string, owner user.ID) (*user.GroupID, error)
+	AddGroup(ctx context.Context, settings user.GroupSettings, owner user.ID) (*user.GroupID, error)
 	DeleteGroup(ctx context.Context, gid user.GroupID) error
+
+	// UpdateGroupSettings replaces group settings
+	UpdateGroupSettings(ctx context.Context, gid user.GroupID, settings user.GroupSettings) error
+
+	// SetGroupArchived archives or unarchives a group.
+	SetGroupArchived(ctx context.Context, gid user.GroupID, archived bool) error
+
+	// SoftDeleteGroup marks group as deleted. Deleted group is hidden but still can be restored.
+	SoftDeleteGroup(ctx context.Context, gid user.GroupID) error
+
+	// RestoreGroup restores group deleted by owner after specified date.
+	//
+	// Returns ErrGroupNotFound if there is no such deleted group.
+	RestoreGroup(ctx context.Context, gid user.GroupID, owner user.ID, deletedAfter time.Time) error
+
 	GroupByID(ctx context.Context, gid user.ID) (*user.Group, error)
+
+	// GroupByIDForShare returns group and locks group row until transaction end,
+	// so group settings and members can't be changed concurrently.
+	GroupByIDForShare(ctx context.Context, gid user.GroupID) (*user.Group, error)
+
 	GetGroupOwner(ctx context.Context, gid user.GroupID) (*user.ID, error)
+
+	// BumpGroupVersion increments group version and returns a new version.
+	//
+	// If expected version is not nil and doesn't match current version, ErrVersionMismatch is returned.
+	BumpGroupVersion(ctx context.Context, gid user.GroupID, expect *int64) (int64, error)
+
+	// TransferOwnership makes group member a new group owner.
+	//
+	// Former owner stays in group as a member with specified role since ownership start date.
+	TransferOwnership(ctx context.Context, gid user.GroupID, from, to user.ID, formerOwnerRole user.Role) error
 }
 
 // GroupManager manages group information and members list
 type GroupManager interface {
 	GroupStore
 
-	// AddGroupUsers adds a new user to a group
-	AddGroupUsers(ctx context.Context, gid user.GroupID, uids []user.ID) error
+	// AddGroupUsers adds new users to a group with specified role
+	AddGroupUsers(ctx context.Context, gid user.GroupID, uids []user.ID, role user.Role) error
+
+	// GetMemberRole returns user role in a group.
+	//
+	// Returns empty role if user is not a group member.
+	GetMemberRole(ctx context.Context, gid user.GroupID, uid user.ID) (user.Role, error)
+
+	// SetMemberRole changes role of a group member
+	SetMemberRole(ctx context.Context, gid user.GroupID, uid user.ID, role user.Role) error
 
 	// DeleteGroupUser removes user from group
 	DeleteGroupUser(ctx context.Context, gid user.GroupID, uid user.ID) error
 
-	// GetGroupMembers returns group members (except owner)
-	GetGroupMembers(ctx context.Context, gid user.GroupID) (user.Users, error)
+	// GetGroupMembers returns active group members (except owner).
+	//
+	// If history is requested, all membership periods including closed are returned.
+	GetGroupMembers(ctx context.Context, gid user.GroupID, history bool) (user.Members, error)
 
 	// GroupsByUser returns all groups where user is owner or member.
-	GroupsByUser(ctx context.Context, uid user.ID) (user.Groups, error)
+	//
+	// Archived groups are returned only if requested.
+	GroupsByUser(ctx context.Context, uid user.ID, includeArchived bool) (user.Groups, error)
 
-	// GroupMemberIDs returns list of users which are members of group, including group owner.
-	GroupMemberIDs(ctx context.Context, gid user.GroupID) ([]user.ID, error)
+	// GroupMemberIDs returns list of users which were members of group at specified date,
+	// including group owner if ownership started before that date.
+	//
+	// Current members are returned if date is nil.
+	GroupMemberIDs(ctx context.Context, gid user.GroupID, at *time.Time) ([]user.ID, error)
 }
 
 type LoanAdder interface {
-	// AddLoan adds a loan for each debtor from lender by specified amount.
-	AddLoan(ctx context.Context, lender user.ID, amount loan.Amount, debtors []user.ID) error
+	// AddExpenseLoans adds a loan from lender to each debtor by share amount.
+	AddExpenseLoans(ctx context.Context, exp *loan.Expense, lender user.ID, shares []loan.ExpenseShare) error
+
+	// AddTransaction saves a journal transaction and updates balance of affected users.
+	AddTransaction(ctx context.Context, t loan.Transaction) (*loan.TransactionID, error)
+}
+
+// GroupBalanceStorage provides user balance inside a group
+type GroupBalanceStorage interface {
+	// GroupUserBalance returns non-zero user balance built from group transactions.
+	GroupUserBalance(ctx context.Context, gid user.GroupID, uid user.ID) ([]loan.Balance, error)
+
+	// GroupDebts returns outstanding debts between users built from group transactions.
+	//
+	// If event ID is not nil, only transactions of group event are included.
+	GroupDebts(ctx context.Context, gid user.GroupID, eid *user.EventID) ([]loan.Debt, error)
+}
+
+// RemovalOptions are options of group member removal.
+//
+// Member with outstanding balance inside a group can't be removed
+// unless balance is transferred to another member or removal is forced.
+type RemovalOptions struct {
+	// Force allows to remove member with outstanding balance.
+	Force bool
+
+	// TransferTo is ID of group member which receives balance of removed member.
+	TransferTo *user.ID
+}
+
+func (opts RemovalOptions) eventPayload(uid user.ID) activity.MemberRemovalPayload {
+	return activity.MemberRemovalPayload{
+		MemberID:   uid,
+		TransferTo: opts.TransferTo,
+		Forced:     opts.Force,
+	}
+}
+
+// ExpenseStorage stores group expenses
+type ExpenseStorage interface {
+	// AddExpense stores a new expense and returns its ID.
+	AddExpense(ctx context.Context, exp loan.Expense) (*loan.ExpenseID, error)
+
+	// ExpenseTotals returns total amount of group expenses in each currency.
+	//
+	// If event ID is not nil, only expenses of group event are included.
+	ExpenseTotals(ctx context.Context, gid user.GroupID, eid *user.EventID) ([]loan.Total, error)
+}
+
+// AdminChecker checks if user has administrative access
+type AdminChecker interface {
+	// IsAdmin checks if user has administrative access
+	IsAdmin(ctx context.Context, uid user.ID) (bool, error)
 }
 
 type GroupService struct {
-	log       *zap.Logger
-	groups    GroupManager
-	loanAdder LoanAdder
+	log        *zap.Logger
+	groups     GroupManager
+	expenses   ExpenseStorage
+	rates      RateProvider
+	loanAdder  LoanAdder
+	balances   GroupBalanceStorage
+	admins     AdminChecker
+	activity   ActivityStore
+	events     EventStore
+	households HouseholdStore
+	tx         Transactor
 }
 
 // NewGroupService is GroupService constructor
-func NewGroupService(log *zap.Logger, groups GroupManager, loanAdder LoanAdder) *GroupService {
-	return &GroupService{log: log.Named("service.groups"), groups: groups, loanAdder: loanAdder}
+func NewGroupService(log *zap.Logger, groups GroupManager, expenses ExpenseStorage, rates RateProvider, loanAdder LoanAdder, balances GroupBalanceStorage, admins AdminChecker, activity ActivityStore, events EventStore, households HouseholdStore, tx Transactor) *GroupService {
+	return &GroupService{
+		log:        log.Named("service.groups"),
+		groups:     groups,
+		expenses:   expenses,
+		rates:      rates,
+		loanAdder:  loanAdder,
+		balances:   balances,
+		admins:     admins,
+		activity:   activity,
+		events:     events,
+		households: households,
+		tx:         tx,
+	}
+}
+
+// permissionErrors contains error messages for denied permissions
+var permissionErrors = map[user.Permission]string{
+	user.PermPostExpense: "you have no right to post expenses in this group",
+	user.PermEditExpense: "you have no right to edit expenses in this group",
 }
 
-func (svc GroupService) checkGroupActor(ctx context.Context, actor user.ID, gid user.GroupID) error {
-	owner, err := svc.groups.GetGroupOwner(ctx, gid)
+// checkPermission checks if actor has permission in a group and returns actor role.
+func (svc GroupService) checkPermission(ctx context.Context, actor user.ID, gid user.GroupID, perm user.Permission) (user.Role, error) {
+	return checkGroupPermission(ctx, svc.groups, actor, gid, perm)
+}
+
+// checkReadAccess checks if actor is allowed to read group information.
+//
+// Group is visible only to group members and administrators.
+// Group is reported as not found to anyone else to not reveal group existence.
+func (svc GroupService) checkReadAccess(ctx context.Context, actor user.ID, gid user.GroupID) error {
+	return checkGroupReadAccess(ctx, svc.groups, svc.admins, actor, gid)
+}
+
+// checkGroupReadAccess checks if actor is group member or administrator.
+//
+// Returns not found error otherwise.
+func checkGroupReadAccess(ctx context.Context, groups GroupManager, admins AdminChecker, actor user.ID, gid user.GroupID) error {
+	role, err := groups.GetMemberRole(ctx, gid, actor)
 	if err == ErrGroupNotFound {
 		return web.NewErrNotFound("group not found")
 	}
@@ -70,39 +219,187 @@ func (svc GroupService) checkGroupActor(ctx context.Context, actor user.ID, gid
 		return err
 	}
 
-	if owner == nil {
-		return errors.New("bad group owner")
+	if role.Can(user.PermRead) {
+		return nil
+	}
+
+	isAdmin, err := admins.IsAdmin(ctx, actor)
+	if err != nil {
+		return err
+	}
+
+	if !isAdmin {
+		return web.NewErrNotFound("group not found")
 	}
 
-	if *owner != actor {
-		return web.NewErrForbidden("you have no right to control this group")
+	return nil
+}
+
+// checkGroupPermission checks if actor has permission in a group and returns actor role.
+//
+// Archived groups are read-only, so only read permission is granted for them.
+func checkGroupPermission(ctx context.Context, groups GroupManager, actor user.ID, gid user.GroupID, perm user.Permission) (user.Role, error) {
+	role, err := checkGroupRole(ctx, groups, actor, gid, perm)
+	if err != nil || perm == user.PermRead {
+		return role, err
+	}
+
+	return role, checkGroupWritable(ctx, groups, gid)
+}
+
+// checkGroupWritable returns ErrGroupArchived if group is archived.
+func checkGroupWritable(ctx context.Context, groups GroupStore, gid user.GroupID) error {
+	grp, err := groups.GroupByID(ctx, gid)
+	if err == ErrGroupNotFound {
+		return web.NewErrNotFound("group not found")
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if grp.IsArchived() {
+		return ErrGroupArchived
 	}
 
 	return nil
 }
 
-// AddGroup creates a new group
-func (svc GroupService) AddGroup(ctx context.Context, name string, owner user.ID) (*user.Group, error) {
-	gid, err := svc.groups.AddGroup(ctx, name, owner)
+// checkGroupRole checks if actor has permission in a group regardless of group archival.
+func checkGroupRole(ctx context.Context, groups GroupManager, actor user.ID, gid user.GroupID, perm user.Permission) (user.Role, error) {
+	role, err := groups.GetMemberRole(ctx, gid, actor)
+	if err == ErrGroupNotFound {
+		return "", web.NewErrNotFound("group not found")
+	}
+
+	if err != nil {
+		return "", err
+	}
+
+	if !role.Can(perm) {
+		if msg, ok := permissionErrors[perm]; ok {
+			return "", web.NewErrForbidden(msg)
+		}
+
+		return "", web.NewErrForbidden("you have no right to control this group")
+	}
+
+	return role, nil
+}
+
+// checkMemberControl checks if actor is allowed to change or remove group member
+// and returns member role.
+//
+// Group owner can't be changed or removed.
+func (svc GroupService) checkMemberControl(ctx context.Context, actorRole user.Role, gid user.GroupID, uid user.ID, ownerErrMsg string) (user.Role, error) {
+	role, err := svc.groups.GetMemberRole(ctx, gid, uid)
+	if err != nil {
+		return "", err
+	}
+
+	switch role {
+	case "":
+		return "", web.NewErrNotFound("item not found")
+	case user.RoleOwner:
+		return "", web.NewErrBadRequest(ownerErrMsg)
+	}
+
+	if !actorRole.CanAssign(role) {
+		return "", web.NewErrForbidden("you have no right to manage group %ss", role)
+	}
+
+	return role, nil
+}
+
+// bumpVersion increments group version and checks that group wasn't modified
+// if expected version is passed.
+//
+// Group row is locked until end of transaction, so concurrent changes are serialized.
+func (svc GroupService) bumpVersion(ctx context.Context, gid user.GroupID, expect *int64) error {
+	return bumpGroupVersion(ctx, svc.groups, gid, expect)
+}
+
+// bumpGroupVersion increments group version, see GroupService.bumpVersion.
+func bumpGroupVersion(ctx context.Context, groups GroupStore, gid user.GroupID, expect *int64) error {
+	_, err := groups.BumpGroupVersion(ctx, gid, expect)
+	if err == ErrGroupNotFound {
+		return web.NewErrNotFound("group not found")
+	}
+
+	return err
+}
+
+// AddGroup creates a new group.
+//
+// Empty currency, split mode and rounding policy are replaced with default values.
+func (svc GroupService) AddGroup(ctx context.Context, settings user.GroupSettings, owner user.ID) (*user.Group, error) {
+	settings = settings.WithDefaults()
+	gid, err := svc.groups.AddGroup(ctx, settings, owner)
 	if err != nil {
 		return nil, err
 	}
 
 	return &user.Group{
-		ID:      *gid,
-		Name:    name,
-		OwnerID: owner,
+		ID:            *gid,
+		OwnerID:       owner,
+		GroupSettings: settings,
+		Version:       1,
 	}, nil
 }
 
-// AddMembers adds members to a group
-func (svc GroupService) AddMembers(ctx context.Context, actorId user.ID, gid user.GroupID, uids []user.ID) error {
+// UpdateGroup changes group settings and returns updated group.
+//
+// If version is not nil, group is updated only if group version matches.
+func (svc GroupService) UpdateGroup(ctx context.Context, actorId user.ID, gid user.GroupID, upd user.GroupSettingsUpdate, version *int64) (*user.Group, error) {
+	if upd.IsEmpty() {
+		return nil, web.NewErrBadRequest("no group settings to update")
+	}
+
+	var grp *user.Group
+	err := svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := svc.checkPermission(ctx, actorId, gid, user.PermEditGroup); err != nil {
+			return err
+		}
+
+		if err := svc.bumpVersion(ctx, gid, version); err != nil {
+			return err
+		}
+
+		g, err := svc.groups.GroupByID(ctx, gid)
+		if err != nil {
+			return err
+		}
+
+		g.GroupSettings = upd.Apply(g.GroupSettings)
+		if err = svc.groups.UpdateGroupSettings(ctx, gid, g.GroupSettings); err != nil {
+			return err
+		}
+
+		grp = g
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grp, nil
+}
+
+// AddMembers adds members with specified role to a group.
+//
+// If role is empty, user.RoleMember is used.
+// If version is not nil, members are added only if group version matches.
+func (svc GroupService) AddMembers(ctx context.Context, actorId user.ID, gid user.GroupID, uids []user.ID, role user.Role, version *int64) error {
 	if len(uids) == 0 {
 		return web.NewErrBadRequest("group member list is empty")
 	}
 
-	if err := svc.checkGroupActor(ctx, actorId, gid); err != nil {
-		return err
+	if role == "" {
+		role = user.RoleMember
+	}
+
+	if !role.IsAssignable() {
+		return web.NewErrBadRequest("invalid member role %q", role)
 	}
 
 	for _, uid := range uids {
@@ -111,43 +408,442 @@ func (svc GroupService) AddMembers(ctx context.Context, actorId user.ID, gid use
 		}
 	}
 
-	return svc.groups.AddGroupUsers(ctx, gid, uids)
+	return svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		actorRole, err := svc.checkPermission(ctx, actorId, gid, user.PermManageMembers)
+		if err != nil {
+			return err
+		}
+
+		if !actorRole.CanAssign(role) {
+			return web.NewErrForbidden("you have no right to manage group %ss", role)
+		}
+
+		owner, err := svc.groups.GetGroupOwner(ctx, gid)
+		if err != nil {
+			return err
+		}
+
+		for _, uid := range uids {
+			if uid == *owner {
+				return web.NewErrBadRequest("group author is already in group")
+			}
+		}
+
+		if err = svc.bumpVersion(ctx, gid, version); err != nil {
+			return err
+		}
+
+		if err = svc.groups.AddGroupUsers(ctx, gid, uids, role); err != nil {
+			return err
+		}
+
+		if role == user.RoleAdmin {
+			if err = svc.checkNotPlaceholders(ctx, gid, uids, "placeholder member cannot be a group admin"); err != nil {
+				return err
+			}
+		}
+
+		return recordEvent(ctx, svc.activity, gid, &actorId, activity.EventMemberAdded,
+			activity.MembersPayload{Members: uids, Role: role})
+	})
 }
 
-// GetMembers returns list of group members
-func (svc GroupService) GetMembers(ctx context.Context, gid user.GroupID) (user.Users, error) {
-	return svc.groups.GetGroupMembers(ctx, gid)
+// GetMembers returns list of group members.
+//
+// Members list is available only to group members and administrators.
+func (svc GroupService) GetMembers(ctx context.Context, actorId user.ID, gid user.GroupID, history bool) (user.Members, error) {
+	if err := svc.checkReadAccess(ctx, actorId, gid); err != nil {
+		return nil, err
+	}
+
+	return svc.groups.GetGroupMembers(ctx, gid, history)
 }
 
-// RemoveMember removes a member from a group
-func (svc GroupService) RemoveMember(ctx context.Context, actorId user.ID, gid user.GroupID, uid user.ID) error {
-	if err := svc.checkGroupActor(ctx, actorId, gid); err != nil {
-		return err
+// RemoveMember removes a member from a group.
+//
+// Member with outstanding balance inside a group is not removed, unless
+// balance is transferred to another member or removal is forced (see RemovalOptions).
+// If version is not nil, member is removed only if group version matches.
+func (svc GroupService) RemoveMember(ctx context.Context, actorId user.ID, gid user.GroupID, uid user.ID, version *int64, opts RemovalOptions) error {
+	return svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		actorRole, err := svc.checkPermission(ctx, actorId, gid, user.PermManageMembers)
+		if err != nil {
+			return err
+		}
+
+		_, err = svc.checkMemberControl(ctx, actorRole, gid, uid, "group creator cannot be removed from the group")
+		if err != nil {
+			return err
+		}
+
+		if err = svc.checkNotInHousehold(ctx, gid, uid); err != nil {
+			return err
+		}
+
+		if err = svc.bumpVersion(ctx, gid, version); err != nil {
+			return err
+		}
+
+		if err = svc.releaseBalance(ctx, gid, uid, opts); err != nil {
+			return err
+		}
+
+		if err = svc.groups.DeleteGroupUser(ctx, gid, uid); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, svc.activity, gid, &actorId, activity.EventMemberRemoved,
+			opts.eventPayload(uid))
+	})
+}
+
+// LeaveGroup removes actor from a group.
+//
+// Group owner can't leave a group and should transfer ownership first.
+// Archived group can't be left, as membership changes are not allowed.
+// Outstanding balance is handled in the same way as in RemoveMember.
+func (svc GroupService) LeaveGroup(ctx context.Context, actorId user.ID, gid user.GroupID, opts RemovalOptions) error {
+	return svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		role, err := svc.groups.GetMemberRole(ctx, gid, actorId)
+		if err == ErrGroupNotFound {
+			return web.NewErrNotFound("group not found")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		switch role {
+		case "":
+			return web.NewErrNotFound("user is not a member of the group")
+		case user.RoleOwner:
+			return web.NewErrBadRequest("group owner cannot leave the group, transfer group ownership first")
+		}
+
+		if err = checkGroupWritable(ctx, svc.groups, gid); err != nil {
+			return err
+		}
+
+		if err = svc.checkNotInHousehold(ctx, gid, actorId); err != nil {
+			return err
+		}
+
+		if err = svc.bumpVersion(ctx, gid, nil); err != nil {
+			return err
+		}
+
+		if err = svc.releaseBalance(ctx, gid, actorId, opts); err != nil {
+			return err
+		}
+
+		if err = svc.groups.DeleteGroupUser(ctx, gid, actorId); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, svc.activity, gid, &actorId, activity.EventMemberLeft,
+			opts.eventPayload(actorId))
+	})
+}
+
+// checkNotInHousehold checks that leaving member doesn't belong to a household.
+//
+// Household balance is held by household holder, so household should be deleted
+// before any of its members leaves a group.
+func (svc GroupService) checkNotInHousehold(ctx context.Context, gid user.GroupID, uid user.ID) error {
+	households, err := svc.households.HouseholdsByGroup(ctx, gid)
+	if err != nil {
+		return fmt.Errorf("failed to get group households: %w", err)
+	}
+
+	if user.FindHousehold(households, uid) != nil {
+		return ErrHouseholdMember
+	}
+
+	return nil
+}
+
+// releaseBalance checks balance of leaving member inside a group and
+// transfers it to another member if requested.
+//
+// Conflict error with list of open balances is returned if balance is not zero
+// and removal is not forced.
+func (svc GroupService) releaseBalance(ctx context.Context, gid user.GroupID, uid user.ID, opts RemovalOptions) error {
+	if opts.TransferTo != nil {
+		if *opts.TransferTo == uid {
+			return web.NewErrBadRequest("balance cannot be transferred to the leaving member")
+		}
+
+		role, err := svc.groups.GetMemberRole(ctx, gid, *opts.TransferTo)
+		if err != nil {
+			return err
+		}
+
+		if role == "" {
+			return web.NewErrBadRequest("balance can be transferred only to a group member")
+		}
+	}
+
+	balance, err := svc.balances.GroupUserBalance(ctx, gid, uid)
+	if err != nil {
+		return fmt.Errorf("failed to get member balance in group: %w", err)
+	}
+
+	if len(balance) == 0 {
+		return nil
 	}
 
+	if opts.TransferTo != nil {
+		for _, t := range loan.DebtTransfer(gid, uid, *opts.TransferTo, balance) {
+			if _, err = svc.loanAdder.AddTransaction(ctx, t); err != nil {
+				return fmt.Errorf("failed to transfer member balance: %w", err)
+			}
+		}
+
+		return nil
+	}
+
+	if opts.Force {
+		svc.log.Warn("removing group member with outstanding balance",
+			zap.Any("gid", gid), zap.Any("uid", uid), zap.Any("balance", balance))
+		return nil
+	}
+
+	apiErr := web.NewErrConflict("member has outstanding balance in the group, " +
+		"settle up or transfer balance to another member")
+	apiErr.Data = balance
+	return apiErr
+}
+
+// SetMemberRole changes role of a group member.
+//
+// Only owner can grant or revoke admin role.
+// If version is not nil, role is changed only if group version matches.
+func (svc GroupService) SetMemberRole(ctx context.Context, actorId user.ID, gid user.GroupID, uid user.ID, role user.Role, version *int64) error {
+	if !role.IsAssignable() {
+		return web.NewErrBadRequest("invalid member role %q", role)
+	}
+
+	return svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		actorRole, err := svc.checkPermission(ctx, actorId, gid, user.PermManageMembers)
+		if err != nil {
+			return err
+		}
+
+		current, err := svc.checkMemberControl(ctx, actorRole, gid, uid, "group creator role cannot be changed")
+		if err != nil {
+			return err
+		}
+
+		if !actorRole.CanAssign(role) {
+			return web.NewErrForbidden("you have no right to manage group %ss", role)
+		}
+
+		if current == role {
+			return nil
+		}
+
+		if role == user.RoleAdmin {
+			err = svc.checkNotPlaceholders(ctx, gid, []user.ID{uid}, "placeholder member cannot be a group admin")
+			if err != nil {
+				return err
+			}
+		}
+
+		if err = svc.bumpVersion(ctx, gid, version); err != nil {
+			return err
+		}
+
+		return svc.groups.SetMemberRole(ctx, gid, uid, role)
+	})
+}
+
+// TransferOwnership hands group over to another group member.
+//
+// Former owner stays in group as an admin.
+// If version is not nil, ownership is transferred only if group version matches.
+func (svc GroupService) TransferOwnership(ctx context.Context, actorId user.ID, gid user.GroupID, uid user.ID, version *int64) error {
 	if actorId == uid {
-		return web.NewErrBadRequest("group creator cannot be removed from the group")
+		return web.NewErrBadRequest("user is already a group owner")
 	}
 
-	return svc.groups.DeleteGroupUser(ctx, gid, uid)
+	return svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := svc.checkPermission(ctx, actorId, gid, user.PermTransferOwnership); err != nil {
+			return err
+		}
+
+		role, err := svc.groups.GetMemberRole(ctx, gid, uid)
+		if err != nil {
+			return err
+		}
+
+		if role == "" {
+			return web.NewErrBadRequest("ownership can be transferred only to a group member")
+		}
+
+		err = svc.checkNotPlaceholders(ctx, gid, []user.ID{uid}, "ownership cannot be transferred to a placeholder member")
+		if err != nil {
+			return err
+		}
+
+		if err = svc.bumpVersion(ctx, gid, version); err != nil {
+			return err
+		}
+
+		if err = svc.groups.TransferOwnership(ctx, gid, actorId, uid, user.RoleAdmin); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, svc.activity, gid, &actorId, activity.EventOwnershipTransferred,
+			activity.OwnershipPayload{FormerOwnerID: actorId, OwnerID: uid})
+	})
 }
 
-// DeleteGroup removes group
-func (svc GroupService) DeleteGroup(ctx context.Context, actorId user.ID, gid user.GroupID) error {
-	if err := svc.checkGroupActor(ctx, actorId, gid); err != nil {
+// checkNotPlaceholders checks that none of specified active group members is a placeholder.
+//
+// Placeholder cannot log in, so it shouldn't hold group management roles.
+func (svc GroupService) checkNotPlaceholders(ctx context.Context, gid user.GroupID, uids []user.ID, msg string) error {
+	members, err := svc.groups.GetGroupMembers(ctx, gid, false)
+	if err != nil {
 		return err
 	}
 
-	return svc.groups.DeleteGroup(ctx, gid)
+	for _, m := range members {
+		if m.Placeholder && containsID(uids, m.ID) {
+			return web.NewErrBadRequest(msg)
+		}
+	}
+
+	return nil
+}
+
+// ArchiveGroup makes group read-only and hides it from default group listing.
+//
+// If version is not nil, group is archived only if group version matches.
+func (svc GroupService) ArchiveGroup(ctx context.Context, actorId user.ID, gid user.GroupID, version *int64) (*user.Group, error) {
+	return svc.setArchived(ctx, actorId, gid, true, version)
+}
+
+// UnarchiveGroup restores archived group.
+//
+// If version is not nil, group is unarchived only if group version matches.
+func (svc GroupService) UnarchiveGroup(ctx context.Context, actorId user.ID, gid user.GroupID, version *int64) (*user.Group, error) {
+	return svc.setArchived(ctx, actorId, gid, false, version)
+}
+
+func (svc GroupService) setArchived(ctx context.Context, actorId user.ID, gid user.GroupID, archived bool, version *int64) (*user.Group, error) {
+	var grp *user.Group
+	err := svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := checkGroupRole(ctx, svc.groups, actorId, gid, user.PermEditGroup); err != nil {
+			return err
+		}
+
+		if err := svc.bumpVersion(ctx, gid, version); err != nil {
+			return err
+		}
+
+		g, err := svc.groups.GroupByID(ctx, gid)
+		if err != nil {
+			return err
+		}
+
+		switch {
+		case archived && g.IsArchived():
+			return web.NewErrBadRequest("group is already archived")
+		case !archived && !g.IsArchived():
+			return web.NewErrBadRequest("group is not archived")
+		}
+
+		if err = svc.groups.SetGroupArchived(ctx, gid, archived); err != nil {
+			return err
+		}
+
+		grp, err = svc.groups.GroupByID(ctx, gid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grp, nil
+}
+
+// DeleteGroup removes group.
+//
+// Group is soft-deleted and can be restored by owner during GroupRestoreWindow.
+// Permanent deletion is allowed only when all debts inside a group are settled.
+//
+// If version is not nil, group is removed only if group version matches.
+func (svc GroupService) DeleteGroup(ctx context.Context, actorId user.ID, gid user.GroupID, version *int64, permanent bool) error {
+	return svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if _, err := checkGroupRole(ctx, svc.groups, actorId, gid, user.PermDeleteGroup); err != nil {
+			return err
+		}
+
+		if err := svc.bumpVersion(ctx, gid, version); err != nil {
+			return err
+		}
+
+		if !permanent {
+			return svc.groups.SoftDeleteGroup(ctx, gid)
+		}
+
+		debts, err := svc.balances.GroupDebts(ctx, gid, nil)
+		if err != nil {
+			return fmt.Errorf("failed to get group debts: %w", err)
+		}
+
+		if len(debts) > 0 {
+			apiErr := web.NewErrConflict("group has outstanding balances, settle up before permanent deletion")
+			apiErr.Data = debts
+			return apiErr
+		}
+
+		return svc.groups.DeleteGroup(ctx, gid)
+	})
+}
+
+// RestoreGroup restores group deleted by owner during GroupRestoreWindow.
+func (svc GroupService) RestoreGroup(ctx context.Context, actorId user.ID, gid user.GroupID) (*user.Group, error) {
+	var grp *user.Group
+	err := svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		err := svc.groups.RestoreGroup(ctx, gid, actorId, time.Now().Add(-GroupRestoreWindow))
+		if err == ErrGroupNotFound {
+			return web.NewErrNotFound("deleted group not found or restore window is expired")
+		}
+
+		if err != nil {
+			return err
+		}
+
+		if err = svc.bumpVersion(ctx, gid, nil); err != nil {
+			return err
+		}
+
+		grp, err = svc.groups.GroupByID(ctx, gid)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return grp, nil
 }
 
 // GroupsByUser returns all groups where user is owner or member.
-func (svc GroupService) GroupsByUser(ctx context.Context, uid user.ID) (user.Groups, error) {
-	return svc.groups.GroupsByUser(ctx, uid)
+//
+// Archived groups are returned only if requested.
+func (svc GroupService) GroupsByUser(ctx context.Context, uid user.ID, includeArchived bool) (user.Groups, error) {
+	return svc.groups.GroupsByUser(ctx, uid, includeArchived)
 }
 
-// GetGroupInfo returns full group information
-func (svc GroupService) GetGroupInfo(ctx context.Context, gid user.GroupID) (*user.GroupInfo, error) {
+// GetGroupInfo returns full group information.
+//
+// Group information is available only to group members and administrators.
+func (svc GroupService) GetGroupInfo(ctx context.Context, actorId user.ID, gid user.GroupID) (*user.GroupInfo, error) {
+	if err := svc.checkReadAccess(ctx, actorId, gid); err != nil {
+		return nil, err
+	}
+
 	g, err := svc.groups.GroupByID(ctx, gid)
 	if err == ErrGroupNotFound {
 		return nil, web.NewErrNotFound("group not found")
@@ -157,7 +853,7 @@ func (svc GroupService) GetGroupInfo(ctx context.Context, gid user.GroupID) (*us
 		return nil, err
 	}
 
-	members, err := svc.groups.GetGroupMembers(ctx, gid)
+	members, err := svc.groups.GetGroupMembers(ctx, gid, false)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get group members: %w", err)
 	}
@@ -168,8 +864,75 @@ func (svc GroupService) GetGroupInfo(ctx context.Context, gid user.GroupID) (*us
 	}, nil
 }
 
-func (svc GroupService) ShareExpense(ctx context.Context, actorID user.ID, amount loan.Amount, gid user.GroupID) error {
-	members, err := svc.groups.GroupMemberIDs(ctx, gid)
+// GroupActivity returns page of group activity feed, newest events first.
+//
+// Activity feed is available only to group members and administrators.
+func (svc GroupService) GroupActivity(ctx context.Context, actorId user.ID, gid user.GroupID, f activity.Filter) (*activity.Page, error) {
+	f, err := checkActivityFilter(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = svc.checkReadAccess(ctx, actorId, gid); err != nil {
+		return nil, err
+	}
+
+	// Request one extra event to find out if there is a next page
+	limit := f.Limit
+	f.Limit++
+	events, err := svc.activity.GroupEvents(ctx, gid, f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group activity: %w", err)
+	}
+
+	page := activity.NewPage(events, limit)
+	return &page, nil
+}
+
+// ShareExpense splits expense between all group members.
+//
+// Expenses in currency other than group base currency are converted
+// using exchange rate at posting time.
+//
+// If currency is empty, group base currency is used.
+//
+// If expense date is set, expense is split between members who were active at that date,
+// and payer pays a share only if payer was a member at that date.
+// Otherwise, expense is split between current members.
+//
+// If event ID is set, expense is registered in group event and is split only between event participants.
+//
+// Members of a household are charged as a single participant with household weight,
+// and household share is charged to household holder.
+// If payer belongs to a household, payer's household is not charged and
+// household holder becomes a lender.
+func (svc GroupService) ShareExpense(ctx context.Context, actorID user.ID, amount model.Decimal, cur model.Currency, gid user.GroupID, spentAt *time.Time, eventID *user.EventID) error {
+	if amount.IsEmpty() {
+		return web.NewErrBadRequest("expense amount is required")
+	}
+
+	if spentAt != nil && spentAt.After(time.Now()) {
+		return web.NewErrBadRequest("expense date cannot be in the future")
+	}
+
+	// Expense and its loans are saved atomically.
+	// Group row is locked, so members list can't be changed until expense is saved.
+	return svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		return svc.shareExpense(ctx, actorID, amount, cur, gid, spentAt, eventID)
+	})
+}
+
+// shareExpense splits expense between group members as part of storage transaction.
+func (svc GroupService) shareExpense(ctx context.Context, actorID user.ID, amount model.Decimal, cur model.Currency, gid user.GroupID, spentAt *time.Time, eventID *user.EventID) error {
+	grp, err := svc.groups.GroupByIDForShare(ctx, gid)
+	if err == ErrGroupNotFound {
+		return web.NewErrNotFound("group not exists")
+	}
+	if err != nil {
+		return fmt.Errorf("failed to get group: %w", err)
+	}
+
+	members, err := svc.groups.GroupMemberIDs(ctx, gid, spentAt)
 	if err == ErrGroupNotFound {
 		return web.NewErrNotFound("group not exists")
 	}
@@ -177,41 +940,207 @@ func (svc GroupService) ShareExpense(ctx context.Context, actorID user.ID, amoun
 		return fmt.Errorf("failed to get group member list: %w", err)
 	}
 
-	// group should have at least 2 members: owner and guest
-	if len(members) < 2 {
-		return web.NewErrBadRequest("group is empty")
+	// Payer of backdated expense might join a group after expense date.
+	isActorMember := containsID(members, actorID)
+	if !isActorMember && spentAt == nil {
+		return web.NewErrForbidden("user is not a member of the group")
+	}
+
+	if _, err = svc.checkPermission(ctx, actorID, gid, user.PermPostExpense); err != nil {
+		return err
+	}
+
+	// Event expense is shared only between event participants,
+	// payer might not participate in event.
+	participants := members
+	if eventID != nil {
+		event, err := svc.events.EventByID(ctx, gid, *eventID)
+		if err != nil {
+			return err
+		}
+
+		participants = event.FilterParticipants(members)
+	}
+
+	households, err := svc.households.HouseholdsByGroup(ctx, gid)
+	if err != nil {
+		return fmt.Errorf("failed to get group households: %w", err)
 	}
 
-	// Select everyone except actor ID as debtor,
-	// simultaneously check if actor is part of group.
-	isActorMember := false
-	debtors := make([]user.ID, 0, len(members))
-	for _, uid := range members {
-		if uid.Bytes == actorID.Bytes {
-			isActorMember = true
+	// Payer's household shares balance with payer.
+	lender := actorID
+	if h := user.FindHousehold(households, actorID); h != nil {
+		lender = h.HolderID
+	}
+
+	// Select every split unit except payer's as debtor,
+	// simultaneously check if payer is participant.
+	isActorParticipant := false
+	actorWeight := 0
+	units := user.SplitUnits(participants, households)
+	debtors := make([]user.SplitUnit, 0, len(units))
+	for _, u := range units {
+		if u.HolderID.Bytes == lender.Bytes {
+			isActorParticipant = true
+			actorWeight = u.Weight
 			continue
 		}
 
-		debtors = append(debtors, uid)
+		debtors = append(debtors, u)
 	}
 
-	if !isActorMember {
-		return web.NewErrForbidden("user is not a member of the group")
+	// expense should be shared at least with one member except payer
+	if len(debtors) == 0 {
+		if eventID != nil {
+			return web.NewErrBadRequest("event has no participants except payer")
+		}
+
+		return web.NewErrBadRequest("group is empty")
 	}
 
-	// Calculate debt per member (including lender)
-	// All prices are represented in cents, and cent is a quantum value
+	// Payer pays his share too, unless group split mode excludes payer.
+	shares := 0
+	for _, u := range debtors {
+		shares += u.Weight
+	}
+
+	if isActorParticipant && grp.SplitMode != user.SplitExcludePayer {
+		shares += actorWeight
+	}
+
+	return svc.addExpense(ctx, grp, actorID, amount, cur, spentAt, eventID, lender, debtors, shares)
+}
+
+// addExpense registers a new expense and splits it between debtors.
+//
+// Expense is divided into specified number of equal shares,
+// share is rounded using group rounding policy.
+// Each debtor is charged by number of shares equal to debtor weight.
+func (svc GroupService) addExpense(ctx context.Context, grp *user.Group, actorID user.ID, amount model.Decimal, cur model.Currency, spentAt *time.Time, eventID *user.EventID, lender user.ID, debtors []user.SplitUnit, shares int) error {
+	exp, err := svc.newExpense(ctx, grp, actorID, amount, cur, spentAt, eventID)
+	if err != nil {
+		return err
+	}
+
+	// Calculate debt per share
+	// All prices are represented in minor units, and minor unit is a quantum value
 	// (in simple words - there is no thing like "half of cent", it's not Bitcoin).
 	//
 	// So final value should be rounded, or we gonna lose some money.
-	roundedDebt := math.Round(float64(amount) / float64(len(members)))
-	debtPerUser := loan.Amount(roundedDebt)
+	debtPerShare := model.NewMoney(exp.Amount, exp.Currency).DivRoundPolicy(int64(shares), grp.Rounding).Amount
+	if debtPerShare == 0 {
+		// Expense is saved in a transaction and rolled back.
+		return web.NewErrBadRequest("expense amount is too small to split")
+	}
+
+	debtorIDs := make([]user.ID, 0, len(debtors))
+	debts := make([]loan.ExpenseShare, 0, len(debtors))
+	for _, u := range debtors {
+		debt, err := model.NewMoney(debtPerShare, exp.Currency).Mul(int64(u.Weight))
+		if err != nil {
+			return err
+		}
+
+		debtorIDs = append(debtorIDs, u.HolderID)
+		debts = append(debts, loan.ExpenseShare{
+			DebtorID: u.HolderID,
+			Amount:   debt.Amount,
+		})
+	}
 
 	svc.log.Debug("adding a new loan",
 		zap.Any("actor_id", actorID),
-		zap.Int64("amount_total", amount),
-		zap.Int64("amount_per_user", debtPerUser),
-		zap.Any("debtors", debtors))
+		zap.Any("lender_id", lender),
+		zap.Any("expense_id", exp.ID),
+		zap.Int64("amount_total", exp.Amount),
+		zap.Int64("amount_per_share", debtPerShare),
+		zap.Stringer("currency", exp.Currency),
+		zap.Any("debts", debts))
+
+	if err = svc.loanAdder.AddExpenseLoans(ctx, exp, lender, debts); err != nil {
+		return err
+	}
+
+	return recordEvent(ctx, svc.activity, grp.ID, &actorID, activity.EventExpenseCreated, activity.ExpensePayload{
+		ExpenseID:        exp.ID,
+		EventID:          exp.EventID,
+		PayerID:          exp.PayerID,
+		Amount:           exp.Amount,
+		Currency:         exp.Currency,
+		OriginalAmount:   exp.OriginalAmount,
+		OriginalCurrency: exp.OriginalCurrency,
+		SpentAt:          exp.SpentAt,
+		Share:            debtPerShare,
+		Debtors:          debtorIDs,
+		Shares:           debts,
+	})
+}
+
+// newExpense converts expense amount to group base currency and registers a new expense.
+//
+// Expense date is set to current time if it's nil.
+func (svc GroupService) newExpense(ctx context.Context, grp *user.Group, payer user.ID, amount model.Decimal, cur model.Currency, spentAt *time.Time, eventID *user.EventID) (*loan.Expense, error) {
+	if cur == "" {
+		cur = grp.Currency
+	}
+
+	original, err := amount.Money(cur)
+	if err != nil {
+		return nil, err
+	}
+
+	if !original.IsPositive() {
+		return nil, web.NewErrBadRequest("expense amount should be positive")
+	}
+
+	rate, err := lookupRate(ctx, svc.rates, cur, grp.Currency)
+	if err == ErrNoRate {
+		return nil, web.NewErrBadRequest("no exchange rate available for %s/%s", cur, grp.Currency)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	converted, err := original.Convert(grp.Currency, rate)
+	if err != nil {
+		return nil, err
+	}
+
+	exp := loan.Expense{
+		GroupID:          grp.ID,
+		EventID:          eventID,
+		PayerID:          payer,
+		Amount:           converted.Amount,
+		Currency:         converted.Currency,
+		OriginalAmount:   original.Amount,
+		OriginalCurrency: original.Currency,
+		Rate:             rate,
+		SpentAt:          time.Now(),
+	}
+	if spentAt != nil {
+		exp.SpentAt = *spentAt
+	}
+
+	if exp.Amount <= 0 {
+		return nil, web.NewErrBadRequest("expense amount is too small")
+	}
+
+	id, err := svc.expenses.AddExpense(ctx, exp)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save expense: %w", err)
+	}
+
+	exp.ID = *id
+	return &exp, nil
+}
+
+// containsID checks if list contains user ID
+func containsID(ids []user.ID, uid user.ID) bool {
+	for _, id := range ids {
+		if id.Bytes == uid.Bytes {
+			return true
+		}
+	}
 
-	return svc.loanAdder.AddLoan(ctx, actorID, loan.Amount(roundedDebt), debtors)
+	return false
 }
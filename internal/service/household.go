@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgtype"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/web"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrHouseholdNotFound = web.NewErrNotFound("household not found")
+
+	// ErrMemberInHousehold is returned on attempt to add member to a second household inside a group.
+	ErrMemberInHousehold = web.NewErrConflict("member already belongs to a household")
+
+	// ErrHouseholdMember is returned on attempt to remove household member from a group.
+	ErrHouseholdMember = web.NewErrConflict("member belongs to a household, delete household first")
+)
+
+// HouseholdStore stores group households
+type HouseholdStore interface {
+	// CreateHousehold saves a new household with members list and returns created household.
+	//
+	// Returns ErrMemberInHousehold if any member already belongs to a household inside a group.
+	CreateHousehold(ctx context.Context, h user.Household) (*user.Household, error)
+
+	// DeleteHousehold deletes a household.
+	//
+	// Returns ErrHouseholdNotFound if there is no such household in a group.
+	DeleteHousehold(ctx context.Context, gid user.GroupID, hid user.HouseholdID) error
+
+	// HouseholdByID returns group household with members list.
+	//
+	// Returns ErrHouseholdNotFound if there is no such household in a group.
+	HouseholdByID(ctx context.Context, gid user.GroupID, hid user.HouseholdID) (*user.Household, error)
+
+	// HouseholdsByGroup returns all group households.
+	HouseholdsByGroup(ctx context.Context, gid user.GroupID) (user.Households, error)
+}
+
+// HouseholdService manages group households.
+//
+// Household is a set of group members which shares one balance inside a group.
+// Household is charged as a single expense participant with specified weight,
+// household debts are held by household holder.
+type HouseholdService struct {
+	log        *zap.Logger
+	groups     GroupManager
+	households HouseholdStore
+	admins     AdminChecker
+}
+
+// NewHouseholdService is HouseholdService constructor
+func NewHouseholdService(log *zap.Logger, groups GroupManager, households HouseholdStore, admins AdminChecker) *HouseholdService {
+	return &HouseholdService{
+		log:        log.Named("service.households"),
+		groups:     groups,
+		households: households,
+		admins:     admins,
+	}
+}
+
+// CreateHousehold creates a new household inside a group.
+//
+// Household should have at least two members, and each member can belong only to one household inside a group.
+// Household holder is the first member unless specified.
+// Balances existing before household creation are kept by members.
+//
+// Actor should be allowed to post expenses in a group and should be a household member,
+// group admins can create households for other members.
+func (svc HouseholdService) CreateHousehold(ctx context.Context, actorId user.ID, gid user.GroupID, h user.Household) (*user.Household, error) {
+	role, err := checkGroupPermission(ctx, svc.groups, actorId, gid, user.PermPostExpense)
+	if err != nil {
+		return nil, err
+	}
+
+	if h.Weight == 0 {
+		h.Weight = user.DefaultHouseholdWeight
+	}
+
+	if h.Weight < 0 || h.Weight > user.MaxHouseholdWeight {
+		return nil, web.NewErrBadRequest("household weight should be between 1 and %d", user.MaxHouseholdWeight)
+	}
+
+	existing, err := svc.households.HouseholdsByGroup(ctx, gid)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get group households: %w", err)
+	}
+
+	seen := make(map[[16]byte]struct{}, len(h.Members))
+	members := make([]user.ID, 0, len(h.Members))
+	for _, uid := range h.Members {
+		if _, ok := seen[uid.Bytes]; ok {
+			continue
+		}
+
+		memberRole, err := svc.groups.GetMemberRole(ctx, gid, uid)
+		if err != nil {
+			return nil, err
+		}
+
+		if memberRole == "" {
+			return nil, web.NewErrBadRequest("household members should be group members")
+		}
+
+		if user.FindHousehold(existing, uid) != nil {
+			return nil, ErrMemberInHousehold
+		}
+
+		seen[uid.Bytes] = struct{}{}
+		members = append(members, uid)
+	}
+
+	if len(members) < 2 {
+		return nil, web.NewErrBadRequest("household should have at least 2 members")
+	}
+
+	h.Members = members
+	if !h.HasMember(actorId) && !role.Can(user.PermManageMembers) {
+		return nil, web.NewErrForbidden("only group admins can create a household for other members")
+	}
+
+	if h.HolderID.Status != pgtype.Present {
+		h.HolderID = members[0]
+	} else if !h.HasMember(h.HolderID) {
+		return nil, web.NewErrBadRequest("household holder should be a household member")
+	}
+
+	h.GroupID = gid
+	h.CreatedBy = &actorId
+	household, err := svc.households.CreateHousehold(ctx, h)
+	if err == ErrMemberInHousehold {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to create household: %w", err)
+	}
+
+	svc.log.Info("household created", zap.Any("gid", gid),
+		zap.Any("hid", household.ID), zap.Any("actor", actorId))
+	return household, nil
+}
+
+// DeleteHousehold deletes a household.
+//
+// Household balance is kept by household holder.
+// Actor should be a household member or a group admin.
+func (svc HouseholdService) DeleteHousehold(ctx context.Context, actorId user.ID, gid user.GroupID, hid user.HouseholdID) error {
+	role, err := checkGroupPermission(ctx, svc.groups, actorId, gid, user.PermPostExpense)
+	if err != nil {
+		return err
+	}
+
+	h, err := svc.households.HouseholdByID(ctx, gid, hid)
+	if err != nil {
+		return err
+	}
+
+	if !h.HasMember(actorId) && !role.Can(user.PermManageMembers) {
+		return web.NewErrForbidden("only group admins can delete a household of other members")
+	}
+
+	if err = svc.households.DeleteHousehold(ctx, gid, hid); err != nil {
+		return err
+	}
+
+	svc.log.Info("household deleted", zap.Any("gid", gid),
+		zap.Any("hid", hid), zap.Any("actor", actorId))
+	return nil
+}
+
+// GroupHouseholds returns all group households.
+//
+// Households are available only to group members and administrators.
+func (svc HouseholdService) GroupHouseholds(ctx context.Context, actorId user.ID, gid user.GroupID) (user.Households, error) {
+	if err := checkGroupReadAccess(ctx, svc.groups, svc.admins, actorId, gid); err != nil {
+		return nil, err
+	}
+
+	return svc.households.HouseholdsByGroup(ctx, gid)
+}
+
+// GetHousehold returns group household.
+//
+// Households are available only to group members and administrators.
+func (svc HouseholdService) GetHousehold(ctx context.Context, actorId user.ID, gid user.GroupID, hid user.HouseholdID) (*user.Household, error) {
+	if err := checkGroupReadAccess(ctx, svc.groups, svc.admins, actorId, gid); err != nil {
+		return nil, err
+	}
+
+	return svc.households.HouseholdByID(ctx, gid, hid)
+}
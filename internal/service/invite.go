@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/x1unix/sbda-ledger/internal/model/activity"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/web"
+	"go.uber.org/zap"
+)
+
+const (
+	// DefaultInviteTTL is invite lifetime used when lifetime is not specified.
+	DefaultInviteTTL = 7 * 24 * time.Hour
+
+	// MaxInviteTTL is max invite lifetime.
+	MaxInviteTTL = 30 * 24 * time.Hour
+)
+
+var (
+	ErrInviteNotFound  = web.NewErrNotFound("invite not found or expired")
+	ErrInviteExhausted = web.NewErrBadRequest("invite usage limit is reached")
+)
+
+// InviteStore stores group invites
+type InviteStore interface {
+	// AddInvite saves a new invite.
+	//
+	// Invite is removed after expiration.
+	AddInvite(ctx context.Context, inv user.Invite) error
+
+	// GetInvite returns invite by token.
+	//
+	// Returns ErrInviteNotFound if invite doesn't exist or expired.
+	GetInvite(ctx context.Context, token string) (*user.Invite, error)
+
+	// GroupInvites returns list of active group invites.
+	GroupInvites(ctx context.Context, gid user.GroupID) (user.Invites, error)
+
+	// UseInvite increments invite uses counter and returns invite.
+	//
+	// Returns ErrInviteNotFound if invite doesn't exist or ErrInviteExhausted if usage limit is reached.
+	UseInvite(ctx context.Context, token string) (*user.Invite, error)
+
+	// ReleaseInvite decrements invite uses counter.
+	//
+	// Used to return invite use if join was failed.
+	ReleaseInvite(ctx context.Context, token string) error
+
+	// RemoveInvite revokes invite.
+	RemoveInvite(ctx context.Context, gid user.GroupID, token string) error
+}
+
+// PlaceholderClaimer replaces placeholder group members with registered users
+type PlaceholderClaimer interface {
+	// CheckPlaceholder checks if user is unclaimed placeholder and a member of a group.
+	CheckPlaceholder(ctx context.Context, gid user.GroupID, pid user.ID) error
+
+	// ClaimPlaceholder replaces placeholder with registered user.
+	ClaimPlaceholder(ctx context.Context, gid user.GroupID, pid, uid user.ID) error
+}
+
+// InviteParams is group invite parameters
+type InviteParams struct {
+	// Role is role of joined members. user.RoleMember is used if empty.
+	Role user.Role
+
+	// MaxUses is max number of invite uses. Zero value means unlimited.
+	MaxUses int
+
+	// TTL is invite lifetime. DefaultInviteTTL is used if zero.
+	TTL time.Duration
+
+	// PlaceholderID is ID of placeholder member claimed by invite (optional).
+	//
+	// Placeholder invite can be used only once.
+	PlaceholderID *user.ID
+}
+
+// InviteService manages group invitation links
+type InviteService struct {
+	log          *zap.Logger
+	groups       GroupManager
+	invites      InviteStore
+	placeholders PlaceholderClaimer
+	activity     ActivityRecorder
+	tx           Transactor
+}
+
+// NewInviteService is InviteService constructor
+func NewInviteService(log *zap.Logger, groups GroupManager, invites InviteStore, placeholders PlaceholderClaimer, activity ActivityRecorder, tx Transactor) *InviteService {
+	return &InviteService{
+		log:          log.Named("service.invites"),
+		groups:       groups,
+		invites:      invites,
+		placeholders: placeholders,
+		activity:     activity,
+		tx:           tx,
+	}
+}
+
+// CreateInvite creates a new group invite.
+//
+// Actor should be allowed to manage members with invite role.
+func (svc InviteService) CreateInvite(ctx context.Context, actorId user.ID, gid user.GroupID, params InviteParams) (*user.Invite, error) {
+	if params.Role == "" {
+		params.Role = user.RoleMember
+	}
+
+	if !params.Role.IsAssignable() {
+		return nil, web.NewErrBadRequest("invalid member role %q", params.Role)
+	}
+
+	if params.TTL == 0 {
+		params.TTL = DefaultInviteTTL
+	}
+
+	if params.TTL < 0 || params.TTL > MaxInviteTTL {
+		return nil, web.NewErrBadRequest("invite lifetime should be between 0 and %s", MaxInviteTTL)
+	}
+
+	if params.MaxUses < 0 {
+		return nil, web.NewErrBadRequest("invite max uses should not be negative")
+	}
+
+	if params.PlaceholderID != nil {
+		if params.MaxUses > 1 {
+			return nil, web.NewErrBadRequest("placeholder invite can be used only once")
+		}
+
+		params.MaxUses = 1
+	}
+
+	actorRole, err := checkGroupPermission(ctx, svc.groups, actorId, gid, user.PermManageMembers)
+	if err != nil {
+		return nil, err
+	}
+
+	if !actorRole.CanAssign(params.Role) {
+		return nil, web.NewErrForbidden("you have no right to manage group %ss", params.Role)
+	}
+
+	if params.PlaceholderID != nil {
+		if err = svc.placeholders.CheckPlaceholder(ctx, gid, *params.PlaceholderID); err != nil {
+			return nil, err
+		}
+	}
+
+	inv, err := user.NewInvite(gid, actorId, params.Role, params.MaxUses, params.TTL)
+	if err != nil {
+		return nil, err
+	}
+
+	inv.PlaceholderID = params.PlaceholderID
+
+	if err = svc.invites.AddInvite(ctx, *inv); err != nil {
+		return nil, err
+	}
+
+	return inv, nil
+}
+
+// GroupInvites returns list of active group invites
+func (svc InviteService) GroupInvites(ctx context.Context, actorId user.ID, gid user.GroupID) (user.Invites, error) {
+	if _, err := checkGroupRole(ctx, svc.groups, actorId, gid, user.PermManageMembers); err != nil {
+		return nil, err
+	}
+
+	return svc.invites.GroupInvites(ctx, gid)
+}
+
+// RevokeInvite removes group invite
+func (svc InviteService) RevokeInvite(ctx context.Context, actorId user.ID, gid user.GroupID, token string) error {
+	actorRole, err := checkGroupRole(ctx, svc.groups, actorId, gid, user.PermManageMembers)
+	if err != nil {
+		return err
+	}
+
+	inv, err := svc.invites.GetInvite(ctx, token)
+	if err != nil {
+		return err
+	}
+
+	if inv.GroupID != gid {
+		return ErrInviteNotFound
+	}
+
+	if !actorRole.CanAssign(inv.Role) {
+		return web.NewErrForbidden("you have no right to manage group %ss", inv.Role)
+	}
+
+	return svc.invites.RemoveInvite(ctx, gid, token)
+}
+
+// AcceptInvite adds actor to invite group and returns group.
+//
+// If invite claims a placeholder, actor takes placeholder place in a group instead.
+// Group member can claim a placeholder too, in this case member role is kept.
+func (svc InviteService) AcceptInvite(ctx context.Context, actorId user.ID, token string) (*user.Group, error) {
+	inv, err := svc.invites.GetInvite(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := svc.groups.GetMemberRole(ctx, inv.GroupID, actorId)
+	if err == ErrGroupNotFound {
+		return nil, ErrInviteNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if role != "" && inv.PlaceholderID == nil {
+		return nil, web.NewErrBadRequest("user is already a member of the group")
+	}
+
+	// Use is counted before join to not exceed invite usage limit
+	// by concurrent requests, and returned back if join was failed.
+	inv, err = svc.invites.UseInvite(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+
+	var grp *user.Group
+	err = svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := bumpGroupVersion(ctx, svc.groups, inv.GroupID, nil); err != nil {
+			return err
+		}
+
+		grp, err = svc.groups.GroupByID(ctx, inv.GroupID)
+		if err != nil {
+			return err
+		}
+
+		if grp.IsArchived() {
+			return ErrGroupArchived
+		}
+
+		if inv.PlaceholderID != nil {
+			return svc.placeholders.ClaimPlaceholder(ctx, inv.GroupID, *inv.PlaceholderID, actorId)
+		}
+
+		if err = svc.groups.AddGroupUsers(ctx, inv.GroupID, []user.ID{actorId}, inv.Role); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, svc.activity, inv.GroupID, &actorId, activity.EventMemberAdded,
+			activity.MembersPayload{Members: []user.ID{actorId}, Role: inv.Role})
+	})
+	if err != nil {
+		if rErr := svc.invites.ReleaseInvite(ctx, token); rErr != nil {
+			svc.log.Error("failed to release invite use", zap.Error(rErr), zap.String("group", user.IDToString(inv.GroupID)))
+		}
+
+		return nil, err
+	}
+
+	return grp, nil
+}
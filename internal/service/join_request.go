@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/x1unix/sbda-ledger/internal/mail"
+	"github.com/x1unix/sbda-ledger/internal/model/activity"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/web"
+	"go.uber.org/zap"
+)
+
+var (
+	ErrJoinRequestExists   = web.NewErrBadRequest("join request is already pending")
+	ErrJoinRequestNotFound = web.NewErrNotFound("join request not found or already decided")
+)
+
+// JoinRequestStore stores group join requests
+type JoinRequestStore interface {
+	// AddJoinRequest saves a new pending join request.
+	//
+	// Returns ErrJoinRequestExists if user already has pending request to the group.
+	AddJoinRequest(ctx context.Context, gid user.GroupID, uid user.ID, msg string) (*user.JoinRequest, error)
+
+	// GroupJoinRequests returns group join requests with specified status.
+	//
+	// Requests with any status are returned if status is empty.
+	GroupJoinRequests(ctx context.Context, gid user.GroupID, status user.JoinRequestStatus) (user.JoinRequests, error)
+
+	// UserJoinRequests returns all join requests of a user.
+	UserJoinRequests(ctx context.Context, uid user.ID) (user.JoinRequests, error)
+
+	// DecideJoinRequest sets status of pending join request and returns updated request.
+	//
+	// Returns ErrJoinRequestNotFound if there is no pending request with specified ID in a group.
+	DecideJoinRequest(ctx context.Context, gid user.GroupID, rid user.JoinRequestID, actor user.ID, status user.JoinRequestStatus) (*user.JoinRequest, error)
+}
+
+// UserProvider provides user information
+type UserProvider interface {
+	// UserByID returns user by ID
+	UserByID(ctx context.Context, uid user.ID) (*user.User, error)
+}
+
+// JoinRequestService manages requests to join a group
+type JoinRequestService struct {
+	log      *zap.Logger
+	groups   GroupManager
+	requests JoinRequestStore
+	users    UserProvider
+	mailer   Mailer
+	activity ActivityRecorder
+	tx       Transactor
+}
+
+// NewJoinRequestService is JoinRequestService constructor
+func NewJoinRequestService(log *zap.Logger, groups GroupManager, requests JoinRequestStore, users UserProvider, mailer Mailer, activity ActivityRecorder, tx Transactor) *JoinRequestService {
+	return &JoinRequestService{
+		log:      log.Named("service.join_requests"),
+		groups:   groups,
+		requests: requests,
+		users:    users,
+		mailer:   mailer,
+		activity: activity,
+		tx:       tx,
+	}
+}
+
+// RequestJoin creates a request to join a group.
+//
+// Groups which don't accept join requests are reported as not found,
+// so group existence is not revealed to outsiders.
+func (svc JoinRequestService) RequestJoin(ctx context.Context, actorId user.ID, gid user.GroupID, msg string) (*user.JoinRequest, error) {
+	grp, err := svc.groups.GroupByID(ctx, gid)
+	if err == ErrGroupNotFound || (err == nil && !grp.JoinPolicy.AcceptsRequests()) {
+		return nil, web.NewErrNotFound("group not found")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	role, err := svc.groups.GetMemberRole(ctx, gid, actorId)
+	if err == ErrGroupNotFound {
+		return nil, web.NewErrNotFound("group not found")
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if role != "" {
+		return nil, web.NewErrBadRequest("user is already a member of the group")
+	}
+
+	if grp.IsArchived() {
+		return nil, ErrGroupArchived
+	}
+
+	return svc.requests.AddJoinRequest(ctx, gid, actorId, msg)
+}
+
+// GroupJoinRequests returns group join requests with specified status.
+//
+// Only group owner and admins can see join requests.
+func (svc JoinRequestService) GroupJoinRequests(ctx context.Context, actorId user.ID, gid user.GroupID, status user.JoinRequestStatus) (user.JoinRequests, error) {
+	if _, err := checkGroupRole(ctx, svc.groups, actorId, gid, user.PermManageMembers); err != nil {
+		return nil, err
+	}
+
+	return svc.requests.GroupJoinRequests(ctx, gid, status)
+}
+
+// UserJoinRequests returns join requests of a user.
+//
+// Requester is notified about decision by email, request status can be polled as well.
+func (svc JoinRequestService) UserJoinRequests(ctx context.Context, uid user.ID) (user.JoinRequests, error) {
+	return svc.requests.UserJoinRequests(ctx, uid)
+}
+
+// ApproveJoinRequest approves pending join request and adds requester to a group as a member.
+//
+// Requester is notified about decision by email.
+func (svc JoinRequestService) ApproveJoinRequest(ctx context.Context, actorId user.ID, gid user.GroupID, rid user.JoinRequestID) (*user.JoinRequest, error) {
+	var req *user.JoinRequest
+	err := svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		actorRole, err := checkGroupPermission(ctx, svc.groups, actorId, gid, user.PermManageMembers)
+		if err != nil {
+			return err
+		}
+
+		if !actorRole.CanAssign(user.RoleMember) {
+			return web.NewErrForbidden("you have no right to manage group %ss", user.RoleMember)
+		}
+
+		req, err = svc.requests.DecideJoinRequest(ctx, gid, rid, actorId, user.JoinRequestApproved)
+		if err != nil {
+			return err
+		}
+
+		// Requester is notified only if decision is committed.
+		decided := *req
+		svc.tx.AfterCommit(ctx, func() {
+			svc.notifyDecision(context.Background(), decided)
+		})
+
+		// User might join a group using invite while request was pending.
+		role, err := svc.groups.GetMemberRole(ctx, gid, req.UserID)
+		if err != nil {
+			return err
+		}
+
+		if role != "" {
+			return nil
+		}
+
+		if err = bumpGroupVersion(ctx, svc.groups, gid, nil); err != nil {
+			return err
+		}
+
+		if err = svc.groups.AddGroupUsers(ctx, gid, []user.ID{req.UserID}, user.RoleMember); err != nil {
+			return err
+		}
+
+		return recordEvent(ctx, svc.activity, gid, &actorId, activity.EventMemberAdded,
+			activity.MembersPayload{Members: []user.ID{req.UserID}, Role: user.RoleMember})
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	svc.log.Info("join request approved", zap.Any("gid", gid),
+		zap.Any("uid", req.UserID), zap.Any("actor", actorId))
+	return req, nil
+}
+
+// DenyJoinRequest rejects pending join request.
+//
+// Requester is notified about decision by email.
+func (svc JoinRequestService) DenyJoinRequest(ctx context.Context, actorId user.ID, gid user.GroupID, rid user.JoinRequestID) (*user.JoinRequest, error) {
+	if _, err := checkGroupRole(ctx, svc.groups, actorId, gid, user.PermManageMembers); err != nil {
+		return nil, err
+	}
+
+	req, err := svc.requests.DecideJoinRequest(ctx, gid, rid, actorId, user.JoinRequestDenied)
+	if err != nil {
+		return nil, err
+	}
+
+	svc.log.Info("join request denied", zap.Any("gid", gid),
+		zap.Any("uid", req.UserID), zap.Any("actor", actorId))
+	svc.notifyDecision(ctx, *req)
+	return req, nil
+}
+
+// notifyDecision sends join request decision to requester by email.
+//
+// Email is sent using the same Mailer as email change confirmation,
+// so join request notifications depend on mail settings in config.
+// Decision is already saved, so notification errors are only logged.
+func (svc JoinRequestService) notifyDecision(ctx context.Context, req user.JoinRequest) {
+	if err := svc.sendDecision(ctx, req); err != nil {
+		svc.log.Error("failed to notify about join request decision", zap.Error(err),
+			zap.Any("gid", req.GroupID), zap.Any("uid", req.UserID))
+	}
+}
+
+func (svc JoinRequestService) sendDecision(ctx context.Context, req user.JoinRequest) error {
+	usr, err := svc.users.UserByID(ctx, req.UserID)
+	if err != nil {
+		return err
+	}
+
+	grp, err := svc.groups.GroupByID(ctx, req.GroupID)
+	if err != nil {
+		return err
+	}
+
+	return svc.mailer.SendMail(ctx, mail.Message{
+		To:      usr.Email,
+		Subject: fmt.Sprintf("Your request to join %q is %s", grp.Name, req.Status),
+		Body: fmt.Sprintf("Your request to join group %q is %s.\r\n\r\nGroup ID: %s\r\n",
+			grp.Name, req.Status, user.IDToString(grp.ID)),
+	})
+}
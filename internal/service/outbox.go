@@ -0,0 +1,138 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/x1unix/sbda-ledger/internal/model/balance"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"go.uber.org/zap"
+)
+
+const (
+	outboxBatchSize = 100
+
+	// OutboxPollInterval is default interval between outbox checks.
+	OutboxPollInterval = 5 * time.Second
+
+	// outboxGracePeriod is time given to apply event right after commit,
+	// before event is picked up by outbox worker.
+	outboxGracePeriod = 10 * time.Second
+)
+
+// OutboxStorage stores balance cache update events.
+//
+// Events are written in the same database transaction as journal postings.
+type OutboxStorage interface {
+	// PendingEvents returns events created before specified time.
+	PendingEvents(ctx context.Context, before time.Time, limit int) ([]balance.Event, error)
+
+	// DeleteEvent removes applied event.
+	DeleteEvent(ctx context.Context, tid loan.TransactionID, uid user.ID) error
+
+	// MarkEventFailed increments number of failed attempts to apply event.
+	MarkEventFailed(ctx context.Context, tid loan.TransactionID, uid user.ID) error
+}
+
+// BalanceOutbox applies balance cache update events from outbox.
+//
+// Events are delivered at least once, cache storage is responsible for
+// ignoring already applied events.
+type BalanceOutbox struct {
+	log    *zap.Logger
+	events OutboxStorage
+	cache  BalanceStorage
+}
+
+// NewBalanceOutbox is BalanceOutbox constructor
+func NewBalanceOutbox(log *zap.Logger, events OutboxStorage, cache BalanceStorage) *BalanceOutbox {
+	return &BalanceOutbox{
+		log:    log.Named("service.outbox"),
+		events: events,
+		cache:  cache,
+	}
+}
+
+// Apply applies event to balance cache and removes it from outbox.
+//
+// If event can't be applied, user balance cache is dropped and
+// will be repopulated on next read.
+// Event is kept in outbox only if cache is unavailable.
+func (o BalanceOutbox) Apply(ctx context.Context, e balance.Event) error {
+	applied, err := o.cache.ApplyEvent(ctx, e)
+	if err != nil {
+		o.log.Error("failed to apply balance update event",
+			zap.Error(err), zap.Any("uid", e.UserID), zap.Any("transaction_id", e.TransactionID))
+
+		// User cache possibly borked, try to truncate it
+		if clearErr := o.cache.ClearBalance(ctx, e.UserID); clearErr != nil {
+			if markErr := o.events.MarkEventFailed(ctx, e.TransactionID, e.UserID); markErr != nil {
+				o.log.Error("failed to mark event as failed", zap.Error(markErr),
+					zap.Any("uid", e.UserID), zap.Any("transaction_id", e.TransactionID))
+			}
+			return fmt.Errorf("failed to apply balance update event: %w", err)
+		}
+	}
+
+	if !applied && err == nil {
+		o.log.Debug("balance update event already applied",
+			zap.Any("uid", e.UserID), zap.Any("transaction_id", e.TransactionID))
+	}
+
+	if err := o.events.DeleteEvent(ctx, e.TransactionID, e.UserID); err != nil {
+		return fmt.Errorf("failed to remove balance update event from outbox: %w", err)
+	}
+
+	return nil
+}
+
+// Flush applies all events which were not applied during grace period.
+//
+// Returns number of applied events.
+func (o BalanceOutbox) Flush(ctx context.Context) (int, error) {
+	count := 0
+	for {
+		events, err := o.events.PendingEvents(ctx, time.Now().Add(-outboxGracePeriod), outboxBatchSize)
+		if err != nil {
+			return count, fmt.Errorf("failed to get pending events: %w", err)
+		}
+
+		for _, e := range events {
+			if err = o.Apply(ctx, e); err != nil {
+				// Cache is probably unavailable, try next time
+				return count, err
+			}
+			count++
+		}
+
+		if len(events) < outboxBatchSize {
+			return count, nil
+		}
+	}
+}
+
+// Start starts outbox worker which periodically applies pending events.
+//
+// Blocks until context is cancelled.
+func (o BalanceOutbox) Start(ctx context.Context, interval time.Duration) {
+	o.log.Info("started balance outbox worker", zap.Duration("interval", interval))
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			n, err := o.Flush(ctx)
+			if err != nil && ctx.Err() == nil {
+				o.log.Error("failed to flush balance outbox", zap.Error(err))
+			}
+
+			if n > 0 {
+				o.log.Info("applied pending balance update events", zap.Int("count", n))
+			}
+		}
+	}
+}
@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/x1unix/sbda-ledger/internal/model/activity"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/web"
+	"go.uber.org/zap"
+)
+
+var ErrPlaceholderNotFound = web.NewErrNotFound("placeholder member not found or already claimed")
+
+// PlaceholderStore stores placeholder users
+type PlaceholderStore interface {
+	// AddPlaceholder creates a new placeholder user without email and password.
+	AddPlaceholder(ctx context.Context, name string) (*user.ID, error)
+
+	// UserByID returns user by ID
+	UserByID(ctx context.Context, uid user.ID) (*user.User, error)
+
+	// ClaimPlaceholder marks placeholder as claimed by user and replaces placeholder
+	// with user in all groups where placeholder is a member or owner.
+	//
+	// Returns ErrPlaceholderNotFound if placeholder doesn't exist or already claimed.
+	ClaimPlaceholder(ctx context.Context, pid, uid user.ID) error
+}
+
+// PlaceholderBalanceStorage provides user balance in each group
+type PlaceholderBalanceStorage interface {
+	// UserGroupBalances returns non-zero user balance for each group, including balance outside groups.
+	UserGroupBalances(ctx context.Context, uid user.ID) ([]loan.GroupBalance, error)
+}
+
+// PlaceholderService manages placeholder group members.
+//
+// Placeholder is unregistered user which shares group expenses like a regular member
+// until it's claimed by registered user.
+type PlaceholderService struct {
+	log       *zap.Logger
+	groups    GroupManager
+	users     PlaceholderStore
+	loanAdder LoanAdder
+	balances  PlaceholderBalanceStorage
+	cache     BalanceStorage
+	activity  ActivityRecorder
+	tx        Transactor
+}
+
+// NewPlaceholderService is PlaceholderService constructor
+func NewPlaceholderService(log *zap.Logger, groups GroupManager, users PlaceholderStore, loanAdder LoanAdder, balances PlaceholderBalanceStorage, cache BalanceStorage, activity ActivityRecorder, tx Transactor) *PlaceholderService {
+	return &PlaceholderService{
+		log:       log.Named("service.placeholders"),
+		groups:    groups,
+		users:     users,
+		loanAdder: loanAdder,
+		balances:  balances,
+		cache:     cache,
+		activity:  activity,
+		tx:        tx,
+	}
+}
+
+// AddPlaceholder creates a new placeholder and adds it to a group as a member.
+//
+// Actor should be allowed to manage group members.
+func (svc PlaceholderService) AddPlaceholder(ctx context.Context, actorId user.ID, gid user.GroupID, name string) (*user.Member, error) {
+	var member *user.Member
+	err := svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		actorRole, err := checkGroupPermission(ctx, svc.groups, actorId, gid, user.PermManageMembers)
+		if err != nil {
+			return err
+		}
+
+		if !actorRole.CanAssign(user.RoleMember) {
+			return web.NewErrForbidden("you have no right to manage group %ss", user.RoleMember)
+		}
+
+		if err = bumpGroupVersion(ctx, svc.groups, gid, nil); err != nil {
+			return err
+		}
+
+		pid, err := svc.users.AddPlaceholder(ctx, name)
+		if err != nil {
+			return fmt.Errorf("failed to create placeholder: %w", err)
+		}
+
+		if err = svc.groups.AddGroupUsers(ctx, gid, []user.ID{*pid}, user.RoleMember); err != nil {
+			return err
+		}
+
+		err = recordEvent(ctx, svc.activity, gid, &actorId, activity.EventMemberAdded,
+			activity.MembersPayload{Members: []user.ID{*pid}, Role: user.RoleMember})
+		if err != nil {
+			return err
+		}
+
+		member = &user.Member{
+			User: user.User{
+				ID:          *pid,
+				Props:       user.Props{Name: name},
+				Placeholder: true,
+			},
+			Role:     user.RoleMember,
+			JoinedAt: time.Now(),
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	svc.log.Info("placeholder added", zap.Any("gid", gid),
+		zap.Any("pid", member.ID), zap.Any("actor", actorId))
+	return member, nil
+}
+
+// CheckPlaceholder checks if user is unclaimed placeholder and a member of a group.
+//
+// Returns ErrPlaceholderNotFound if check fails.
+func (svc PlaceholderService) CheckPlaceholder(ctx context.Context, gid user.GroupID, pid user.ID) error {
+	role, err := svc.groups.GetMemberRole(ctx, gid, pid)
+	if err == ErrGroupNotFound {
+		return ErrPlaceholderNotFound
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if role == "" {
+		return ErrPlaceholderNotFound
+	}
+
+	usr, err := svc.users.UserByID(ctx, pid)
+	if err != nil {
+		return err
+	}
+
+	if !usr.IsUnclaimedPlaceholder() {
+		return ErrPlaceholderNotFound
+	}
+
+	return nil
+}
+
+// ClaimPlaceholder replaces placeholder with registered user.
+//
+// User takes placeholder place in groups and whole placeholder balance is moved to user
+// by transfer transactions, as journal postings are immutable.
+// Placeholder balance cache is dropped after claim.
+func (svc PlaceholderService) ClaimPlaceholder(ctx context.Context, gid user.GroupID, pid, uid user.ID) error {
+	if pid.Bytes == uid.Bytes {
+		return ErrPlaceholderNotFound
+	}
+
+	err := svc.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		if err := svc.CheckPlaceholder(ctx, gid, pid); err != nil {
+			return err
+		}
+
+		if err := svc.users.ClaimPlaceholder(ctx, pid, uid); err != nil {
+			return err
+		}
+
+		balance, err := svc.balances.UserGroupBalances(ctx, pid)
+		if err != nil {
+			return fmt.Errorf("failed to get placeholder balance: %w", err)
+		}
+
+		for _, t := range loan.BalanceClaim(pid, uid, balance) {
+			if _, err = svc.loanAdder.AddTransaction(ctx, t); err != nil {
+				return fmt.Errorf("failed to transfer placeholder balance: %w", err)
+			}
+		}
+
+		// Group member which claimed a placeholder keeps own role.
+		role, err := svc.groups.GetMemberRole(ctx, gid, uid)
+		if err != nil {
+			return err
+		}
+
+		err = recordEvent(ctx, svc.activity, gid, &uid, activity.EventMemberAdded,
+			activity.MembersPayload{Members: []user.ID{uid}, Role: role, PlaceholderID: &pid})
+		if err != nil {
+			return err
+		}
+
+		// Registered after balance updates, so cache is cleared after them.
+		svc.tx.AfterCommit(ctx, func() {
+			if err := svc.cache.ClearBalance(context.Background(), pid); err != nil {
+				svc.log.Error("failed to clear placeholder balance cache",
+					zap.Error(err), zap.Any("pid", pid))
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	svc.log.Info("placeholder claimed", zap.Any("gid", gid),
+		zap.Any("pid", pid), zap.Any("uid", uid))
+	return nil
+}
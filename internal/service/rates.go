@@ -0,0 +1,51 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/x1unix/sbda-ledger/internal/model"
+)
+
+var (
+	ErrNoRate = errors.New("exchange rate not available")
+)
+
+// RateProvider provides currency exchange rates.
+type RateProvider interface {
+	// ExchangeRate returns amount of quote currency for 1 unit of base currency.
+	//
+	// Returns ErrNoRate if provider has no rate for currency pair.
+	ExchangeRate(ctx context.Context, base, quote model.Currency) (float64, error)
+}
+
+// lookupRate returns exchange rate from one currency to another.
+//
+// If provider doesn't have a rate for currency pair,
+// rate for reverse currency pair is used.
+func lookupRate(ctx context.Context, p RateProvider, from, to model.Currency) (float64, error) {
+	if from == to {
+		return 1, nil
+	}
+
+	rate, err := p.ExchangeRate(ctx, from, to)
+	if err == nil {
+		return rate, nil
+	}
+
+	if err != ErrNoRate {
+		return 0, fmt.Errorf("failed to get %s/%s exchange rate: %w", from, to, err)
+	}
+
+	rate, err = p.ExchangeRate(ctx, to, from)
+	if err == ErrNoRate {
+		return 0, err
+	}
+
+	if err != nil {
+		return 0, fmt.Errorf("failed to get %s/%s exchange rate: %w", to, from, err)
+	}
+
+	return 1 / rate, nil
+}
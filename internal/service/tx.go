@@ -0,0 +1,20 @@
+package service
+
+import "context"
+
+// Transactor runs multiple storage calls atomically.
+type Transactor interface {
+	// WithinTransaction runs function in a single storage transaction.
+	//
+	// Storage calls which use context passed to function are part of transaction.
+	// Transaction is committed if function returns no error, otherwise it's rolled back.
+	// Nested calls are executed as part of outer transaction.
+	WithinTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+
+	// AfterCommit schedules function call after transaction from context is committed.
+	//
+	// Used for side effects which can't be rolled back (cache updates, etc).
+	// Function is called immediately if context has no transaction
+	// and never called if transaction is rolled back.
+	AfterCommit(ctx context.Context, fn func())
+}
@@ -4,16 +4,23 @@ import (
 	"context"
 	"fmt"
 	"strings"
+	"time"
 
+	"github.com/x1unix/sbda-ledger/internal/mail"
 	"github.com/x1unix/sbda-ledger/internal/model"
 	"github.com/x1unix/sbda-ledger/internal/model/user"
 	"github.com/x1unix/sbda-ledger/internal/web"
 	"go.uber.org/zap"
 )
 
+// EmailChangeTTL is lifetime of email change confirmation token.
+const EmailChangeTTL = 24 * time.Hour
+
 var (
 	ErrNotExists = web.NewErrBadRequest("record not found")
 	ErrExists    = web.NewErrBadRequest("record already exists")
+
+	ErrEmailChangeNotFound = web.NewErrNotFound("email change not found or expired")
 )
 
 // UserStorage provides user storage
@@ -33,19 +40,77 @@ type UserStorage interface {
 	AllUsers(ctx context.Context) (user.Users, error)
 
 	// Exists checks if user with specified email exists
-	Exists(email string) (bool, error)
+	Exists(ctx context.Context, email string) (bool, error)
+
+	// UpdateUser replaces user name and email.
+	//
+	// Returns ErrExists if email is already taken by another user.
+	UpdateUser(ctx context.Context, uid user.ID, props user.Props) error
+}
+
+// EmailChangeStore stores pending email changes
+type EmailChangeStore interface {
+	// AddEmailChange saves email change and replaces previous pending email change of the user.
+	AddEmailChange(ctx context.Context, c user.EmailChange) error
+
+	// EmailChange returns email change by token.
+	//
+	// Returns ErrEmailChangeNotFound if change doesn't exist or expired.
+	EmailChange(ctx context.Context, token string) (*user.EmailChange, error)
+
+	// RemoveEmailChange removes email change.
+	//
+	// Returns ErrEmailChangeNotFound if change was already removed.
+	RemoveEmailChange(ctx context.Context, c user.EmailChange) error
+}
+
+// Mailer sends emails
+type Mailer interface {
+	// SendMail sends email message
+	SendMail(ctx context.Context, msg mail.Message) error
 }
 
 type UsersService struct {
-	log   *zap.Logger
-	store UserStorage
+	log     *zap.Logger
+	store   UserStorage
+	changes EmailChangeStore
+	mailer  Mailer
+	tx      Transactor
+	admins  map[string]struct{}
 }
 
-func NewUsersService(log *zap.Logger, store UserStorage) *UsersService {
+// NewUsersService is UsersService constructor.
+//
+// Users with email from admin emails list have administrative access.
+func NewUsersService(log *zap.Logger, store UserStorage, changes EmailChangeStore, mailer Mailer, tx Transactor, adminEmails []string) *UsersService {
+	admins := make(map[string]struct{}, len(adminEmails))
+	for _, email := range adminEmails {
+		admins[strings.ToLower(strings.TrimSpace(email))] = struct{}{}
+	}
+
 	return &UsersService{
-		log:   log.Named("service.users"),
-		store: store,
+		log:     log.Named("service.users"),
+		store:   store,
+		changes: changes,
+		mailer:  mailer,
+		tx:      tx,
+		admins:  admins,
+	}
+}
+
+// IsAdmin checks if user has administrative access
+func (s UsersService) IsAdmin(ctx context.Context, uid user.ID) (bool, error) {
+	if len(s.admins) == 0 {
+		return false, nil
+	}
+
+	usr, err := s.store.UserByID(ctx, uid)
+	if err != nil {
+		return false, fmt.Errorf("failed to get user: %w", err)
 	}
+
+	_, ok := s.admins[strings.ToLower(usr.Email)]
+	return ok, nil
 }
 
 // GetAll returns all users
@@ -77,9 +142,86 @@ func (s UsersService) AddUser(ctx context.Context, usrReg user.Registration) (*u
 		return nil, err
 	}
 
-	// TODO: should I do this in single isolated transaction? 🤔
 	usrReg.Email = strings.ToLower(usrReg.Email)
-	exists, err := s.store.Exists(usrReg.Email)
+	usr := user.User{Props: usrReg.Props}
+	if err := usr.SetPassword(usrReg.Password); err != nil {
+		return nil, err
+	}
+
+	err := s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		exists, err := s.store.Exists(ctx, usrReg.Email)
+		if err != nil {
+			return fmt.Errorf("can't check if user exists: %w", err)
+		}
+
+		if exists {
+			return ErrExists
+		}
+
+		uid, err := s.store.AddUser(ctx, usr)
+		if err == ErrExists {
+			// User was registered by concurrent request
+			return err
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to create new user %q: %w", usrReg.Email, err)
+		}
+
+		usr.ID = *uid
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &usr, nil
+}
+
+// UpdateProfile changes user name and email.
+//
+// Name is changed immediately. Email change stays pending until user confirms it
+// with a token sent to a new email (see ConfirmEmail).
+// Pending email change is returned if email change was requested.
+func (s UsersService) UpdateProfile(ctx context.Context, uid user.ID, upd user.ProfileUpdate) (*user.User, *user.EmailChange, error) {
+	if upd.IsEmpty() {
+		return nil, nil, web.NewErrBadRequest("no profile fields to update")
+	}
+
+	usr, err := s.store.UserByID(ctx, uid)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	props := upd.Apply(usr.Props)
+	props.Email = strings.ToLower(props.Email)
+	if err = model.Validate(props); err != nil {
+		return nil, nil, err
+	}
+
+	var change *user.EmailChange
+	if props.Email != usr.Email {
+		if change, err = s.requestEmailChange(ctx, uid, props.Email); err != nil {
+			return nil, nil, err
+		}
+
+		props.Email = usr.Email
+	}
+
+	if props.Name != usr.Name {
+		if err = s.store.UpdateUser(ctx, uid, props); err != nil {
+			return nil, nil, fmt.Errorf("failed to update user: %w", err)
+		}
+
+		usr.Props = props
+	}
+
+	return usr, change, nil
+}
+
+// requestEmailChange saves pending email change and sends confirmation token to a new email.
+func (s UsersService) requestEmailChange(ctx context.Context, uid user.ID, email string) (*user.EmailChange, error) {
+	exists, err := s.store.Exists(ctx, email)
 	if err != nil {
 		return nil, fmt.Errorf("can't check if user exists: %w", err)
 	}
@@ -88,16 +230,81 @@ func (s UsersService) AddUser(ctx context.Context, usrReg user.Registration) (*u
 		return nil, ErrExists
 	}
 
-	usr := user.User{Props: usrReg.Props}
-	if err = usr.SetPassword(usrReg.Password); err != nil {
+	change, err := user.NewEmailChange(uid, email, EmailChangeTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	if err = s.changes.AddEmailChange(ctx, *change); err != nil {
+		return nil, err
+	}
+
+	err = s.mailer.SendMail(ctx, mail.Message{
+		To:      email,
+		Subject: "Confirm your new email",
+		Body: fmt.Sprintf("Use the token below to confirm your new email address.\r\n"+
+			"The token expires at %s.\r\n\r\nConfirmation token: %s\r\n",
+			change.ExpiresAt.Format(time.RFC1123), change.Token),
+	})
+	if err != nil {
 		return nil, err
 	}
 
-	uid, err := s.store.AddUser(ctx, usr)
+	s.log.Info("email change requested", zap.Any("uid", uid))
+	return change, nil
+}
+
+// ConfirmEmail applies pending email change of a user.
+func (s UsersService) ConfirmEmail(ctx context.Context, uid user.ID, token string) (*user.User, error) {
+	change, err := s.changes.EmailChange(ctx, token)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create new user %q: %w", usrReg.Email, err)
+		return nil, err
 	}
 
-	usr.ID = *uid
-	return &usr, nil
+	if change.UserID.Bytes != uid.Bytes {
+		return nil, ErrEmailChangeNotFound
+	}
+
+	var usr *user.User
+	err = s.tx.WithinTransaction(ctx, func(ctx context.Context) error {
+		usr, err = s.store.UserByID(ctx, uid)
+		if err != nil {
+			return err
+		}
+
+		exists, err := s.store.Exists(ctx, change.Email)
+		if err != nil {
+			return fmt.Errorf("can't check if user exists: %w", err)
+		}
+
+		if exists {
+			return ErrExists
+		}
+
+		usr.Email = change.Email
+		err = s.store.UpdateUser(ctx, uid, usr.Props)
+		if err == ErrExists {
+			// Email was taken by concurrent request
+			return err
+		}
+
+		if err != nil {
+			return fmt.Errorf("failed to update user email: %w", err)
+		}
+
+		// Token stays valid if email update is rolled back.
+		s.tx.AfterCommit(ctx, func() {
+			err := s.changes.RemoveEmailChange(context.Background(), *change)
+			if err != nil && err != ErrEmailChangeNotFound {
+				s.log.Error("failed to remove applied email change", zap.Error(err), zap.Any("uid", uid))
+			}
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.log.Info("email changed", zap.Any("uid", uid))
+	return usr, nil
 }
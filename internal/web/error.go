@@ -64,6 +64,16 @@ func NewErrForbidden(msg string, args ...interface{}) *APIError {
 	return NewAPIError(http.StatusForbidden, msg, args...)
 }
 
+// NewErrConflict returns new conflict error
+func NewErrConflict(msg string, args ...interface{}) *APIError {
+	return NewAPIError(http.StatusConflict, msg, args...)
+}
+
+// NewErrPreconditionFailed returns new precondition failed error
+func NewErrPreconditionFailed(msg string, args ...interface{}) *APIError {
+	return NewAPIError(http.StatusPreconditionFailed, msg, args...)
+}
+
 // ToAPIError constructs APIError from passed error.
 //
 // If error implements APIErrorer interface, APIError() method will be called.
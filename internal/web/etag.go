@@ -0,0 +1,39 @@
+package web
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// FormatETag returns entity tag for resource version
+func FormatETag(version int64) string {
+	return strconv.Quote(strconv.FormatInt(version, 10))
+}
+
+// IfMatchVersion returns expected resource version from "If-Match" request header.
+//
+// Returns nil if header is empty or matches any version ("*").
+func IfMatchVersion(r *http.Request) (*int64, error) {
+	val := strings.TrimSpace(r.Header.Get("If-Match"))
+	if val == "" || val == "*" {
+		return nil, nil
+	}
+
+	if strings.Contains(val, ",") {
+		return nil, NewErrBadRequest("only one entity tag is supported in If-Match header")
+	}
+
+	// weak comparison is not allowed for If-Match, but it's harmless for version tags.
+	tag, err := strconv.Unquote(strings.TrimPrefix(val, "W/"))
+	if err != nil {
+		return nil, NewErrBadRequest("invalid entity tag %q", val)
+	}
+
+	version, err := strconv.ParseInt(tag, 10, 64)
+	if err != nil {
+		return nil, NewErrPreconditionFailed("resource version mismatch")
+	}
+
+	return &version, nil
+}
@@ -22,6 +22,20 @@ type HandlerFunc = func(rw http.ResponseWriter, req *http.Request) error
 // result which will be encoded to JSON or response error.
 type ResourceHandlerFunc = func(req *http.Request) (interface{}, error)
 
+// Response is resource handler result with custom response headers.
+type Response struct {
+	// Header is list of response headers
+	Header http.Header
+
+	// Body is response body which will be encoded to JSON
+	Body interface{}
+}
+
+// NewResponse returns resource handler result with custom response headers.
+func NewResponse(body interface{}, header http.Header) *Response {
+	return &Response{Header: header, Body: body}
+}
+
 // MiddlewareFunc is request wrapper
 type MiddlewareFunc = func(rw http.ResponseWriter, req *http.Request) (*http.Request, error)
 
@@ -87,6 +101,13 @@ func (w Wrapper) WrapResourceHandler(h ResourceHandlerFunc, mw ...MiddlewareFunc
 			return err
 		}
 
+		if rsp, ok := obj.(*Response); ok {
+			for k, v := range rsp.Header {
+				rw.Header()[k] = v
+			}
+			obj = rsp.Body
+		}
+
 		data, err := json.Marshal(obj)
 		if err != nil {
 			return fmt.Errorf("failed to encode response: %w", err)
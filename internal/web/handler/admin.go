@@ -0,0 +1,34 @@
+package handler
+
+import (
+	"expvar"
+	"net/http"
+
+	"github.com/x1unix/sbda-ledger/internal/model/request"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+type AdminHandler struct {
+	balanceChecker *service.BalanceChecker
+}
+
+// NewAdminHandler is AdminHandler constructor
+func NewAdminHandler(balanceChecker *service.BalanceChecker) *AdminHandler {
+	return &AdminHandler{balanceChecker: balanceChecker}
+}
+
+// CheckBalance compares balance cache with loans journal and optionally rebuilds inconsistent cache.
+func (h AdminHandler) CheckBalance(r *http.Request) (interface{}, error) {
+	var req request.BalanceCheckRequest
+	if err := UnmarshalAndValidate(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	return h.balanceChecker.Check(r.Context(), req.UserIDs, req.Rebuild)
+}
+
+// Metrics returns service metrics published with expvar.
+func (h AdminHandler) Metrics(w http.ResponseWriter, r *http.Request) error {
+	expvar.Handler().ServeHTTP(w, r)
+	return nil
+}
@@ -0,0 +1,116 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/auth"
+	"github.com/x1unix/sbda-ledger/internal/model/request"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+type EventHandler struct {
+	eventService *service.EventService
+}
+
+// NewEventHandler is EventHandler constructor
+func NewEventHandler(eventSvc *service.EventService) *EventHandler {
+	return &EventHandler{eventService: eventSvc}
+}
+
+func (h EventHandler) CreateEvent(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req request.EventRequest
+	if err = UnmarshalAndValidate(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	return h.eventService.CreateEvent(ctx, sess.UserID, *gid, req.Event())
+}
+
+func (h EventHandler) GetEvents(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	events, err := h.eventService.GroupEvents(ctx, sess.UserID, *gid)
+	if err != nil {
+		return nil, err
+	}
+
+	if events == nil {
+		events = user.Events{}
+	}
+
+	return request.EventsResponse{Events: events}, nil
+}
+
+func (h EventHandler) GetEvent(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, eid, err := eventIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.eventService.GetEvent(ctx, sess.UserID, *gid, *eid)
+}
+
+func (h EventHandler) GetReport(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var eid *user.EventID
+	if v := r.URL.Query().Get("event_id"); v != "" {
+		if eid, err = model.DecodeUUID(v); err != nil {
+			return nil, err
+		}
+	}
+
+	return h.eventService.GroupReport(ctx, sess.UserID, *gid, eid)
+}
+
+func eventIdFromRequest(r *http.Request) (*user.GroupID, *user.EventID, error) {
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	eid, err := model.DecodeUUID(mux.Vars(r)["eventId"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return gid, eid, nil
+}
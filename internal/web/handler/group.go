@@ -2,13 +2,18 @@ package handler
 
 import (
 	"net/http"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
+	"github.com/jackc/pgtype"
 	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/activity"
 	"github.com/x1unix/sbda-ledger/internal/model/auth"
 	"github.com/x1unix/sbda-ledger/internal/model/request"
 	"github.com/x1unix/sbda-ledger/internal/model/user"
 	"github.com/x1unix/sbda-ledger/internal/service"
+	"github.com/x1unix/sbda-ledger/internal/web"
 )
 
 type GroupHandler struct {
@@ -32,16 +37,65 @@ func (h GroupHandler) CreateGroup(r *http.Request) (interface{}, error) {
 		return nil, err
 	}
 
-	return h.groupService.AddGroup(ctx, req.Name, sess.UserID)
+	return h.groupService.AddGroup(ctx, req.Settings(), sess.UserID)
+}
+
+func (h GroupHandler) UpdateGroup(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := web.IfMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req request.GroupUpdateRequest
+	if err = web.UnmarshalJSON(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	if err = req.Validate(); err != nil {
+		return nil, err
+	}
+
+	grp, err := h.groupService.UpdateGroup(ctx, sess.UserID, *gid, req.Update(), version)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make(http.Header)
+	header.Set("ETag", web.FormatETag(grp.Version))
+	return web.NewResponse(grp, header), nil
 }
 
 func (h GroupHandler) GetGroupInfo(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
 	gid, err := groupIdFromRequest(r)
 	if err != nil {
 		return nil, err
 	}
 
-	return h.groupService.GetGroupInfo(r.Context(), *gid)
+	info, err := h.groupService.GetGroupInfo(ctx, sess.UserID, *gid)
+	if err != nil {
+		return nil, err
+	}
+
+	header := make(http.Header)
+	header.Set("ETag", web.FormatETag(info.Version))
+	return web.NewResponse(info, header), nil
 }
 
 func (h GroupHandler) GetUserGroups(r *http.Request) (interface{}, error) {
@@ -51,7 +105,12 @@ func (h GroupHandler) GetUserGroups(r *http.Request) (interface{}, error) {
 		return nil, service.ErrAuthRequired
 	}
 
-	groups, err := h.groupService.GroupsByUser(ctx, sess.UserID)
+	includeArchived, err := boolQueryParam(r, "include_archived")
+	if err != nil {
+		return nil, err
+	}
+
+	groups, err := h.groupService.GroupsByUser(ctx, sess.UserID, includeArchived)
 	if err != nil {
 		return nil, err
 	}
@@ -71,7 +130,17 @@ func (h GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
-	err = h.groupService.DeleteGroup(ctx, sess.UserID, *gid)
+	version, err := web.IfMatchVersion(r)
+	if err != nil {
+		return err
+	}
+
+	permanent, err := boolQueryParam(r, "permanent")
+	if err != nil {
+		return err
+	}
+
+	err = h.groupService.DeleteGroup(ctx, sess.UserID, *gid, version, permanent)
 	if err != nil {
 		return err
 	}
@@ -80,6 +149,61 @@ func (h GroupHandler) DeleteGroup(w http.ResponseWriter, r *http.Request) error
 	return nil
 }
 
+func (h GroupHandler) RestoreGroup(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.groupService.RestoreGroup(ctx, sess.UserID, *gid)
+}
+
+func (h GroupHandler) ArchiveGroup(r *http.Request) (interface{}, error) {
+	return h.setArchived(r, true)
+}
+
+func (h GroupHandler) UnarchiveGroup(r *http.Request) (interface{}, error) {
+	return h.setArchived(r, false)
+}
+
+func (h GroupHandler) setArchived(r *http.Request, archived bool) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := web.IfMatchVersion(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var grp *user.Group
+	if archived {
+		grp, err = h.groupService.ArchiveGroup(ctx, sess.UserID, *gid, version)
+	} else {
+		grp, err = h.groupService.UnarchiveGroup(ctx, sess.UserID, *gid, version)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	header := make(http.Header)
+	header.Set("ETag", web.FormatETag(grp.Version))
+	return web.NewResponse(grp, header), nil
+}
+
 func (h GroupHandler) GetMembers(r *http.Request) (interface{}, error) {
 	ctx := r.Context()
 	sess := auth.SessionFromContext(ctx)
@@ -92,12 +216,37 @@ func (h GroupHandler) GetMembers(r *http.Request) (interface{}, error) {
 		return nil, err
 	}
 
-	members, err := h.groupService.GetMembers(ctx, *gid)
+	history, err := boolQueryParam(r, "history")
+	if err != nil {
+		return nil, err
+	}
+
+	members, err := h.groupService.GetMembers(ctx, sess.UserID, *gid, history)
+	if err != nil {
+		return nil, err
+	}
+
+	return request.MembersList{Users: members}, nil
+}
+
+func (h GroupHandler) GetActivity(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err := activityFilterFromRequest(r)
 	if err != nil {
 		return nil, err
 	}
 
-	return request.UsersList{Users: members}, nil
+	return h.groupService.GroupActivity(ctx, sess.UserID, *gid, *filter)
 }
 
 func (h GroupHandler) AddMembers(w http.ResponseWriter, r *http.Request) error {
@@ -112,12 +261,17 @@ func (h GroupHandler) AddMembers(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	var req request.UserIDs
+	version, err := web.IfMatchVersion(r)
+	if err != nil {
+		return err
+	}
+
+	var req request.AddMembersRequest
 	if err = UnmarshalAndValidate(r.Body, &req); err != nil {
 		return err
 	}
 
-	err = h.groupService.AddMembers(ctx, sess.UserID, *gid, req.IDs)
+	err = h.groupService.AddMembers(ctx, sess.UserID, *gid, req.IDs, req.Role, version)
 	if err != nil {
 		return err
 	}
@@ -139,7 +293,109 @@ func (h GroupHandler) RemoveMember(w http.ResponseWriter, r *http.Request) error
 		return err
 	}
 
-	err = h.groupService.RemoveMember(ctx, sess.UserID, ids[0], ids[1])
+	version, err := web.IfMatchVersion(r)
+	if err != nil {
+		return err
+	}
+
+	opts, err := removalOptionsFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	err = h.groupService.RemoveMember(ctx, sess.UserID, ids[0], ids[1], version, *opts)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h GroupHandler) LeaveGroup(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	opts, err := removalOptionsFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if err = h.groupService.LeaveGroup(ctx, sess.UserID, *gid, *opts); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h GroupHandler) SetMemberRole(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return service.ErrAuthRequired
+	}
+
+	vars := mux.Vars(r)
+	ids, err := model.DecodeUUIDs(vars["groupId"], vars["userId"])
+	if err != nil {
+		return err
+	}
+
+	version, err := web.IfMatchVersion(r)
+	if err != nil {
+		return err
+	}
+
+	var req request.RoleRequest
+	if err = UnmarshalAndValidate(r.Body, &req); err != nil {
+		return err
+	}
+
+	err = h.groupService.SetMemberRole(ctx, sess.UserID, ids[0], ids[1], req.Role, version)
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h GroupHandler) TransferOwnership(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	version, err := web.IfMatchVersion(r)
+	if err != nil {
+		return err
+	}
+
+	var req request.TransferRequest
+	if err = UnmarshalAndValidate(r.Body, &req); err != nil {
+		return err
+	}
+
+	if req.UserID.Status != pgtype.Present {
+		return web.NewErrBadRequest("new owner id is required")
+	}
+
+	err = h.groupService.TransferOwnership(ctx, sess.UserID, *gid, req.UserID, version)
 	if err != nil {
 		return err
 	}
@@ -165,7 +421,7 @@ func (h GroupHandler) LogExpense(w http.ResponseWriter, r *http.Request) error {
 		return err
 	}
 
-	err = h.groupService.ShareExpense(ctx, sess.UserID, req.Amount, *gid)
+	err = h.groupService.ShareExpense(ctx, sess.UserID, req.Amount, req.Currency, *gid, req.Date, req.EventID)
 	if err != nil {
 		return err
 	}
@@ -178,3 +434,75 @@ func groupIdFromRequest(r *http.Request) (*user.GroupID, error) {
 	vars := mux.Vars(r)
 	return model.DecodeUUID(vars["groupId"])
 }
+
+// removalOptionsFromRequest reads member removal options from "force" and "transfer_to" query params.
+func removalOptionsFromRequest(r *http.Request) (*service.RemovalOptions, error) {
+	query := r.URL.Query()
+	opts := new(service.RemovalOptions)
+	force, err := boolQueryParam(r, "force")
+	if err != nil {
+		return nil, err
+	}
+
+	opts.Force = force
+	if v := query.Get("transfer_to"); v != "" {
+		uid, err := model.DecodeUUID(v)
+		if err != nil {
+			return nil, err
+		}
+
+		opts.TransferTo = uid
+	}
+
+	return opts, nil
+}
+
+// activityFilterFromRequest reads activity feed filter from "type", "cursor" and "limit" query params.
+//
+// Event types can be passed as multiple params or as comma-separated list.
+func activityFilterFromRequest(r *http.Request) (*activity.Filter, error) {
+	query := r.URL.Query()
+	filter := new(activity.Filter)
+	for _, v := range query["type"] {
+		for _, t := range strings.Split(v, ",") {
+			if t = strings.TrimSpace(t); t != "" {
+				filter.Types = append(filter.Types, activity.EventType(t))
+			}
+		}
+	}
+
+	if v := query.Get("cursor"); v != "" {
+		cursor, err := activity.ParseCursor(v)
+		if err != nil {
+			return nil, web.NewErrBadRequest("invalid cursor parameter value")
+		}
+
+		filter.Cursor = cursor
+	}
+
+	if v := query.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil {
+			return nil, web.NewErrBadRequest("invalid limit parameter value")
+		}
+
+		filter.Limit = limit
+	}
+
+	return filter, nil
+}
+
+// boolQueryParam reads optional boolean query param. Empty param is false.
+func boolQueryParam(r *http.Request, name string) (bool, error) {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return false, nil
+	}
+
+	val, err := strconv.ParseBool(v)
+	if err != nil {
+		return false, web.NewErrBadRequest("invalid %s parameter value", name)
+	}
+
+	return val, nil
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/auth"
+	"github.com/x1unix/sbda-ledger/internal/model/request"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+type HouseholdHandler struct {
+	householdService *service.HouseholdService
+}
+
+// NewHouseholdHandler is HouseholdHandler constructor
+func NewHouseholdHandler(householdSvc *service.HouseholdService) *HouseholdHandler {
+	return &HouseholdHandler{householdService: householdSvc}
+}
+
+func (h HouseholdHandler) CreateHousehold(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req request.HouseholdRequest
+	if err = UnmarshalAndValidate(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	return h.householdService.CreateHousehold(ctx, sess.UserID, *gid, req.Household())
+}
+
+func (h HouseholdHandler) GetHouseholds(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	households, err := h.householdService.GroupHouseholds(ctx, sess.UserID, *gid)
+	if err != nil {
+		return nil, err
+	}
+
+	if households == nil {
+		households = user.Households{}
+	}
+
+	return request.HouseholdsResponse{Households: households}, nil
+}
+
+func (h HouseholdHandler) GetHousehold(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, hid, err := householdIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.householdService.GetHousehold(ctx, sess.UserID, *gid, *hid)
+}
+
+func (h HouseholdHandler) DeleteHousehold(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return service.ErrAuthRequired
+	}
+
+	gid, hid, err := householdIdFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	if err = h.householdService.DeleteHousehold(ctx, sess.UserID, *gid, *hid); err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func householdIdFromRequest(r *http.Request) (*user.GroupID, *user.HouseholdID, error) {
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	hid, err := model.DecodeUUID(mux.Vars(r)["householdId"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return gid, hid, nil
+}
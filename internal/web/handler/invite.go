@@ -0,0 +1,96 @@
+package handler
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/x1unix/sbda-ledger/internal/model/auth"
+	"github.com/x1unix/sbda-ledger/internal/model/request"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+type InviteHandler struct {
+	inviteService *service.InviteService
+}
+
+// NewInviteHandler is InviteHandler constructor
+func NewInviteHandler(inviteSvc *service.InviteService) *InviteHandler {
+	return &InviteHandler{inviteService: inviteSvc}
+}
+
+func (h InviteHandler) CreateInvite(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req request.InviteRequest
+	if err = UnmarshalAndValidate(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	return h.inviteService.CreateInvite(ctx, sess.UserID, *gid, service.InviteParams{
+		Role:          req.Role,
+		MaxUses:       req.MaxUses,
+		TTL:           time.Duration(req.ExpiresIn) * time.Second,
+		PlaceholderID: req.PlaceholderID,
+	})
+}
+
+func (h InviteHandler) GetInvites(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	invites, err := h.inviteService.GroupInvites(ctx, sess.UserID, *gid)
+	if err != nil {
+		return nil, err
+	}
+
+	return request.InvitesResponse{Invites: invites}, nil
+}
+
+func (h InviteHandler) RevokeInvite(w http.ResponseWriter, r *http.Request) error {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return err
+	}
+
+	err = h.inviteService.RevokeInvite(ctx, sess.UserID, *gid, mux.Vars(r)["token"])
+	if err != nil {
+		return err
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func (h InviteHandler) AcceptInvite(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	return h.inviteService.AcceptInvite(ctx, sess.UserID, mux.Vars(r)["token"])
+}
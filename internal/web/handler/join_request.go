@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+	"github.com/x1unix/sbda-ledger/internal/model"
+	"github.com/x1unix/sbda-ledger/internal/model/auth"
+	"github.com/x1unix/sbda-ledger/internal/model/request"
+	"github.com/x1unix/sbda-ledger/internal/model/user"
+	"github.com/x1unix/sbda-ledger/internal/service"
+	"github.com/x1unix/sbda-ledger/internal/web"
+)
+
+type JoinRequestHandler struct {
+	joinService *service.JoinRequestService
+}
+
+// NewJoinRequestHandler is JoinRequestHandler constructor
+func NewJoinRequestHandler(joinSvc *service.JoinRequestService) *JoinRequestHandler {
+	return &JoinRequestHandler{joinService: joinSvc}
+}
+
+func (h JoinRequestHandler) RequestJoin(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req request.JoinRequestCreate
+	if err = UnmarshalAndValidate(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	return h.joinService.RequestJoin(ctx, sess.UserID, *gid, req.Message)
+}
+
+func (h JoinRequestHandler) GetGroupRequests(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	status, err := joinRequestStatusFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	reqs, err := h.joinService.GroupJoinRequests(ctx, sess.UserID, *gid, status)
+	if err != nil {
+		return nil, err
+	}
+
+	return request.JoinRequestsResponse{Requests: reqs}, nil
+}
+
+func (h JoinRequestHandler) GetUserRequests(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	reqs, err := h.joinService.UserJoinRequests(ctx, sess.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	return request.JoinRequestsResponse{Requests: reqs}, nil
+}
+
+func (h JoinRequestHandler) ApproveRequest(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, rid, err := joinRequestIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.joinService.ApproveJoinRequest(ctx, sess.UserID, *gid, *rid)
+}
+
+func (h JoinRequestHandler) DenyRequest(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, rid, err := joinRequestIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	return h.joinService.DenyJoinRequest(ctx, sess.UserID, *gid, *rid)
+}
+
+func joinRequestIdFromRequest(r *http.Request) (*user.GroupID, *user.JoinRequestID, error) {
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	rid, err := model.DecodeUUID(mux.Vars(r)["requestId"])
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return gid, rid, nil
+}
+
+// joinRequestStatusFromRequest reads join request status filter from "status" query param.
+//
+// Returns pending status if param is empty and empty status if all requests are requested.
+func joinRequestStatusFromRequest(r *http.Request) (user.JoinRequestStatus, error) {
+	switch v := user.JoinRequestStatus(r.URL.Query().Get("status")); v {
+	case "":
+		return user.JoinRequestPending, nil
+	case "all":
+		return "", nil
+	case user.JoinRequestPending, user.JoinRequestApproved, user.JoinRequestDenied:
+		return v, nil
+	default:
+		return "", web.NewErrBadRequest("invalid join request status %q", v)
+	}
+}
@@ -0,0 +1,33 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
+	"github.com/x1unix/sbda-ledger/internal/service"
+	"github.com/x1unix/sbda-ledger/internal/web"
+)
+
+type LedgerHandler struct {
+	verifier *service.ChainVerifier
+}
+
+// NewLedgerHandler is LedgerHandler constructor
+func NewLedgerHandler(verifier *service.ChainVerifier) *LedgerHandler {
+	return &LedgerHandler{verifier: verifier}
+}
+
+// VerifyChain walks through journal transactions chain and reports first broken link.
+//
+// Optional "head" query parameter is previously recorded chain head, used to detect chain truncation.
+func (h LedgerHandler) VerifyChain(r *http.Request) (interface{}, error) {
+	var head loan.Hash
+	if v := r.URL.Query().Get("head"); v != "" {
+		var err error
+		if head, err = loan.ParseHash(v); err != nil {
+			return nil, web.NewErrBadRequest("invalid head parameter value")
+		}
+	}
+
+	return h.verifier.Verify(r.Context(), head)
+}
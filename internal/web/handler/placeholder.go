@@ -0,0 +1,38 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/x1unix/sbda-ledger/internal/model/auth"
+	"github.com/x1unix/sbda-ledger/internal/model/request"
+	"github.com/x1unix/sbda-ledger/internal/service"
+)
+
+type PlaceholderHandler struct {
+	placeholderService *service.PlaceholderService
+}
+
+// NewPlaceholderHandler is PlaceholderHandler constructor
+func NewPlaceholderHandler(placeholderSvc *service.PlaceholderService) *PlaceholderHandler {
+	return &PlaceholderHandler{placeholderService: placeholderSvc}
+}
+
+func (h PlaceholderHandler) AddPlaceholder(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	gid, err := groupIdFromRequest(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var req request.PlaceholderRequest
+	if err = UnmarshalAndValidate(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	return h.placeholderService.AddPlaceholder(ctx, sess.UserID, *gid, req.Name)
+}
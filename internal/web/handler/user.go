@@ -6,8 +6,10 @@ import (
 	"github.com/gorilla/mux"
 	"github.com/x1unix/sbda-ledger/internal/model"
 	"github.com/x1unix/sbda-ledger/internal/model/auth"
+	"github.com/x1unix/sbda-ledger/internal/model/loan"
 	"github.com/x1unix/sbda-ledger/internal/model/request"
 	"github.com/x1unix/sbda-ledger/internal/service"
+	"github.com/x1unix/sbda-ledger/internal/web"
 )
 
 type UserHandler struct {
@@ -49,6 +51,46 @@ func (h UserHandler) GetCurrentUser(r *http.Request) (interface{}, error) {
 	return h.usersSvc.UserByID(ctx, sess.UserID)
 }
 
+func (h UserHandler) UpdateProfile(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	var req request.ProfileUpdateRequest
+	if err := web.UnmarshalJSON(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	usr, change, err := h.usersSvc.UpdateProfile(ctx, sess.UserID, req.Update())
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := request.ProfileResponse{User: usr}
+	if change != nil {
+		rsp.PendingEmail = change.Email
+	}
+
+	return rsp, nil
+}
+
+func (h UserHandler) ConfirmEmail(r *http.Request) (interface{}, error) {
+	ctx := r.Context()
+	sess := auth.SessionFromContext(ctx)
+	if sess == nil {
+		return nil, service.ErrAuthRequired
+	}
+
+	var req request.ConfirmEmailRequest
+	if err := UnmarshalAndValidate(r.Body, &req); err != nil {
+		return nil, err
+	}
+
+	return h.usersSvc.ConfirmEmail(ctx, sess.UserID, req.Token)
+}
+
 func (h UserHandler) GetBalance(r *http.Request) (interface{}, error) {
 	ctx := r.Context()
 	sess := auth.SessionFromContext(ctx)
@@ -61,5 +103,5 @@ func (h UserHandler) GetBalance(r *http.Request) (interface{}, error) {
 		return nil, err
 	}
 
-	return request.BalanceStatus{Status: val}, nil
+	return request.BalanceStatus{Status: loan.GroupByCurrency(val)}, nil
 }
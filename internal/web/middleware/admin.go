@@ -0,0 +1,32 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/x1unix/sbda-ledger/internal/model/auth"
+	"github.com/x1unix/sbda-ledger/internal/service"
+	"github.com/x1unix/sbda-ledger/internal/web"
+)
+
+// NewAdminMiddleware returns a new middleware which checks if authenticated user is an administrator.
+//
+// Should be used after auth middleware.
+func NewAdminMiddleware(usersSvc *service.UsersService) web.MiddlewareFunc {
+	return func(rw http.ResponseWriter, req *http.Request) (*http.Request, error) {
+		sess := auth.SessionFromContext(req.Context())
+		if sess == nil {
+			return req, service.ErrAuthRequired
+		}
+
+		isAdmin, err := usersSvc.IsAdmin(req.Context(), sess.UserID)
+		if err != nil {
+			return req, err
+		}
+
+		if !isAdmin {
+			return req, web.NewErrForbidden("administrative access required")
+		}
+
+		return req, nil
+	}
+}
@@ -0,0 +1,81 @@
+package ledger
+
+import (
+	"encoding/json"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// Group activity event types
+const (
+	EventExpenseCreated     = "expense_created"
+	EventExpenseVoided      = "expense_voided"
+	EventMemberAdded        = "member_added"
+	EventMemberRemoved      = "member_removed"
+	EventMemberLeft         = "member_left"
+	EventSettlement         = "settlement"
+	EventBalanceTransferred = "balance_transferred"
+
+	EventOwnershipTransferred = "ownership_transferred"
+)
+
+// ActivityEvent is group activity feed entry
+type ActivityEvent struct {
+	ID      int64  `json:"id"`
+	GroupID string `json:"group_id"`
+
+	// ActorID is ID of user who caused the event. Empty if event was caused by system.
+	ActorID   string          `json:"actor_id,omitempty"`
+	Type      string          `json:"type"`
+	Payload   json.RawMessage `json:"payload"`
+	CreatedAt time.Time       `json:"created_at"`
+}
+
+// ActivityPage is group activity feed page
+type ActivityPage struct {
+	// Events is list of events, newest first.
+	Events []ActivityEvent `json:"events"`
+
+	// NextCursor is cursor to fetch next page. Empty if there are no more events.
+	NextCursor string `json:"next_cursor,omitempty"`
+}
+
+// ActivityQuery is group activity feed query
+type ActivityQuery struct {
+	// Types is list of event types to return. All events are returned if empty.
+	Types []string
+
+	// Cursor is next page cursor from previous page.
+	Cursor string
+
+	// Limit is max number of events in a page. Server default is used if zero.
+	Limit int
+}
+
+func (q ActivityQuery) query() string {
+	v := make(url.Values)
+	for _, t := range q.Types {
+		v.Add("type", t)
+	}
+
+	if q.Cursor != "" {
+		v.Set("cursor", q.Cursor)
+	}
+
+	if q.Limit != 0 {
+		v.Set("limit", strconv.Itoa(q.Limit))
+	}
+
+	if len(v) == 0 {
+		return ""
+	}
+
+	return "?" + v.Encode()
+}
+
+// GroupActivity returns page of group activity feed, newest events first.
+func (c Client) GroupActivity(gid string, q ActivityQuery, t Token) (*ActivityPage, error) {
+	out := new(ActivityPage)
+	return out, c.get("/groups/"+gid+"/activity"+q.query(), out, t)
+}
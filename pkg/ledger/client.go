@@ -48,35 +48,41 @@ func (c Client) newRequest(method, reqPath string, data interface{}, auth Token)
 }
 
 func (c Client) do(req *http.Request, out interface{}) error {
+	_, err := c.doWithHeader(req, out)
+	return err
+}
+
+// doWithHeader sends request and returns response headers.
+func (c Client) doWithHeader(req *http.Request, out interface{}) (http.Header, error) {
 	rsp, err := c.http.Do(req)
 	if err != nil {
-		return fmt.Errorf("failed to send request: %w", err)
+		return nil, fmt.Errorf("failed to send request: %w", err)
 	}
 
 	defer rsp.Body.Close()
 	content, err := ioutil.ReadAll(rsp.Body)
 	if err != nil && err != io.EOF {
-		return fmt.Errorf("failed to read response: %w", err)
+		return nil, fmt.Errorf("failed to read response: %w", err)
 	}
 
 	switch rsp.StatusCode {
 	case http.StatusOK:
 		if out == nil {
-			return fmt.Errorf("got response but passed output is nil")
+			return nil, fmt.Errorf("got response but passed output is nil")
 		}
 
-		return json.Unmarshal(content, out)
+		return rsp.Header, json.Unmarshal(content, out)
 	case http.StatusNoContent:
-		return nil
+		return rsp.Header, nil
 	case http.StatusBadGateway:
-		return errors.New(rsp.Status)
+		return nil, errors.New(rsp.Status)
 	default:
 		errRsp := &ErrorResponse{StatusCode: rsp.StatusCode, Status: rsp.Status}
 		if err := json.Unmarshal(content, errRsp); err != nil {
 			errRsp.ErrorData.Message = string(content)
 		}
 
-		return errRsp
+		return nil, errRsp
 	}
 }
 
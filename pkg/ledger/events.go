@@ -0,0 +1,93 @@
+package ledger
+
+import (
+	"net/url"
+	"time"
+)
+
+// Event is a sub-ledger inside a group, like a trip or an occasion.
+type Event struct {
+	ID          string `json:"id"`
+	GroupID     string `json:"group_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+
+	// Participants is list of group members who share event expenses.
+	// Event is shared by all group members if list is empty.
+	Participants []string  `json:"participants"`
+	CreatedBy    string    `json:"created_by,omitempty"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+// EventParams is group event creation params
+type EventParams struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+
+	// Participants is optional list of group members who share event expenses.
+	Participants []string `json:"participants,omitempty"`
+}
+
+type eventsResponse struct {
+	Events []Event `json:"events"`
+}
+
+// Total is total amount of expenses in a currency
+type Total struct {
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+// Debt is outstanding debt of one user to another
+type Debt struct {
+	LenderID string `json:"lender_id"`
+	DebtorID string `json:"debtor_id"`
+	Currency string `json:"currency"`
+	Amount   int64  `json:"amount"`
+}
+
+// Report is summary of expenses and outstanding debts of a group or a group event
+type Report struct {
+	GroupID string  `json:"group_id"`
+	EventID string  `json:"event_id,omitempty"`
+	Spent   []Total `json:"spent"`
+	Debts   []Debt  `json:"debts"`
+}
+
+// CreateEvent creates a new group event
+func (c Client) CreateEvent(gid string, params EventParams, t Token) (*Event, error) {
+	out := new(Event)
+	return out, c.post("/groups/"+gid+"/events", params, out, t)
+}
+
+// GroupEvents returns all group events, newest first
+func (c Client) GroupEvents(gid string, t Token) ([]Event, error) {
+	out := new(eventsResponse)
+	return out.Events, c.get("/groups/"+gid+"/events", out, t)
+}
+
+// GroupEvent returns group event by ID
+func (c Client) GroupEvent(gid, eid string, t Token) (*Event, error) {
+	out := new(Event)
+	return out, c.get("/groups/"+gid+"/events/"+eid, out, t)
+}
+
+// AddEventExpense adds expense to a group event.
+//
+// Expense is shared only between event participants.
+func (c Client) AddEventExpense(gid, eid string, amount int64, currency string, t Token) error {
+	return c.post("/groups/"+gid+"/expenses", amountRequest{Amount: amount, Currency: currency, EventID: eid}, nil, t)
+}
+
+// GroupReport returns summary of group expenses and debts.
+//
+// If event ID is not empty, report is scoped to group event.
+func (c Client) GroupReport(gid, eid string, t Token) (*Report, error) {
+	out := new(Report)
+	path := "/groups/" + gid + "/report"
+	if eid != "" {
+		path += "?" + url.Values{"event_id": {eid}}.Encode()
+	}
+
+	return out, c.get(path, out, t)
+}
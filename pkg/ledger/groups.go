@@ -1,18 +1,89 @@
 package ledger
 
+import (
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
 type Group struct {
-	ID      string `json:"id"`
-	OwnerID string `json:"owner_id"`
-	Name    string `json:"name"`
+	ID          string `json:"id"`
+	OwnerID     string `json:"owner_id"`
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	Currency    string `json:"currency"`
+	SplitMode   string `json:"split_mode"`
+	Rounding    string `json:"rounding"`
+	JoinPolicy  string `json:"join_policy"`
+	Version     int64  `json:"version"`
+
+	// ArchivedAt is group archival date. Empty if group is not archived.
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
+}
+
+// Group expense split modes
+const (
+	SplitEqual        = "equal"
+	SplitExcludePayer = "exclude_payer"
+)
+
+// Group expense share rounding policies
+const (
+	RoundHalfUp = "half_up"
+	RoundUp     = "up"
+	RoundDown   = "down"
+)
+
+// Group join policies
+const (
+	JoinPolicyClosed  = "closed"
+	JoinPolicyRequest = "request"
+)
+
+// GroupUpdate is partial group settings update.
+//
+// Only non-nil fields are changed.
+type GroupUpdate struct {
+	Name        *string `json:"name,omitempty"`
+	Description *string `json:"description,omitempty"`
+	Currency    *string `json:"currency,omitempty"`
+	SplitMode   *string `json:"split_mode,omitempty"`
+	Rounding    *string `json:"rounding,omitempty"`
+	JoinPolicy  *string `json:"join_policy,omitempty"`
+}
+
+// Group member roles
+const (
+	RoleOwner  = "owner"
+	RoleAdmin  = "admin"
+	RoleMember = "member"
+	RoleViewer = "viewer"
+)
+
+// Member is group member
+type Member struct {
+	User
+	Role string `json:"role"`
+
+	// JoinedAt is start date of membership period.
+	JoinedAt time.Time `json:"joined_at"`
+
+	// LeftAt is end date of membership period. Nil if membership is active.
+	LeftAt *time.Time `json:"left_at,omitempty"`
 }
 
 type GroupInfo struct {
 	Group
-	Members []User `json:"members"`
+	Members []Member `json:"members"`
+
+	// ETag is group entity tag, used for conditional group changes.
+	ETag string `json:"-"`
 }
 
 type groupCreateParams struct {
-	Name string `json:"name"`
+	Name     string `json:"name"`
+	Currency string `json:"currency,omitempty"`
 }
 
 type groupsResponse struct {
@@ -20,11 +91,57 @@ type groupsResponse struct {
 }
 
 type idsRequest struct {
-	IDs []string `json:"ids"`
+	IDs  []string `json:"ids"`
+	Role string   `json:"role,omitempty"`
+}
+
+type membersResponse struct {
+	Users []Member `json:"users"`
+}
+
+type transferRequest struct {
+	UserID string `json:"user_id"`
+}
+
+// RemovalOptions are options of group member removal.
+type RemovalOptions struct {
+	// Force allows to remove member with outstanding balance in a group.
+	Force bool
+
+	// TransferTo is ID of group member which receives balance of removed member.
+	TransferTo string
+}
+
+func (opts RemovalOptions) query() string {
+	q := make(url.Values)
+	if opts.Force {
+		q.Set("force", strconv.FormatBool(opts.Force))
+	}
+
+	if opts.TransferTo != "" {
+		q.Set("transfer_to", opts.TransferTo)
+	}
+
+	if len(q) == 0 {
+		return ""
+	}
+
+	return "?" + q.Encode()
+}
+
+type placeholderRequest struct {
+	Name string `json:"name"`
+}
+
+type roleRequest struct {
+	Role string `json:"role"`
 }
 
 type amountRequest struct {
-	Amount int64 `json:"amount"`
+	Amount   int64      `json:"amount"`
+	Currency string     `json:"currency,omitempty"`
+	Date     *time.Time `json:"date,omitempty"`
+	EventID  string     `json:"event_id,omitempty"`
 }
 
 func (c Client) CreateGroup(name string, t Token) (*Group, error) {
@@ -32,33 +149,188 @@ func (c Client) CreateGroup(name string, t Token) (*Group, error) {
 	return out, c.post("/groups", groupCreateParams{Name: name}, out, t)
 }
 
+// CreateCurrencyGroup creates a new group with specified base currency
+func (c Client) CreateCurrencyGroup(name, currency string, t Token) (*Group, error) {
+	out := new(Group)
+	return out, c.post("/groups", groupCreateParams{Name: name, Currency: currency}, out, t)
+}
+
 func (c Client) Groups(t Token) ([]Group, error) {
 	out := new(groupsResponse)
 	return out.Groups, c.get("/groups", out, t)
 }
 
+// GroupsIncludeArchived returns user groups including archived groups
+func (c Client) GroupsIncludeArchived(t Token) ([]Group, error) {
+	out := new(groupsResponse)
+	return out.Groups, c.get("/groups?include_archived=true", out, t)
+}
+
 func (c Client) GroupByID(gid string, t Token) (*GroupInfo, error) {
+	req, err := c.newRequest(http.MethodGet, "/groups/"+gid, nil, t)
+	if err != nil {
+		return nil, err
+	}
+
 	out := new(GroupInfo)
-	return out, c.get("/groups/"+gid, out, t)
+	header, err := c.doWithHeader(req, out)
+	if err != nil {
+		return nil, err
+	}
+
+	out.ETag = header.Get("ETag")
+	return out, nil
+}
+
+// UpdateGroup changes group settings and returns updated group.
+func (c Client) UpdateGroup(gid string, upd GroupUpdate, t Token) (*Group, error) {
+	return c.UpdateGroupIfMatch(gid, upd, "", t)
+}
+
+// UpdateGroupIfMatch changes group settings only if group entity tag matches.
+//
+// Entity tag check is skipped if etag is empty.
+func (c Client) UpdateGroupIfMatch(gid string, upd GroupUpdate, etag string, t Token) (*Group, error) {
+	req, err := c.newRequest(http.MethodPatch, "/groups/"+gid, upd, t)
+	if err != nil {
+		return nil, err
+	}
+
+	if etag != "" {
+		req.Header.Set("If-Match", etag)
+	}
+
+	out := new(Group)
+	return out, c.do(req, out)
 }
 
 func (c Client) DeleteGroup(gid string, t Token) error {
 	return c.delete("/groups/"+gid, t)
 }
 
-func (c Client) GroupMembers(gid string, t Token) ([]User, error) {
-	out := new(UsersResponse)
+// DeleteGroupPermanently deletes group without ability to restore it.
+//
+// Group can be deleted permanently only if all debts inside the group are settled.
+func (c Client) DeleteGroupPermanently(gid string, t Token) error {
+	return c.delete("/groups/"+gid+"?permanent=true", t)
+}
+
+// RestoreGroup restores deleted group
+func (c Client) RestoreGroup(gid string, t Token) (*Group, error) {
+	out := new(Group)
+	return out, c.post("/groups/"+gid+"/restore", nil, out, t)
+}
+
+// ArchiveGroup makes group read-only and hides it from default group list
+func (c Client) ArchiveGroup(gid string, t Token) (*Group, error) {
+	out := new(Group)
+	return out, c.post("/groups/"+gid+"/archive", nil, out, t)
+}
+
+// UnarchiveGroup restores archived group
+func (c Client) UnarchiveGroup(gid string, t Token) (*Group, error) {
+	out := new(Group)
+	return out, c.post("/groups/"+gid+"/unarchive", nil, out, t)
+}
+
+// DeleteGroupIfMatch deletes group only if group entity tag matches.
+func (c Client) DeleteGroupIfMatch(gid, etag string, t Token) error {
+	return c.ifMatch(http.MethodDelete, "/groups/"+gid, nil, etag, t)
+}
+
+func (c Client) GroupMembers(gid string, t Token) ([]Member, error) {
+	out := new(membersResponse)
 	return out.Users, c.get("/groups/"+gid+"/members", out, t)
 }
 
+// GroupMembersHistory returns all membership periods of group members, including closed.
+func (c Client) GroupMembersHistory(gid string, t Token) ([]Member, error) {
+	out := new(membersResponse)
+	return out.Users, c.get("/groups/"+gid+"/members?history=true", out, t)
+}
+
+// AddPlaceholder adds unregistered placeholder member to a group.
+//
+// Placeholder can be claimed later by registered user using placeholder invite.
+func (c Client) AddPlaceholder(gid, name string, t Token) (*Member, error) {
+	out := new(Member)
+	return out, c.post("/groups/"+gid+"/placeholders", placeholderRequest{Name: name}, out, t)
+}
+
 func (c Client) AddGroupMembers(gid string, t Token, uids ...string) error {
 	return c.post("/groups/"+gid+"/members", idsRequest{IDs: uids}, nil, t)
 }
 
+// AddGroupMembersIfMatch adds group members only if group entity tag matches.
+func (c Client) AddGroupMembersIfMatch(gid, etag string, t Token, uids ...string) error {
+	return c.ifMatch(http.MethodPost, "/groups/"+gid+"/members", idsRequest{IDs: uids}, etag, t)
+}
+
+// AddGroupMembersWithRole adds group members with specified role.
+func (c Client) AddGroupMembersWithRole(gid, role string, t Token, uids ...string) error {
+	return c.post("/groups/"+gid+"/members", idsRequest{IDs: uids, Role: role}, nil, t)
+}
+
+// SetMemberRole changes role of a group member.
+func (c Client) SetMemberRole(gid, uid, role string, t Token) error {
+	req, err := c.newRequest(http.MethodPut, "/groups/"+gid+"/members/"+uid+"/role", roleRequest{Role: role}, t)
+	if err != nil {
+		return err
+	}
+
+	return c.do(req, nil)
+}
+
+// TransferGroup hands group over to another group member.
+func (c Client) TransferGroup(gid, uid string, t Token) error {
+	return c.post("/groups/"+gid+"/transfer", transferRequest{UserID: uid}, nil, t)
+}
+
+// TransferGroupIfMatch hands group over to another group member only if group entity tag matches.
+func (c Client) TransferGroupIfMatch(gid, uid, etag string, t Token) error {
+	return c.ifMatch(http.MethodPost, "/groups/"+gid+"/transfer", transferRequest{UserID: uid}, etag, t)
+}
+
 func (c Client) DeleteGroupMember(gid, uid string, t Token) error {
 	return c.delete("/groups/"+gid+"/members/"+uid, t)
 }
 
-func (c Client) AddGroupExpense(gid string, amount int64, t Token) error {
-	return c.post("/groups/"+gid+"/expenses", amountRequest{Amount: amount}, nil, t)
+// DeleteGroupMemberWithOptions removes group member with outstanding balance handling options.
+func (c Client) DeleteGroupMemberWithOptions(gid, uid string, opts RemovalOptions, t Token) error {
+	return c.delete("/groups/"+gid+"/members/"+uid+opts.query(), t)
+}
+
+// LeaveGroup removes current user from a group.
+func (c Client) LeaveGroup(gid string, opts RemovalOptions, t Token) error {
+	return c.delete("/groups/"+gid+"/members/self"+opts.query(), t)
+}
+
+// DeleteGroupMemberIfMatch removes group member only if group entity tag matches.
+func (c Client) DeleteGroupMemberIfMatch(gid, uid, etag string, t Token) error {
+	return c.ifMatch(http.MethodDelete, "/groups/"+gid+"/members/"+uid, nil, etag, t)
+}
+
+// ifMatch sends conditional request with "If-Match" header.
+func (c Client) ifMatch(method, reqPath string, data interface{}, etag string, t Token) error {
+	req, err := c.newRequest(method, reqPath, data, t)
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("If-Match", etag)
+	return c.do(req, nil)
+}
+
+// AddGroupExpense adds expense to a group.
+//
+// Currency is optional, server uses default currency if it's empty.
+func (c Client) AddGroupExpense(gid string, amount int64, currency string, t Token) error {
+	return c.post("/groups/"+gid+"/expenses", amountRequest{Amount: amount, Currency: currency}, nil, t)
+}
+
+// AddGroupExpenseAt adds expense made at specified date to a group.
+//
+// Expense is shared between members who were active at that date.
+func (c Client) AddGroupExpenseAt(gid string, amount int64, currency string, date time.Time, t Token) error {
+	return c.post("/groups/"+gid+"/expenses", amountRequest{Amount: amount, Currency: currency, Date: &date}, nil, t)
 }
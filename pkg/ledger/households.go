@@ -0,0 +1,57 @@
+package ledger
+
+import "time"
+
+// Household is a set of group members which shares one balance inside a group.
+//
+// Household is charged as a single expense participant with specified weight,
+// household debts are held by household holder.
+type Household struct {
+	ID        string    `json:"id"`
+	GroupID   string    `json:"group_id"`
+	Name      string    `json:"name"`
+	HolderID  string    `json:"holder_id"`
+	Weight    int       `json:"weight"`
+	Members   []string  `json:"members"`
+	CreatedBy string    `json:"created_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// HouseholdParams is household creation params
+type HouseholdParams struct {
+	Name    string   `json:"name"`
+	Members []string `json:"members"`
+
+	// HolderID is optional ID of household member who holds household balance.
+	HolderID string `json:"holder_id,omitempty"`
+
+	// Weight is optional number of expense shares charged to household.
+	Weight int `json:"weight,omitempty"`
+}
+
+type householdsResponse struct {
+	Households []Household `json:"households"`
+}
+
+// CreateHousehold creates a new household inside a group
+func (c Client) CreateHousehold(gid string, params HouseholdParams, t Token) (*Household, error) {
+	out := new(Household)
+	return out, c.post("/groups/"+gid+"/households", params, out, t)
+}
+
+// GroupHouseholds returns all group households
+func (c Client) GroupHouseholds(gid string, t Token) ([]Household, error) {
+	out := new(householdsResponse)
+	return out.Households, c.get("/groups/"+gid+"/households", out, t)
+}
+
+// GroupHousehold returns group household by ID
+func (c Client) GroupHousehold(gid, hid string, t Token) (*Household, error) {
+	out := new(Household)
+	return out, c.get("/groups/"+gid+"/households/"+hid, out, t)
+}
+
+// DeleteHousehold deletes group household
+func (c Client) DeleteHousehold(gid, hid string, t Token) error {
+	return c.delete("/groups/"+gid+"/households/"+hid, t)
+}
@@ -0,0 +1,60 @@
+package ledger
+
+import "time"
+
+// Invite is shareable group invitation
+type Invite struct {
+	Token     string    `json:"token"`
+	GroupID   string    `json:"group_id"`
+	CreatedBy string    `json:"created_by"`
+	Role      string    `json:"role"`
+	MaxUses   int       `json:"max_uses"`
+	Uses      int       `json:"uses"`
+	CreatedAt time.Time `json:"created_at"`
+	ExpiresAt time.Time `json:"expires_at"`
+
+	// PlaceholderID is ID of placeholder member claimed by invite.
+	PlaceholderID string `json:"placeholder_id,omitempty"`
+}
+
+// InviteParams is group invite parameters
+type InviteParams struct {
+	// Role is optional role of joined members.
+	Role string `json:"role,omitempty"`
+
+	// MaxUses is max number of invite uses. Zero value means unlimited.
+	MaxUses int `json:"max_uses,omitempty"`
+
+	// ExpiresIn is invite lifetime in seconds. Server uses default lifetime if zero.
+	ExpiresIn int64 `json:"expires_in,omitempty"`
+
+	// PlaceholderID is optional ID of placeholder member claimed by invite.
+	PlaceholderID string `json:"placeholder_id,omitempty"`
+}
+
+type invitesResponse struct {
+	Invites []Invite `json:"invites"`
+}
+
+// CreateInvite creates a new group invite
+func (c Client) CreateInvite(gid string, params InviteParams, t Token) (*Invite, error) {
+	out := new(Invite)
+	return out, c.post("/groups/"+gid+"/invites", params, out, t)
+}
+
+// GroupInvites returns list of active group invites
+func (c Client) GroupInvites(gid string, t Token) ([]Invite, error) {
+	out := new(invitesResponse)
+	return out.Invites, c.get("/groups/"+gid+"/invites", out, t)
+}
+
+// RevokeInvite removes group invite
+func (c Client) RevokeInvite(gid, token string, t Token) error {
+	return c.delete("/groups/"+gid+"/invites/"+token, t)
+}
+
+// AcceptInvite joins a group using invite token
+func (c Client) AcceptInvite(token string, t Token) (*Group, error) {
+	out := new(Group)
+	return out, c.post("/invites/"+token+"/accept", nil, out, t)
+}
@@ -0,0 +1,69 @@
+package ledger
+
+import (
+	"net/url"
+	"time"
+)
+
+const (
+	JoinRequestPending  = "pending"
+	JoinRequestApproved = "approved"
+	JoinRequestDenied   = "denied"
+)
+
+// JoinRequest is user request to join a group
+type JoinRequest struct {
+	ID        string     `json:"id"`
+	GroupID   string     `json:"group_id"`
+	UserID    string     `json:"user_id"`
+	Message   string     `json:"message"`
+	Status    string     `json:"status"`
+	CreatedAt time.Time  `json:"created_at"`
+	DecidedBy string     `json:"decided_by,omitempty"`
+	DecidedAt *time.Time `json:"decided_at,omitempty"`
+}
+
+type joinRequestCreate struct {
+	Message string `json:"message"`
+}
+
+type joinRequestsResponse struct {
+	Requests []JoinRequest `json:"requests"`
+}
+
+// RequestJoin sends request to join a group
+func (c Client) RequestJoin(gid, msg string, t Token) (*JoinRequest, error) {
+	out := new(JoinRequest)
+	return out, c.post("/groups/"+gid+"/join-requests", joinRequestCreate{Message: msg}, out, t)
+}
+
+// GroupJoinRequests returns group join requests with specified status.
+//
+// Pending requests are returned if status is empty.
+func (c Client) GroupJoinRequests(gid, status string, t Token) ([]JoinRequest, error) {
+	out := new(joinRequestsResponse)
+	path := "/groups/" + gid + "/join-requests"
+	if status != "" {
+		path += "?" + url.Values{"status": {status}}.Encode()
+	}
+
+	return out.Requests, c.get(path, out, t)
+}
+
+// UserJoinRequests returns join requests of current user
+func (c Client) UserJoinRequests(t Token) ([]JoinRequest, error) {
+	out := new(joinRequestsResponse)
+	return out.Requests, c.get("/users/self/join-requests", out, t)
+}
+
+// ApproveJoinRequest approves join request and adds requester to a group
+func (c Client) ApproveJoinRequest(gid, rid string, t Token) (*JoinRequest, error) {
+	out := new(JoinRequest)
+	return out, c.post("/groups/"+gid+"/join-requests/"+rid+"/approve", nil, out, t)
+}
+
+// DenyJoinRequest rejects join request
+func (c Client) DenyJoinRequest(gid, rid string, t Token) (*JoinRequest, error) {
+	out := new(JoinRequest)
+	return out, c.post("/groups/"+gid+"/join-requests/"+rid+"/deny", nil, out, t)
+}
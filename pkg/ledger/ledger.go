@@ -0,0 +1,29 @@
+package ledger
+
+import "net/url"
+
+type BrokenLink struct {
+	Seq           int64  `json:"seq"`
+	TransactionID string `json:"transaction_id"`
+	Reason        string `json:"reason"`
+}
+
+type ChainReport struct {
+	Valid      bool        `json:"valid"`
+	Checked    int64       `json:"checked"`
+	Head       string      `json:"head"`
+	BrokenLink *BrokenLink `json:"broken_link"`
+}
+
+// VerifyLedger verifies ledger hash chain
+//
+// If head is not empty, chain is also checked to contain previously recorded head.
+func (c Client) VerifyLedger(head string, t Token) (*ChainReport, error) {
+	rsp := new(ChainReport)
+	path := "/admin/ledger/verify"
+	if head != "" {
+		path += "?" + url.Values{"head": {head}}.Encode()
+	}
+
+	return rsp, c.get(path, rsp, t)
+}
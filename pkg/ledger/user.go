@@ -1,9 +1,17 @@
 package ledger
 
+import "net/http"
+
 type User struct {
 	ID    string `json:"id"`
 	Email string `json:"email"`
 	Name  string `json:"name"`
+
+	// Placeholder marks unregistered placeholder member.
+	Placeholder bool `json:"placeholder,omitempty"`
+
+	// ClaimedBy is ID of user who claimed placeholder.
+	ClaimedBy string `json:"claimed_by,omitempty"`
 }
 
 type UsersResponse struct {
@@ -11,12 +19,16 @@ type UsersResponse struct {
 }
 
 type Balance struct {
-	UserID  string `json:"user_id"`
-	Balance int64  `json:"balance"`
+	UserID   string `json:"user_id"`
+	Currency string `json:"currency"`
+	Balance  int64  `json:"balance"`
 }
 
+// Balances is list of balances grouped by currency
+type Balances = map[string][]Balance
+
 type balanceResponse struct {
-	Status []Balance `json:"status"`
+	Status Balances `json:"status"`
 }
 
 func (c Client) Users(t Token) ([]User, error) {
@@ -34,7 +46,40 @@ func (c Client) CurrentUser(t Token) (*User, error) {
 	return rsp, c.get("/users/self", rsp, t)
 }
 
-func (c Client) Balance(t Token) ([]Balance, error) {
+func (c Client) Balance(t Token) (Balances, error) {
 	rsp := new(balanceResponse)
 	return rsp.Status, c.get("/users/self/balance", rsp, t)
 }
+
+// ProfileUpdate is partial user profile update. Only non-empty fields are changed.
+type ProfileUpdate struct {
+	Name  string `json:"name,omitempty"`
+	Email string `json:"email,omitempty"`
+}
+
+// Profile is user profile with pending email change
+type Profile struct {
+	User
+
+	// PendingEmail is a new email which waits for confirmation
+	PendingEmail string `json:"pending_email,omitempty"`
+}
+
+// UpdateProfile changes current user name and email.
+//
+// Email is changed only after confirmation with a token sent to a new email, see ConfirmEmail.
+func (c Client) UpdateProfile(upd ProfileUpdate, t Token) (*Profile, error) {
+	req, err := c.newRequest(http.MethodPatch, "/users/self", upd, t)
+	if err != nil {
+		return nil, err
+	}
+
+	rsp := new(Profile)
+	return rsp, c.do(req, rsp)
+}
+
+// ConfirmEmail confirms current user email change
+func (c Client) ConfirmEmail(token string, t Token) (*User, error) {
+	rsp := new(User)
+	return rsp, c.post("/users/self/email/confirm", map[string]string{"token": token}, rsp, t)
+}